@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/config"
+	ctrl "github.com/mercari/certificate-expiry-monitor-controller/controller"
+	logging "github.com/mercari/certificate-expiry-monitor-controller/log"
+	"github.com/mercari/certificate-expiry-monitor-controller/report"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Exit codes of check subcommand.
+const (
+	exitCheckOK       = 0
+	exitCheckError    = 1
+	exitCheckFindings = 2
+)
+
+// runCheck runs check subcommand, that verifies certificates once and writes the report to stdout.
+// It returns exitCheckFindings when any certificate is at the level of --fail-on or more severe,
+// so that CI pipelines can fail on findings.
+func runCheck(args []string, stdout, stderr io.Writer) int {
+	// Parse configurations from environment variables as defaults of flags.
+	var env config.Env
+	if err := env.ParseEnv(); err != nil {
+		fmt.Fprintf(stderr, "[ERROR] Failed to parse environement variables: %s\n", err.Error())
+		return exitCheckError
+	}
+
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	once := flags.Bool("once", true, "Verify certificates once and exit. check supports only one-shot mode.")
+	output := flags.String("output", report.FormatTable, "Output format: table, json, csv or markdown.")
+	failOn := flags.String("fail-on", "warning", "Exit with code 2 when any certificate is at this level or more severe: none, error, warning or critical.")
+	kubeconfig := flags.String("kubeconfig", env.KubeconfigPath, "Path to kubeconfig. When empty, the default kubeconfig or in-cluster config is used.")
+	threshold := flags.Duration("threshold", env.AlertThreshold, "Certificates expiring within threshold are WARNING.")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s check [flags]\n\nVerifies certificates in Ingresses once and writes the report to stdout.\n\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitCheckOK
+		}
+		return exitCheckError
+	}
+
+	if !*once {
+		fmt.Fprintln(stderr, "[ERROR] check supports only --once. Run without subcommand to verify certificates continuously")
+		return exitCheckError
+	}
+
+	var failLevel report.Status
+	if *failOn != "none" {
+		level, err := report.ParseStatus(*failOn)
+		if err != nil || level == report.StatusOK {
+			fmt.Fprintf(stderr, "[ERROR] --fail-on must be none, error, warning or critical: %s\n", *failOn)
+			return exitCheckError
+		}
+		failLevel = level
+	}
+
+	// Validate the format before verification that takes time.
+	if err := report.Format(io.Discard, *output, nil, time.Now()); err != nil {
+		fmt.Fprintf(stderr, "[ERROR] Invalid --output: %s\n", err.Error())
+		return exitCheckError
+	}
+
+	restConfig, err := newCheckRestConfig(*kubeconfig)
+	if err != nil {
+		fmt.Fprintf(stderr, "[ERROR] Failed to create clientSet: %s\n", err.Error())
+		return exitCheckError
+	}
+
+	clientSet, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		fmt.Fprintf(stderr, "[ERROR] Failed to create clientSet: %s\n", err.Error())
+		return exitCheckError
+	}
+
+	// Logs of probes are written to stderr, so that stdout has only the report.
+	logger, err := logging.NewLoggerWithOutput(env.LogLevel, "stderr")
+	if err != nil {
+		fmt.Fprintf(stderr, "[ERROR] Failed to create logger: %s\n", err.Error())
+		return exitCheckError
+	}
+
+	// check does not send alerts nor manage synthetics tests.
+	controller, err := ctrl.NewController(logger, clientSet, env.VerifyInterval, *threshold, nil, nil)
+	if err != nil {
+		fmt.Fprintf(stderr, "[ERROR] Failed to create controller: %s\n", err.Error())
+		return exitCheckError
+	}
+	controller.Source.ClusterName = env.ClusterName
+
+	now := time.Now()
+	reports, err := controller.RunOnce(now)
+	if err != nil {
+		fmt.Fprintf(stderr, "[ERROR] Failed to verify certificates: %s\n", err.Error())
+		return exitCheckError
+	}
+
+	if err := report.Format(stdout, *output, reports, now); err != nil {
+		fmt.Fprintf(stderr, "[ERROR] Failed to write report: %s\n", err.Error())
+		return exitCheckError
+	}
+
+	return checkExitCode(reports, failLevel)
+}
+
+// checkExitCode returns exitCheckFindings if any certificate of reports is at failLevel or more severe.
+// If failLevel is empty, checkExitCode always returns exitCheckOK.
+func checkExitCode(reports []*report.Report, failLevel report.Status) int {
+	if failLevel == "" {
+		return exitCheckOK
+	}
+
+	for _, r := range reports {
+		for _, tls := range r.TLS {
+			if tls.Status.AtLeast(failLevel) {
+				return exitCheckFindings
+			}
+		}
+	}
+
+	return exitCheckOK
+}
+
+// newCheckRestConfig returns config of Kubernetes's clients for check subcommand.
+// When kubeconfigPath is empty, it reads KUBECONFIG or ~/.kube/config, and falls back to internal cluster config,
+// so that check works on laptops as well as in CI pods.
+func newCheckRestConfig(kubeconfigPath string) (*rest.Config, error) {
+	if kubeconfigPath != "" {
+		return clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+}
+
+// isCheckCommand reports whether args run check subcommand.
+func isCheckCommand(args []string) bool {
+	return len(args) > 1 && args[1] == "check"
+}
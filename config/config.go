@@ -2,9 +2,15 @@ package config
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics"
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics/checkly"
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics/datadog"
 )
 
 const (
@@ -14,29 +20,93 @@ const (
 	lowerThresholdHours  = 24 // THRESHOLD must be more than 24 hours
 )
 
+// clusterNamePattern restricts CLUSTER_NAME to characters that Datadog keeps in tags as they are.
+var clusterNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*$`)
+
 // Env struct defines configuration of controller that provided by ENV.
 type Env struct {
 	// Original configurations
 	LogLevel       string        `envconfig:"LOG_LEVEL" default:"INFO"`
+	ClusterName    string        `envconfig:"CLUSTER_NAME"`
 	KubeconfigPath string        `envconfig:"KUBE_CONFIG_PATH"`
 	VerifyInterval time.Duration `envconfig:"INTERVAL" default:"12h"`
 	AlertThreshold time.Duration `envconfig:"THRESHOLD" default:"336h"`
 	Notifiers      []string      `envconfig:"NOTIFIERS" default:"log"`
 	TestManager    bool          `envconfig:"SYNTHETICS_ENABLED" default:"false"`
+	Provider       string        `envconfig:"SYNTHETICS_PROVIDER" default:"datadog"`
+	DigestEnabled  bool          `envconfig:"DIGEST_ENABLED" default:"false"`
+	DigestInterval time.Duration `envconfig:"DIGEST_INTERVAL" default:"0"`
+	RoutingConfig  string        `envconfig:"ROUTING_CONFIG_PATH"`
+	SilencesPath   string        `envconfig:"SILENCES_PATH"`
+	DryRun         bool          `envconfig:"DRY_RUN" default:"false"`
+
+	// Configuration for Kubernetes Events and annotations of Ingress
+	EventsEnabled   bool `envconfig:"EVENTS_ENABLED" default:"true"`
+	AnnotateIngress bool `envconfig:"ANNOTATE_INGRESS" default:"false"`
+	ReportsEnabled  bool `envconfig:"REPORTS_ENABLED" default:"false"`
+
+	// Configuration for leader election
+	LeaderElectionEnabled   bool   `envconfig:"LEADER_ELECTION_ENABLED" default:"false"`
+	LeaderElectionNamespace string `envconfig:"LEADER_ELECTION_NAMESPACE" default:"kube-system"`
+	LeaderElectionID        string `envconfig:"LEADER_ELECTION_ID" default:"certificate-expiry-monitor-controller"`
+	PodName                 string `envconfig:"POD_NAME"`
+
+	// Configuration for HTTP endpoints and timeouts
+	HTTPAddr       string        `envconfig:"HTTP_ADDR" default:":8080"`
+	PprofEnabled   bool          `envconfig:"PPROF_ENABLED" default:"false"`
+	RunTimeout     time.Duration `envconfig:"RUN_TIMEOUT" default:"30m"`
+	TLSDialTimeout time.Duration `envconfig:"TLS_DIAL_TIMEOUT" default:"10s"`
+
+	// Configuration for alert templates
+	TemplateConfigMap string `envconfig:"TEMPLATE_CONFIGMAP"`
+	SlackTemplatePath string `envconfig:"SLACK_TEMPLATE_PATH"`
+	LogTemplatePath   string `envconfig:"LOG_TEMPLATE_PATH"`
+	EmailTemplatePath string `envconfig:"EMAIL_TEMPLATE_PATH"`
 
 	// Configration for Slack
-	SlackToken   string `envconfig:"SLACK_TOKEN"`
-	SlackChannel string `envconfig:"SLACK_CHANNEL"`
+	SlackToken         string   `envconfig:"SLACK_TOKEN"`
+	SlackChannel       string   `envconfig:"SLACK_CHANNEL"`
+	SlackChannelRoutes string   `envconfig:"SLACK_CHANNEL_ROUTES"`
+	SlackWebhookURLs   []string `envconfig:"SLACK_WEBHOOK_URL"`
+
+	// Configuration for Email
+	EmailSMTPHost     string   `envconfig:"EMAIL_SMTP_HOST"`
+	EmailSMTPPort     int      `envconfig:"EMAIL_SMTP_PORT" default:"587"`
+	EmailSMTPUsername string   `envconfig:"EMAIL_SMTP_USERNAME"`
+	EmailSMTPPassword string   `envconfig:"EMAIL_SMTP_PASSWORD"`
+	EmailFrom         string   `envconfig:"EMAIL_FROM"`
+	EmailTo           []string `envconfig:"EMAIL_TO"`
 
 	// Configuration for Datadog
 	DatadogAPIKey       string   `envconfig:"DATADOG_API_KEY" default:""`
 	DatadogAppKey       string   `envconfig:"DATADOG_APPLICATION_KEY" default:""`
+	DatadogSite         string   `envconfig:"DD_SITE" default:"datadoghq.com"`
 	AlertMessage        string   `envconfig:"SYNTHETICS_ALERT_MESSAGE" default:""`
 	CheckInterval       int      `envconfig:"SYNTHETICS_CHECK_INTERVAL" default:"900"`
 	Tags                []string `envconfig:"SYNTHETICS_TAGS" default:""`
 	DefaultTag          string   `envconfig:"SYNTHETICS_DEFAULT_TAG" default:"managed-by-cert-expiry-mon"`
 	DefaultLocations    []string `envconfig:"SYNTHETICS_DEFAULT_LOCATIONS" default:"aws:ap-northeast-1"`
+	PrivateLocations    []string `envconfig:"SYNTHETICS_PRIVATE_LOCATIONS" default:""`
 	AdditionalEndpoints []string `envconfig:"SYNTHETICS_ADDITIONAL_ENDPOINTS" default:""`
+
+	// SyntheticsInterval is the interval of reconciliation of synthetics tests, independent of INTERVAL.
+	SyntheticsInterval time.Duration `envconfig:"SYNTHETICS_INTERVAL" default:"10m"`
+
+	// Configuration for Checkly
+	ChecklyAPIKey    string   `envconfig:"CHECKLY_API_KEY"`
+	ChecklyAccountID string   `envconfig:"CHECKLY_ACCOUNT_ID"`
+	ChecklyLocations []string `envconfig:"CHECKLY_LOCATIONS" default:"ap-northeast-1"`
+
+	// Configuration for assertions of synthetics tests
+	CertificateDays  int           `envconfig:"SYNTHETICS_CERTIFICATE_DAYS" default:"0"`
+	MinTLSVersion    string        `envconfig:"SYNTHETICS_MIN_TLS_VERSION"`
+	MaxResponseTime  time.Duration `envconfig:"SYNTHETICS_MAX_RESPONSE_TIME" default:"0"`
+	AcceptSelfSigned bool          `envconfig:"SYNTHETICS_ACCEPT_SELF_SIGNED" default:"false"`
+
+	// Configuration for the safeguard against mass deletion of synthetics tests
+	MaxDeleteCount  int     `envconfig:"SYNTHETICS_MAX_DELETE_COUNT" default:"10"`
+	MaxDeleteRatio  float64 `envconfig:"SYNTHETICS_MAX_DELETE_RATIO" default:"0.5"`
+	DeleteAfterRuns int     `envconfig:"SYNTHETICS_DELETE_AFTER_RUNS" default:"3"`
 }
 
 // ParseEnv function sets to Env struct and verify it.
@@ -69,6 +139,74 @@ func (e *Env) validate() error {
 			e.AlertThreshold.Hours() >= lowerThresholdHours,
 			fmt.Sprintf("THRESHOLD must be more than %d hours", lowerThresholdHours),
 		},
+		{
+			e.ClusterName == "" || clusterNamePattern.MatchString(e.ClusterName),
+			"CLUSTER_NAME must consist of lower case alphanumeric characters, '-', '_' or '.'",
+		},
+		{
+			e.DigestInterval >= 0,
+			"DIGEST_INTERVAL must not be negative",
+		},
+		{
+			e.RunTimeout >= 0,
+			"RUN_TIMEOUT must not be negative",
+		},
+		{
+			e.TLSDialTimeout >= 0,
+			"TLS_DIAL_TIMEOUT must not be negative",
+		},
+		{
+			e.CertificateDays >= 0,
+			"SYNTHETICS_CERTIFICATE_DAYS must not be negative",
+		},
+		{
+			e.MaxResponseTime >= 0,
+			"SYNTHETICS_MAX_RESPONSE_TIME must not be negative",
+		},
+		{
+			e.MaxDeleteCount >= 0,
+			"SYNTHETICS_MAX_DELETE_COUNT must not be negative",
+		},
+		{
+			e.MaxDeleteRatio >= 0 && e.MaxDeleteRatio <= 1,
+			"SYNTHETICS_MAX_DELETE_RATIO must be between 0 and 1",
+		},
+		{
+			e.DeleteAfterRuns >= 0,
+			"SYNTHETICS_DELETE_AFTER_RUNS must not be negative",
+		},
+		{
+			!e.TestManager || e.SyntheticsInterval.Minutes() >= lowerIntervalMinutes,
+			fmt.Sprintf("SYNTHETICS_INTERVAL must be more than %d minutes", lowerIntervalMinutes),
+		},
+		{
+			e.Provider == "" || e.Provider == datadog.String() || e.Provider == checkly.String(),
+			fmt.Sprintf("SYNTHETICS_PROVIDER must be %s or %s", datadog.String(), checkly.String()),
+		},
+		{
+			e.DatadogSite == "" || datadog.ValidateSite(e.DatadogSite) == nil,
+			"DD_SITE must be a Datadog site, e.g. datadoghq.com or datadoghq.eu",
+		},
+		{
+			datadog.ValidatePrivateLocations(e.PrivateLocations) == nil,
+			"SYNTHETICS_PRIVATE_LOCATIONS must be IDs of private locations prefixed by pl:",
+		},
+		{
+			synthetics.ValidateTLSVersion(e.MinTLSVersion) == nil,
+			"SYNTHETICS_MIN_TLS_VERSION must be 1.0, 1.1, 1.2 or 1.3",
+		},
+		{
+			synthetics.ValidateTags(e.Tags) == nil,
+			"SYNTHETICS_TAGS must not contain tags prefixed by cluster:, which is reserved for CLUSTER_NAME",
+		},
+		{
+			synthetics.ValidateEndpoints(e.AdditionalEndpoints) == nil,
+			"SYNTHETICS_ADDITIONAL_ENDPOINTS must be endpoints formatted as host, host:port or [IPv6]:port, where host is a valid DNS name or IP address",
+		},
+		{
+			e.TemplateConfigMap == "" || len(strings.Split(e.TemplateConfigMap, "/")) == 2,
+			"TEMPLATE_CONFIGMAP must be formatted as <namespace>/<name>",
+		},
 	}
 
 	for _, v := range validations {
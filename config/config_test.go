@@ -27,12 +27,66 @@ func TestDefaultEnv(t *testing.T) {
 	if len(env.Notifiers) != 1 || env.Notifiers[0] != "log" {
 		t.Fatal("Unexpected default value in NOTIFIERS")
 	}
+	if env.DigestEnabled {
+		t.Fatal("Unexpected default value in DIGEST_ENABLED")
+	}
+	if env.DigestInterval != 0 {
+		t.Fatal("Unexpected default value in DIGEST_INTERVAL")
+	}
+	if env.ClusterName != "" {
+		t.Fatal("Unexpected default value in CLUSTER_NAME")
+	}
+	if env.DryRun {
+		t.Fatal("Unexpected default value in DRY_RUN")
+	}
+	if !env.EventsEnabled {
+		t.Fatal("Unexpected default value in EVENTS_ENABLED")
+	}
+	if env.AnnotateIngress {
+		t.Fatal("Unexpected default value in ANNOTATE_INGRESS")
+	}
+	if env.ReportsEnabled {
+		t.Fatal("Unexpected default value in REPORTS_ENABLED")
+	}
+	if env.LeaderElectionEnabled {
+		t.Fatal("Unexpected default value in LEADER_ELECTION_ENABLED")
+	}
+	if env.LeaderElectionNamespace != "kube-system" {
+		t.Fatal("Unexpected default value in LEADER_ELECTION_NAMESPACE")
+	}
+	if env.LeaderElectionID != "certificate-expiry-monitor-controller" {
+		t.Fatal("Unexpected default value in LEADER_ELECTION_ID")
+	}
+	if env.HTTPAddr != ":8080" {
+		t.Fatal("Unexpected default value in HTTP_ADDR")
+	}
+	if env.PprofEnabled {
+		t.Fatal("Unexpected default value in PPROF_ENABLED")
+	}
+	if env.RunTimeout != 30*time.Minute {
+		t.Fatal("Unexpected default value in RUN_TIMEOUT")
+	}
+	if env.TLSDialTimeout != 10*time.Second {
+		t.Fatal("Unexpected default value in TLS_DIAL_TIMEOUT")
+	}
+	if env.EmailSMTPPort != 587 {
+		t.Fatal("Unexpected default value in EMAIL_SMTP_PORT")
+	}
 	if env.DatadogAPIKey != "" {
 		t.Fatal("Unexpected default value in DATADOG_API_KEY")
 	}
 	if env.DatadogAppKey != "" {
 		t.Fatal("Unexpected default value in DATADOG_APPLICATION_KEY")
 	}
+	if env.Provider != "datadog" {
+		t.Fatal("Unexpected default value in SYNTHETICS_PROVIDER")
+	}
+	if len(env.ChecklyLocations) != 1 || env.ChecklyLocations[0] != "ap-northeast-1" {
+		t.Fatal("Unexpected default value in CHECKLY_LOCATIONS")
+	}
+	if env.DatadogSite != "datadoghq.com" {
+		t.Fatal("Unexpected default value in DD_SITE")
+	}
 	if env.TestManager {
 		t.Fatal("Unexpected default value in SYNTHETICS_ENABLED")
 	}
@@ -48,9 +102,36 @@ func TestDefaultEnv(t *testing.T) {
 	if env.AdditionalEndpoints != nil {
 		t.Fatal("Unexpected default value in SYNTHETICS_ADDITIONAL_ENDPOINTS")
 	}
+	if env.SyntheticsInterval != 10*time.Minute {
+		t.Fatal("Unexpected default value in SYNTHETICS_INTERVAL")
+	}
 	if env.DefaultLocations == nil {
 		t.Fatal("Unexpected default value in SYNTHETICS_DEFAULT_LOCATIONS")
 	}
+	if env.PrivateLocations != nil {
+		t.Fatal("Unexpected default value in SYNTHETICS_PRIVATE_LOCATIONS")
+	}
+	if env.CertificateDays != 0 {
+		t.Fatal("Unexpected default value in SYNTHETICS_CERTIFICATE_DAYS")
+	}
+	if env.MinTLSVersion != "" {
+		t.Fatal("Unexpected default value in SYNTHETICS_MIN_TLS_VERSION")
+	}
+	if env.MaxResponseTime != 0 {
+		t.Fatal("Unexpected default value in SYNTHETICS_MAX_RESPONSE_TIME")
+	}
+	if env.AcceptSelfSigned {
+		t.Fatal("Unexpected default value in SYNTHETICS_ACCEPT_SELF_SIGNED")
+	}
+	if env.MaxDeleteCount != 10 {
+		t.Fatal("Unexpected default value in SYNTHETICS_MAX_DELETE_COUNT")
+	}
+	if env.MaxDeleteRatio != 0.5 {
+		t.Fatal("Unexpected default value in SYNTHETICS_MAX_DELETE_RATIO")
+	}
+	if env.DeleteAfterRuns != 3 {
+		t.Fatal("Unexpected default value in SYNTHETICS_DELETE_AFTER_RUNS")
+	}
 }
 
 func TestValidate(t *testing.T) {
@@ -86,6 +167,118 @@ func TestValidate(t *testing.T) {
 			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 23},
 			expected: false,
 		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, RunTimeout: -time.Minute},
+			expected: false,
+		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, MinTLSVersion: "1.4"},
+			expected: false,
+		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, AdditionalEndpoints: []string{"example.com:8443", "[2001:db8::1]:443"}},
+			expected: true,
+		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, AdditionalEndpoints: []string{"example.com:70000"}},
+			expected: false,
+		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, Tags: []string{"team:sre", "cluster:x"}},
+			expected: false,
+		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, MaxDeleteRatio: 1.5},
+			expected: false,
+		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, TestManager: true, SyntheticsInterval: time.Second * 30},
+			expected: false,
+		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, TestManager: true, SyntheticsInterval: time.Minute * 5},
+			expected: true,
+		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, ClusterName: "prod-tokyo.1"},
+			expected: true,
+		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, ClusterName: "Prod/Tokyo"},
+			expected: false,
+		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, DatadogSite: "datadoghq.eu"},
+			expected: true,
+		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, DatadogSite: "example.com"},
+			expected: false,
+		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, PrivateLocations: []string{"pl:my-location-1234"}},
+			expected: true,
+		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, PrivateLocations: []string{"aws:us-east-2"}},
+			expected: false,
+		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, Provider: "checkly"},
+			expected: true,
+		},
+		struct {
+			env      *Env
+			expected bool
+		}{
+			env:      &Env{VerifyInterval: time.Hour * 24, AlertThreshold: time.Hour * 24, Provider: "grafana"},
+			expected: false,
+		},
 	}
 
 	for _, test := range tests {
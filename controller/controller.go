@@ -3,16 +3,20 @@ package controller
 import (
 	"crypto/x509"
 	"errors"
+	"sort"
+	"sync"
 	"time"
 
-	synthetics "github.com/mercari/certificate-expiry-monitor-controller/synthetics/datadog"
-
 	"go.uber.org/zap"
 
 	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/report"
+	"github.com/mercari/certificate-expiry-monitor-controller/silence"
 	"github.com/mercari/certificate-expiry-monitor-controller/source"
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics"
 
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 )
 
 // onIteration is called when the starting runOnce. Used in testing.
@@ -26,7 +30,42 @@ type Controller struct {
 	VerifyInterval time.Duration
 	AlertThreshold time.Duration
 	Notifiers      []notifier.Notifier
-	TestManager    *synthetics.TestManager
+
+	// When Synthetics is set, controller reconciles synthetics tests with TLS endpoints of Ingresses
+	// and AdditionalEndpoints, formatted as `host[:port]`.
+	// Synthetics tests are reconciled at SyntheticsInterval and when Ingresses change, independently of verifications.
+	Synthetics          synthetics.SyntheticsProvider
+	AdditionalEndpoints []string
+	SyntheticsInterval  time.Duration
+
+	// When DigestEnabled is true, controller collects findings and sends them as one digest
+	// at DigestInterval instead of one alert per certificate.
+	// If DigestInterval is zero, controller sends the digest at every runOnce.
+	DigestEnabled  bool
+	DigestInterval time.Duration
+
+	// When Recorder is set, controller records Warning Events of findings on the Ingress.
+	// When AnnotateIngress is true, controller patches NotAfterAnnotation of each Ingress.
+	Recorder        record.EventRecorder
+	AnnotateIngress bool
+
+	// Inventory holds reports of the latest check.
+	// When ReportWriter is set, controller writes reports as CertificateReport custom resources.
+	Inventory    *report.Inventory
+	ReportWriter *report.Writer
+
+	// Silences suppress notifications of matching certificates in addition to silence.SnoozeAnnotation.
+	Silences []silence.Silence
+
+	// RunTimeout is the maximum time of runOnce before Healthy reports that the controller is stuck.
+	RunTimeout time.Duration
+
+	health          health
+	pendingFindings map[string]notifier.Finding
+	lastDigestTime  time.Time
+
+	// lastMassDeletionNotice is the time of the last notice of refused deletion of synthetics tests.
+	lastMassDeletionNotice time.Time
 }
 
 // NewController function validates arguments and
@@ -37,7 +76,7 @@ func NewController(
 	interval time.Duration,
 	threshold time.Duration,
 	notifiers []notifier.Notifier,
-	testManager *synthetics.TestManager,
+	provider synthetics.SyntheticsProvider,
 ) (*Controller, error) {
 	if clientSet == nil {
 		return nil, errors.New("clientSet must be non nil value")
@@ -53,19 +92,37 @@ func NewController(
 		VerifyInterval: interval,
 		AlertThreshold: threshold,
 		Notifiers:      notifiers,
-		TestManager:    testManager,
+		Synthetics:     provider,
+		Inventory:      report.NewInventory(),
+		RunTimeout:     DefaultRunTimeout,
+
+		SyntheticsInterval: DefaultSyntheticsInterval,
 	}, nil
 }
 
 // Run function starts execution loop that executes runOnce at VerifyInterval.
-// If stopCh receives message, Run function terminates execution loop.
+// When Synthetics is set, Run also starts RunSynthetics in another goroutine,
+// so that slow or failing APIs of the provider do not delay verifications.
+// If stopCh receives message, Run function terminates execution loop and waits for RunSynthetics to terminate.
 func (c *Controller) Run(stopCh chan struct{}) {
 	c.Logger.Info("Starting controller...")
 
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	if c.Synthetics != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.RunSynthetics(stopCh)
+		}()
+	}
+
 	for {
 		onIteration()
 		currentTime := time.Now()
+		c.health.start(currentTime)
 		err := c.runOnce(currentTime)
+		c.health.finish(time.Now())
 		if err != nil {
 			c.Logger.Error("Failed to run runOnce: %s", zap.Error(err))
 		}
@@ -79,6 +136,16 @@ func (c *Controller) Run(stopCh chan struct{}) {
 	}
 }
 
+// RunOnce function verifies certificates once at currentTime, and returns reports of the verification.
+// It is used by one-shot mode that does not run execution loop.
+func (c *Controller) RunOnce(currentTime time.Time) ([]*report.Report, error) {
+	if err := c.runOnce(currentTime); err != nil {
+		return nil, err
+	}
+
+	return c.Inventory.List(), nil
+}
+
 func (c *Controller) runOnce(currentTime time.Time) error {
 	ingresses, err := c.Source.Ingresses()
 	if err != nil {
@@ -86,37 +153,24 @@ func (c *Controller) runOnce(currentTime time.Time) error {
 	}
 	thresholdTime := currentTime.Add(c.AlertThreshold)
 
-	syntheticEndpoints := make(synthetics.SyntheticEndpoints)
+	var findings []notifier.Finding
+	var reports []*report.Report
 
 	for _, ingress := range ingresses {
-		for _, tls := range ingress.TLS {
-
-			// Add non overlapping endpoints to a list to manage synthetic tests
-			for _, tlsEndpoint := range tls.Endpoints {
-				s, err := synthetics.SyntheticEndpoint{}.FromHostPortStr(tlsEndpoint.Hostname, tlsEndpoint.Port)
-
-				if err != nil {
-					c.Logger.Warn("Failed to parse synthetic endpoint", zap.Error(err))
-				}
-
-				syntheticEndpoints.Add(s)
-			}
-
-			var certificates []*x509.Certificate
-			for _, e := range tls.Endpoints {
-				certificates, err = e.GetCertificates()
-				if err != nil {
-					c.Logger.Warn("Detect error when GetCertificates()", zap.String("host", e.Hostname+":"+e.Port), zap.Error(err))
-					continue
-				}
-
-				// Controller assumes that IngressTLS has one certificate chain and all endpoints associated it.
-				// So, if detect certificate chain the first time, break loop and verify it.
-				break
-			}
+		ingressReport := &report.Report{
+			ClusterName:   ingress.ClusterName,
+			Namespace:     ingress.Namespace,
+			Ingress:       ingress.Name,
+			UID:           ingress.UID,
+			LastCheckTime: currentTime,
+		}
 
+		for _, tls := range ingress.TLS {
+			certificates, tlsReport := c.probe(currentTime, tls)
 			if len(certificates) == 0 {
 				c.Logger.Warn("Remote endpoints has no certificates, but endpoints enabled TLS")
+				tlsReport.Status = report.StatusError
+				ingressReport.Add(tlsReport)
 				continue
 			}
 
@@ -124,56 +178,233 @@ func (c *Controller) runOnce(currentTime time.Time) error {
 			// TODO: able to verify root and intermediate certificate by option
 			expiration := certificates[0].NotAfter
 
-			opt := notifier.Option{}
+			opt := notifier.Option{Certificate: certificates[0], CheckedAt: currentTime}
+			tlsReport.Status = report.StatusOK
 			if expiration.Before(currentTime) {
 				// If certificate has been expired.
 				opt.AlertLevel = notifier.AlertLevelCritical
+				tlsReport.Status = report.StatusCritical
 			} else if expiration.Before(thresholdTime) {
 				// If certificates has been reached the thresholdTime.
 				opt.AlertLevel = notifier.AlertLevelWarning
-			} else {
+				tlsReport.Status = report.StatusWarning
+			}
+			ingressReport.Add(tlsReport)
+
+			if tlsReport.Status == report.StatusOK {
 				// This expiration has not reached the threshold.
+				// Forget the pending finding and notify resolvers in case the certificate has been renewed since the last alert.
+				delete(c.pendingFindings, notifier.Finding{Ingress: ingress, TLS: tls}.Key())
+				for _, n := range c.Notifiers {
+					if r, ok := n.(notifier.Resolver); ok {
+						if err := r.Resolve(expiration, ingress, tls); err != nil {
+							c.Logger.Warn("Failed to send Resolve", zap.Error(err))
+						}
+					}
+				}
 				continue
 			}
 
-			// Send Alert to all notifiers.
-			for _, notifier := range c.Notifiers {
-				err := notifier.Alert(expiration, ingress, tls, opt)
+			finding := notifier.Finding{Expiration: expiration, Ingress: ingress, TLS: tls, Option: opt}
+
+			// Events show the state of the certificate, so that they are recorded even if notifications are suppressed.
+			c.recordEvent(currentTime, finding)
+
+			if reason, until := c.suppressed(currentTime, finding); reason != "" {
+				delete(c.pendingFindings, finding.Key())
+				c.Logger.Info("Suppressed notification",
+					zap.String("namespace", ingress.Namespace),
+					zap.String("ingress", ingress.Name),
+					zap.Strings("hosts", finding.Hosts()),
+					zap.String("level", opt.AlertLevel.String()),
+					zap.String("reason", reason),
+					zap.Time("until", until),
+				)
+				continue
+			}
 
-				if err != nil {
-					c.Logger.Warn("Failed to send Alert", zap.Error(err))
+			if c.DigestEnabled {
+				if c.pendingFindings == nil {
+					c.pendingFindings = make(map[string]notifier.Finding)
 				}
+				c.pendingFindings[finding.Key()] = finding
+				continue
 			}
+
+			findings = append(findings, finding)
 		}
-	}
 
-	if c.TestManager.Enabled {
-		// Create managed synthetics tests matching the Ingress endpoint list
-		c.Logger.Info("Checking if tests need to be created")
+		if len(ingressReport.TLS) == 0 {
+			continue
+		}
+		reports = append(reports, ingressReport)
 
-		for _, e := range c.TestManager.AdditionalEndpoints {
-			s, err := (synthetics.SyntheticEndpoint{}).FromString(e)
+		if c.AnnotateIngress && ingressReport.NotAfter != nil {
+			c.annotate(ingress, *ingressReport.NotAfter)
+		}
+	}
 
-			if err != nil {
-				c.Logger.Warn("Failed to parse synthetic endpoint", zap.Error(err))
+	c.Inventory.Update(reports)
+	if c.ReportWriter != nil {
+		for _, r := range reports {
+			if err := c.ReportWriter.Write(r); err != nil {
+				c.Logger.Warn("Failed to write CertificateReport", zap.String("namespace", r.Namespace), zap.String("ingress", r.Ingress), zap.Error(err))
 			}
+		}
+	}
+
+	// Send Alert to all notifiers.
+	// Critical findings are sent first, so that notifiers can inhibit warnings by them.
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Option.AlertLevel > findings[j].Option.AlertLevel
+	})
+	for _, f := range findings {
+		c.alert(c.Notifiers, f)
+	}
+
+	if c.DigestEnabled {
+		c.sendDigest(currentTime)
+	}
+
+	return nil
+}
+
+// probe gets certificate chains from all endpoints of tls, and returns the chain used to verify the expiration with the result of probes.
+// Controller assumes that IngressTLS has one certificate chain and all endpoints associated it.
+// So, the first certificate chain is used to verify the expiration.
+func (c *Controller) probe(currentTime time.Time, tls *source.IngressTLS) ([]*x509.Certificate, report.TLS) {
+	result := report.TLS{
+		SecretName: tls.SecretName,
+		Endpoints:  make([]report.Endpoint, 0, len(tls.Endpoints)),
+	}
+
+	var certificates []*x509.Certificate
+	for _, e := range tls.Endpoints {
+		endpoint := report.Endpoint{Host: e.Hostname, Port: e.Port}
 
-			syntheticEndpoints.Add(s)
+		chain, err := e.GetCertificates()
+		if err != nil {
+			c.Logger.Warn("Detect error when GetCertificates()", zap.Stringer("host", e), zap.Error(err))
+			endpoint.Error = err.Error()
+			result.LastError = err.Error()
+		} else {
+			endpoint.Chain = report.NewChain(chain)
+			if len(certificates) == 0 && len(chain) > 0 {
+				certificates = chain
+				result.NotAfter = &chain[0].NotAfter
+				result.LastSuccessfulProbeTime = &currentTime
+			}
 		}
 
-		err = c.TestManager.CreateManagedSyntheticsTests(syntheticEndpoints)
+		result.Endpoints = append(result.Endpoints, endpoint)
+	}
+
+	if len(certificates) == 0 && result.LastError == "" {
+		result.LastError = "Remote endpoints has no certificates"
+	}
+
+	return certificates, result
+}
+
+// suppressed returns the reason and the end of suppression if notifications of the finding are suppressed at currentTime.
+// Notifications are suppressed by silence.SnoozeAnnotation of Ingress or TLS Secret, or by Silences.
+// If the finding is not suppressed, suppressed returns the empty reason.
+func (c *Controller) suppressed(currentTime time.Time, finding notifier.Finding) (string, time.Time) {
+	until, err := silence.SnoozedUntil(finding.Ingress.Annotations)
+	if err != nil {
+		c.Logger.Warn("Failed to parse snooze annotation of Ingress", zap.String("namespace", finding.Ingress.Namespace), zap.String("ingress", finding.Ingress.Name), zap.Error(err))
+	} else if currentTime.Before(until) {
+		return "Ingress annotation " + silence.SnoozeAnnotation, until
+	}
+
+	if finding.TLS.SecretName != "" {
+		annotations, err := c.Source.SecretAnnotations(finding.Ingress.Namespace, finding.TLS.SecretName)
 		if err != nil {
-			c.Logger.Warn("Failed to create synthetics tests", zap.Error(err))
+			c.Logger.Debug("Failed to get annotations of Secret", zap.String("namespace", finding.Ingress.Namespace), zap.String("secret", finding.TLS.SecretName), zap.Error(err))
+		} else if until, err := silence.SnoozedUntil(annotations); err != nil {
+			c.Logger.Warn("Failed to parse snooze annotation of Secret", zap.String("namespace", finding.Ingress.Namespace), zap.String("secret", finding.TLS.SecretName), zap.Error(err))
+		} else if currentTime.Before(until) {
+			return "Secret annotation " + silence.SnoozeAnnotation, until
+		}
+	}
+
+	hostnames := make([]string, len(finding.TLS.Endpoints))
+	for i, e := range finding.TLS.Endpoints {
+		hostnames[i] = e.Hostname
+	}
+
+	for _, s := range c.Silences {
+		if s.Active(currentTime) && s.Match(finding.Ingress.Namespace, hostnames, finding.Option.Certificate) {
+			reason := "silence"
+			if s.Comment != "" {
+				reason += ": " + s.Comment
+			}
+			return reason, s.Until
 		}
+	}
+
+	return "", time.Time{}
+}
 
-		c.Logger.Info("Checking if orphaned tests need to be deleted")
+// alert sends the finding to notifiers.
+func (c *Controller) alert(notifiers []notifier.Notifier, finding notifier.Finding) {
+	for _, n := range notifiers {
+		err := n.Alert(finding.Expiration, finding.Ingress, finding.TLS, finding.Option)
 
-		// Delete managed synthetics tests not matching the Ingress endpoint list
-		err := c.TestManager.DeleteManagedSyntheticsTests(syntheticEndpoints)
 		if err != nil {
-			c.Logger.Warn("Failed to delete synthetics tests", zap.Error(err))
+			c.Logger.Warn("Failed to send Alert", zap.Error(err))
 		}
 	}
+}
 
-	return nil
+// notice sends the notice to notifiers that support notices.
+func (c *Controller) notice(notice notifier.Notice) {
+	for _, n := range c.Notifiers {
+		nn, ok := n.(notifier.NoticeNotifier)
+		if !ok {
+			continue
+		}
+
+		if err := nn.Notice(notice); err != nil {
+			c.Logger.Warn("Failed to send Notice", zap.Error(err))
+		}
+	}
+}
+
+// sendDigest sends pending findings as one digest when DigestInterval has elapsed since the last digest.
+// Notifiers that do not support digest receive the findings as individual alerts.
+func (c *Controller) sendDigest(currentTime time.Time) {
+	if len(c.pendingFindings) == 0 {
+		return
+	}
+
+	if c.DigestInterval > 0 && !c.lastDigestTime.IsZero() && currentTime.Sub(c.lastDigestTime) < c.DigestInterval {
+		return
+	}
+
+	digest := notifier.Digest{GeneratedAt: currentTime}
+	for _, f := range c.pendingFindings {
+		digest.Findings = append(digest.Findings, f)
+	}
+	digest.Findings = digest.Sorted()
+
+	var others []notifier.Notifier
+	for _, n := range c.Notifiers {
+		d, ok := n.(notifier.DigestNotifier)
+		if !ok {
+			others = append(others, n)
+			continue
+		}
+
+		if err := d.Digest(digest); err != nil {
+			c.Logger.Warn("Failed to send Digest", zap.Error(err))
+		}
+	}
+
+	for _, f := range digest.Findings {
+		c.alert(others, f)
+	}
+
+	c.pendingFindings = nil
+	c.lastDigestTime = currentTime
 }
@@ -1,26 +1,31 @@
 package controller
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
 	"time"
 
-	synthetics "github.com/mercari/certificate-expiry-monitor-controller/synthetics/datadog"
-
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
 
 	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
 	"github.com/mercari/certificate-expiry-monitor-controller/notifier/log"
+	"github.com/mercari/certificate-expiry-monitor-controller/report"
+	"github.com/mercari/certificate-expiry-monitor-controller/silence"
 	"github.com/mercari/certificate-expiry-monitor-controller/source"
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics"
 
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
@@ -29,12 +34,12 @@ const (
 
 func TestNewController(t *testing.T) {
 	type testArg struct {
-		logger      *zap.Logger
-		clientSet   kubernetes.Interface
-		interval    time.Duration
-		threshold   time.Duration
-		notifiers   []notifier.Notifier
-		testManager *synthetics.TestManager
+		logger    *zap.Logger
+		clientSet kubernetes.Interface
+		interval  time.Duration
+		threshold time.Duration
+		notifiers []notifier.Notifier
+		provider  synthetics.SyntheticsProvider
 	}
 	tests := []struct {
 		arg     testArg
@@ -71,7 +76,7 @@ func TestNewController(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		_, err := NewController(test.arg.logger, test.arg.clientSet, test.arg.interval, test.arg.threshold, test.arg.notifiers, test.arg.testManager)
+		_, err := NewController(test.arg.logger, test.arg.clientSet, test.arg.interval, test.arg.threshold, test.arg.notifiers, test.arg.provider)
 
 		if (err == nil) != test.success {
 			t.Fatalf("Unexpected result with error: %s", err.Error())
@@ -95,10 +100,8 @@ func TestRun(t *testing.T) {
 	threshold := 24 * time.Hour
 	notifiers := []notifier.Notifier{log.NewNotifier(zap.NewNop())}
 	clientSet := makeTestClientSet(t, []string{u.Hostname()})
-	testManager, err := synthetics.NewTestManager("api_key", "app_key")
-	testManager.Client = nil
 
-	controller, err := NewController(zap.New(core), clientSet, interval, threshold, notifiers, testManager)
+	controller, err := NewController(zap.New(core), clientSet, interval, threshold, notifiers, nil)
 	if err != nil {
 		t.Fatalf("Unexpected falied to initialize controller: %s", err.Error())
 	}
@@ -144,8 +147,6 @@ func TestRunOnce(t *testing.T) {
 	interval := 10 * time.Hour
 	threshold := 48 * time.Hour
 	clientSet := makeTestClientSet(t, []string{u.Hostname()})
-	testManager, _ := synthetics.NewTestManager("api_key", "app_key")
-	testManager.Client = nil
 
 	tests := []struct {
 		arg                time.Time
@@ -183,7 +184,7 @@ func TestRunOnce(t *testing.T) {
 
 		notifiers := []notifier.Notifier{log.NewNotifier(zap.New(core))}
 
-		controller, err := NewController(zap.NewNop(), clientSet, interval, threshold, notifiers, testManager)
+		controller, err := NewController(zap.NewNop(), clientSet, interval, threshold, notifiers, nil)
 		if err != nil {
 			t.Fatalf("Unexpected falied to initialize controller: %s", err.Error())
 		}
@@ -200,6 +201,288 @@ func TestRunOnce(t *testing.T) {
 	}
 }
 
+func TestRunOnceDigest(t *testing.T) {
+	server := httptest.NewTLSServer(http.NewServeMux())
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	// Prefetch certs to get expiration for testing
+	expectedCerts, _ := source.NewTLSEndpoint(u.Hostname(), u.Port()).GetCertificates()
+	expiration := expectedCerts[0].NotAfter
+
+	// Overwrite default port number to test server.URL
+	source.DefaultPortNumber = u.Port()
+
+	core, recorded := observer.New(zapcore.InfoLevel)
+	notifiers := []notifier.Notifier{log.NewNotifier(zap.New(core))}
+	clientSet := makeTestClientSet(t, []string{u.Hostname()})
+
+	controller, err := NewController(zap.NewNop(), clientSet, 10*time.Hour, 48*time.Hour, notifiers, nil)
+	if err != nil {
+		t.Fatalf("Unexpected falied to initialize controller: %s", err.Error())
+	}
+	controller.DigestEnabled = true
+	controller.DigestInterval = 24 * time.Hour
+
+	tests := []struct {
+		arg                 time.Time
+		expectedDigestCount int
+	}{
+		{
+			// The first digest is sent immediately.
+			arg:                 expiration,
+			expectedDigestCount: 1,
+		},
+		{
+			// DigestInterval has not elapsed.
+			arg:                 expiration.Add(time.Hour),
+			expectedDigestCount: 1,
+		},
+		{
+			// DigestInterval has elapsed.
+			arg:                 expiration.Add(25 * time.Hour),
+			expectedDigestCount: 2,
+		},
+	}
+
+	for _, test := range tests {
+		if err := controller.runOnce(test.arg); err != nil {
+			t.Fatalf("Unexpected falied to run runOnce: %s", err.Error())
+		}
+
+		if count := recorded.FilterMessage("DIGEST").Len(); count != test.expectedDigestCount {
+			t.Fatalf("Unexpected number of digests: %d", count)
+		}
+		if count := recorded.FilterMessage("ALERT").Len(); count != 0 {
+			t.Fatalf("Unexpected number of alerts: %d", count)
+		}
+	}
+
+	// The digest includes the expired certificate.
+	last := recorded.FilterMessage("DIGEST").All()[1]
+	if last.ContextMap()["CRITICAL"] != int64(1) {
+		t.Fatalf("Unexpected digest: %v", last.ContextMap())
+	}
+}
+
+func TestRunOnceSilence(t *testing.T) {
+	server := httptest.NewTLSServer(http.NewServeMux())
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	// Prefetch certs to get expiration for testing
+	expectedCerts, _ := source.NewTLSEndpoint(u.Hostname(), u.Port()).GetCertificates()
+	expiration := expectedCerts[0].NotAfter
+	serial := fmt.Sprintf("%X", expectedCerts[0].SerialNumber)
+
+	// Overwrite default port number to test server.URL
+	source.DefaultPortNumber = u.Port()
+
+	tests := []struct {
+		secretAnnotations map[string]string
+		silences          []silence.Silence
+		expectedAlerts    int
+	}{
+		{
+			// no silences
+			expectedAlerts: 1,
+		},
+		{
+			// snoozed by the Secret annotation
+			secretAnnotations: map[string]string{silence.SnoozeAnnotation: expiration.Add(time.Hour).Format(time.RFC3339)},
+			expectedAlerts:    0,
+		},
+		{
+			// the snooze has expired
+			secretAnnotations: map[string]string{silence.SnoozeAnnotation: expiration.Add(-time.Hour).Format(time.RFC3339)},
+			expectedAlerts:    1,
+		},
+		{
+			// invalid annotation is ignored
+			secretAnnotations: map[string]string{silence.SnoozeAnnotation: "tomorrow"},
+			expectedAlerts:    1,
+		},
+		{
+			// silenced by namespace and host
+			silences:       []silence.Silence{{Namespace: "namespace*", Host: u.Hostname(), Until: expiration.Add(time.Hour)}},
+			expectedAlerts: 0,
+		},
+		{
+			// silenced by serial number
+			silences:       []silence.Silence{{Serial: serial, Until: expiration.Add(time.Hour)}},
+			expectedAlerts: 0,
+		},
+		{
+			// the silence has expired
+			silences:       []silence.Silence{{Namespace: "namespace1", Until: expiration.Add(-time.Hour)}},
+			expectedAlerts: 1,
+		},
+		{
+			// the silence does not match
+			silences:       []silence.Silence{{Namespace: "namespace2", Until: expiration.Add(time.Hour)}},
+			expectedAlerts: 1,
+		},
+	}
+
+	for i, test := range tests {
+		clientSet := makeTestClientSet(t, []string{u.Hostname()})
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "ingressSecret1",
+				Namespace:   "namespace1",
+				Annotations: test.secretAnnotations,
+			},
+		}
+		if _, err := clientSet.CoreV1().Secrets("namespace1").Create(context.TODO(), secret, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Unexpected failed to create Secret: %s", err.Error())
+		}
+
+		core, recorded := observer.New(zapcore.InfoLevel)
+		notifiers := []notifier.Notifier{log.NewNotifier(zap.New(core))}
+
+		controller, err := NewController(zap.NewNop(), clientSet, 10*time.Hour, 48*time.Hour, notifiers, nil)
+		if err != nil {
+			t.Fatalf("Unexpected falied to initialize controller: %s", err.Error())
+		}
+		controller.Silences = test.silences
+
+		if err := controller.runOnce(expiration); err != nil {
+			t.Fatalf("Unexpected falied to run runOnce: %s", err.Error())
+		}
+
+		if count := recorded.FilterMessage("ALERT").Len(); count != test.expectedAlerts {
+			t.Fatalf("Unexpected number of alerts in case %d: %d", i, count)
+		}
+	}
+}
+
+func TestRunOnceEvents(t *testing.T) {
+	server := httptest.NewTLSServer(http.NewServeMux())
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	// Prefetch certs to get expiration for testing
+	expectedCerts, _ := source.NewTLSEndpoint(u.Hostname(), u.Port()).GetCertificates()
+	expiration := expectedCerts[0].NotAfter
+
+	// Overwrite default port number to test server.URL
+	source.DefaultPortNumber = u.Port()
+
+	tests := []struct {
+		arg            time.Time
+		expectedEvents []string
+	}{
+		{
+			// expiration has not reached the threshold.
+			arg:            expiration.Add(-96 * time.Hour),
+			expectedEvents: nil,
+		},
+		{
+			// expiration reached the threshold.
+			arg:            expiration.Add(-25 * time.Hour),
+			expectedEvents: []string{"Warning CertificateExpiringSoon Certificate of secret \"ingressSecret1\" for hosts [" + u.Host + "] expires at " + expiration.UTC().Format(time.RFC3339) + " (in 1 days)"},
+		},
+		{
+			// expired less than a day ago
+			arg:            expiration.Add(time.Hour),
+			expectedEvents: []string{"Warning CertificateExpired Certificate of secret \"ingressSecret1\" for hosts [" + u.Host + "] expired at " + expiration.UTC().Format(time.RFC3339) + " (0 days ago)"},
+		},
+		{
+			// already expired
+			arg:            expiration.Add(49 * time.Hour),
+			expectedEvents: []string{"Warning CertificateExpired Certificate of secret \"ingressSecret1\" for hosts [" + u.Host + "] expired at " + expiration.UTC().Format(time.RFC3339) + " (2 days ago)"},
+		},
+	}
+
+	for i, test := range tests {
+		clientSet := makeTestClientSet(t, []string{u.Hostname()})
+		recorder := record.NewFakeRecorder(10)
+
+		controller, err := NewController(zap.NewNop(), clientSet, 10*time.Hour, 48*time.Hour, nil, nil)
+		if err != nil {
+			t.Fatalf("Unexpected falied to initialize controller: %s", err.Error())
+		}
+		controller.Recorder = recorder
+		controller.AnnotateIngress = true
+
+		if err := controller.runOnce(test.arg); err != nil {
+			t.Fatalf("Unexpected falied to run runOnce: %s", err.Error())
+		}
+		close(recorder.Events)
+
+		var events []string
+		for e := range recorder.Events {
+			events = append(events, e)
+		}
+		if fmt.Sprint(events) != fmt.Sprint(test.expectedEvents) {
+			t.Fatalf("Unexpected events in case %d: %v", i, events)
+		}
+
+		// The annotation is patched regardless of the level.
+		ingress, err := clientSet.NetworkingV1().Ingresses("namespace1").Get(context.TODO(), "ingress1", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Unexpected failed to get Ingress: %s", err.Error())
+		}
+		if actual := ingress.Annotations[NotAfterAnnotation]; actual != expiration.UTC().Format(time.RFC3339) {
+			t.Fatalf("Unexpected annotation in case %d: %q", i, actual)
+		}
+	}
+}
+
+func TestRunOnceReports(t *testing.T) {
+	server := httptest.NewTLSServer(http.NewServeMux())
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	// Prefetch certs to get expiration for testing
+	expectedCerts, _ := source.NewTLSEndpoint(u.Hostname(), u.Port()).GetCertificates()
+	expiration := expectedCerts[0].NotAfter
+
+	// Overwrite default port number to test server.URL
+	source.DefaultPortNumber = u.Port()
+
+	controller, err := NewController(zap.NewNop(), makeTestClientSet(t, []string{u.Hostname()}), 10*time.Hour, 48*time.Hour, nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected falied to initialize controller: %s", err.Error())
+	}
+
+	if err := controller.runOnce(expiration); err != nil {
+		t.Fatalf("Unexpected falied to run runOnce: %s", err.Error())
+	}
+
+	tests := []struct {
+		namespace      string
+		name           string
+		expectedStatus report.Status
+	}{
+		{namespace: "namespace1", name: "ingress1", expectedStatus: report.StatusWarning},
+		{namespace: "namespace3", name: "ingress3", expectedStatus: report.StatusError},
+		{namespace: "namespace4", name: "ingress4", expectedStatus: report.StatusError},
+	}
+
+	if reports := controller.Inventory.List(); len(reports) != len(tests) {
+		t.Fatalf("Unexpected number of reports: %d", len(reports))
+	}
+
+	for _, test := range tests {
+		r, ok := controller.Inventory.Get(test.namespace, test.name)
+		if !ok {
+			t.Fatalf("Not found report of %s/%s", test.namespace, test.name)
+		}
+		if r.Status != test.expectedStatus {
+			t.Fatalf("Unexpected status of %s/%s: %s", test.namespace, test.name, r.Status)
+		}
+		if r.Status == report.StatusError && r.LastError == "" {
+			t.Fatalf("Unexpected empty last error of %s/%s", test.namespace, test.name)
+		}
+	}
+
+	r, _ := controller.Inventory.Get("namespace1", "ingress1")
+	if !r.NotAfter.Equal(expiration) || r.LastSuccessfulProbeTime == nil || len(r.TLS[0].Endpoints) != 1 || len(r.TLS[0].Endpoints[0].Chain) == 0 {
+		t.Fatalf("Unexpected report of namespace1/ingress1: %v", r)
+	}
+}
+
 func makeTestClientSet(t *testing.T, availableHosts []string) kubernetes.Interface {
 	t.Helper()
 
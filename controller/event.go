@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Reasons of Events recorded on the Ingress.
+const (
+	ReasonCertificateExpiringSoon = "CertificateExpiringSoon"
+	ReasonCertificateExpired      = "CertificateExpired"
+)
+
+// NotAfterAnnotation is the annotation of Ingress that holds the earliest expiration of its certificates formatted as RFC 3339.
+const NotAfterAnnotation = "cert-expiry-monitor/not-after"
+
+// recordEvent records the Warning Event of the finding on the Ingress.
+func (c *Controller) recordEvent(currentTime time.Time, finding notifier.Finding) {
+	if c.Recorder == nil {
+		return
+	}
+
+	reason := ReasonCertificateExpiringSoon
+	if finding.Option.AlertLevel == notifier.AlertLevelCritical {
+		reason = ReasonCertificateExpired
+	}
+
+	c.Recorder.Event(ingressReference(finding.Ingress), corev1.EventTypeWarning, reason, eventMessage(currentTime, finding))
+}
+
+// eventMessage returns the message of the Event, e.g.
+// `Certificate of secret "foo" for hosts [example.com:443] expires at 2020-01-01T00:00:00Z (in 3 days)`.
+func eventMessage(currentTime time.Time, finding notifier.Finding) string {
+	hosts := "[" + strings.Join(finding.Hosts(), " ") + "]"
+	expiration := finding.Expiration.UTC().Format(time.RFC3339)
+
+	if finding.Expiration.Before(currentTime) {
+		days := int64(currentTime.Sub(finding.Expiration).Hours() / 24)
+		return fmt.Sprintf("Certificate of secret %q for hosts %s expired at %s (%d days ago)", finding.TLS.SecretName, hosts, expiration, days)
+	}
+	days := int64(finding.Expiration.Sub(currentTime).Hours() / 24)
+	return fmt.Sprintf("Certificate of secret %q for hosts %s expires at %s (in %d days)", finding.TLS.SecretName, hosts, expiration, days)
+}
+
+// annotate patches NotAfterAnnotation of the Ingress with notAfter.
+// The Ingress is not patched if the annotation already has the same value.
+func (c *Controller) annotate(ingress *source.Ingress, notAfter time.Time) {
+	value := notAfter.UTC().Format(time.RFC3339)
+	if ingress.Annotations[NotAfterAnnotation] == value {
+		return
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{NotAfterAnnotation: value},
+		},
+	})
+	if err != nil {
+		c.Logger.Warn("Failed to create patch of Ingress", zap.Error(err))
+		return
+	}
+
+	err = c.Source.PatchIngress(ingress.Namespace, ingress.Name, types.MergePatchType, patch)
+	if err != nil {
+		c.Logger.Warn("Failed to patch annotation of Ingress", zap.String("namespace", ingress.Namespace), zap.String("ingress", ingress.Name), zap.Error(err))
+	}
+}
+
+// ingressReference returns the reference to the Ingress that Events are recorded on.
+func ingressReference(ingress *source.Ingress) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		Kind:       "Ingress",
+		APIVersion: "networking.k8s.io/v1",
+		Namespace:  ingress.Namespace,
+		Name:       ingress.Name,
+		UID:        ingress.UID,
+	}
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultRunTimeout is the default maximum time of runOnce before the controller is regarded as stuck.
+const DefaultRunTimeout = 30 * time.Minute
+
+// health holds the state of execution loop to check liveness.
+type health struct {
+	mu         sync.Mutex
+	running    bool
+	startedAt  time.Time
+	finishedAt time.Time
+}
+
+func (h *health) start(t time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.running = true
+	h.startedAt = t
+}
+
+func (h *health) finish(t time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.running = false
+	h.finishedAt = t
+}
+
+// Healthy returns error if execution loop is stuck at now.
+// The loop is stuck when runOnce has been running longer than RunTimeout,
+// or the next runOnce has not started within VerifyInterval and RunTimeout after the last one.
+// Before the first runOnce, e.g. while waiting for leader election, the controller is healthy.
+// If RunTimeout is zero, the controller is always healthy.
+func (c *Controller) Healthy(now time.Time) error {
+	c.health.mu.Lock()
+	defer c.health.mu.Unlock()
+
+	if c.RunTimeout == 0 {
+		return nil
+	}
+
+	if c.health.running {
+		if elapsed := now.Sub(c.health.startedAt); elapsed > c.RunTimeout {
+			return fmt.Errorf("runOnce has been running for %s", elapsed.Round(time.Second))
+		}
+		return nil
+	}
+
+	if !c.health.finishedAt.IsZero() {
+		if elapsed := now.Sub(c.health.finishedAt); elapsed > c.VerifyInterval+c.RunTimeout {
+			return fmt.Errorf("runOnce has not started for %s", elapsed.Round(time.Second))
+		}
+	}
+
+	return nil
+}
+
+// Ready returns error if the controller is not ready to verify certificates.
+// The controller is ready when Kubernetes API is reachable and notifiers are configured.
+func (c *Controller) Ready() error {
+	if len(c.Notifiers) == 0 {
+		return errors.New("no notifiers are configured")
+	}
+
+	if _, err := c.Source.ClientSet.Discovery().ServerVersion(); err != nil {
+		return fmt.Errorf("Kubernetes API is unreachable: %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier/log"
+)
+
+func TestHealthy(t *testing.T) {
+	controller, err := NewController(zap.NewNop(), makeTestClientSet(t, []string{}), time.Hour, 24*time.Hour, nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected falied to initialize controller: %s", err.Error())
+	}
+	controller.RunTimeout = 10 * time.Minute
+
+	now := time.Now()
+
+	// Before the first runOnce.
+	if err := controller.Healthy(now); err != nil {
+		t.Fatalf("Unexpected unhealthy before the first runOnce: %s", err.Error())
+	}
+
+	controller.health.start(now)
+	if err := controller.Healthy(now.Add(5 * time.Minute)); err != nil {
+		t.Fatalf("Unexpected unhealthy while running: %s", err.Error())
+	}
+	if err := controller.Healthy(now.Add(11 * time.Minute)); err == nil {
+		t.Fatal("Unexpected healthy when runOnce is stuck")
+	}
+
+	controller.health.finish(now.Add(time.Minute))
+	if err := controller.Healthy(now.Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected unhealthy while waiting for the next runOnce: %s", err.Error())
+	}
+	if err := controller.Healthy(now.Add(2 * time.Hour)); err == nil {
+		t.Fatal("Unexpected healthy when the next runOnce has not started")
+	}
+}
+
+func TestReady(t *testing.T) {
+	tests := []struct {
+		notifiers []notifier.Notifier
+		success   bool
+	}{
+		{
+			notifiers: []notifier.Notifier{log.NewNotifier(zap.NewNop())},
+			success:   true,
+		},
+		{
+			notifiers: nil,
+			success:   false,
+		},
+	}
+
+	for _, test := range tests {
+		controller, err := NewController(zap.NewNop(), makeTestClientSet(t, []string{}), time.Hour, 24*time.Hour, test.notifiers, nil)
+		if err != nil {
+			t.Fatalf("Unexpected falied to initialize controller: %s", err.Error())
+		}
+
+		if err := controller.Ready(); (err == nil) != test.success {
+			t.Fatalf("Unexpected result with notifiers %v: %v", test.notifiers, err)
+		}
+	}
+}
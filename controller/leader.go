@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// Default timings of leader election, same as kube-controller-manager.
+const (
+	DefaultLeaseDuration = 15 * time.Second
+	DefaultRenewDeadline = 10 * time.Second
+	DefaultRetryPeriod   = 2 * time.Second
+)
+
+// ErrLeaderElectionLost is returned by RunWithLeaderElection when the controller lost the lease.
+var ErrLeaderElectionLost = errors.New("leader election lost")
+
+// LeaderElection expresses the configuration of Lease based leader election.
+// Identity must be unique between replicas, e.g. the name of Pod.
+type LeaderElection struct {
+	Namespace     string
+	Name          string
+	Identity      string
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+// RunWithLeaderElection function runs Run only while the controller holds the Lease,
+// so that only one replica verifies certificates, sends alerts and manages synthetics tests.
+// If stopCh receives message, RunWithLeaderElection stops Run, then releases the Lease for fast failover and returns nil.
+// The Lease is released only after Run returns, so that the next leader never runs concurrently with this replica.
+// If the controller lost the Lease, RunWithLeaderElection returns ErrLeaderElectionLost,
+// since the state of the controller can not be trusted after another replica became the leader.
+func (c *Controller) RunWithLeaderElection(le LeaderElection, stopCh chan struct{}) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// runStopCh stops Run, and the elector is cancelled, i.e. the Lease is released, after Run returns.
+	runStopCh := make(chan struct{})
+	startedCh, doneCh := make(chan struct{}), make(chan struct{})
+	go func() {
+		select {
+		case <-stopCh:
+			close(runStopCh)
+			select {
+			case <-startedCh:
+				<-doneCh
+			default:
+			}
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Namespace: le.Namespace,
+			Name:      le.Name,
+		},
+		Client: c.Source.ClientSet.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: le.Identity,
+		},
+	}
+
+	lost := false
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   le.LeaseDuration,
+		RenewDeadline:   le.RenewDeadline,
+		RetryPeriod:     le.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            le.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				close(startedCh)
+				defer close(doneCh)
+				if ctx.Err() != nil {
+					return
+				}
+				select {
+				case <-runStopCh:
+					return
+				default:
+				}
+
+				c.Logger.Info("Started leading", zap.String("identity", le.Identity))
+
+				leaderStopCh := make(chan struct{})
+				go func() {
+					select {
+					case <-ctx.Done():
+					case <-runStopCh:
+					}
+					close(leaderStopCh)
+				}()
+
+				c.Run(leaderStopCh)
+			},
+			OnStoppedLeading: func() {
+				select {
+				case <-stopCh:
+					c.Logger.Info("Released leader election lease", zap.String("identity", le.Identity))
+				default:
+					lost = true
+					c.Logger.Error("Lost leader election lease", zap.String("identity", le.Identity))
+				}
+			},
+			OnNewLeader: func(identity string) {
+				if identity != le.Identity {
+					c.Logger.Info("New leader elected", zap.String("leader", identity))
+				}
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Logger.Info("Waiting for leader election...", zap.String("lease", le.Namespace+"/"+le.Name))
+	elector.Run(ctx)
+
+	// Wait for the running verification to finish.
+	select {
+	case <-startedCh:
+		<-doneCh
+	default:
+	}
+
+	if lost {
+		return ErrLeaderElectionLost
+	}
+	return nil
+}
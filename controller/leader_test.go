@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRunWithLeaderElection(t *testing.T) {
+	clientSet := makeTestClientSet(t, []string{})
+
+	newLeaderElection := func(identity string) LeaderElection {
+		return LeaderElection{
+			Namespace:     "kube-system",
+			Name:          "certificate-expiry-monitor-controller",
+			Identity:      identity,
+			LeaseDuration: 1 * time.Second,
+			RenewDeadline: 500 * time.Millisecond,
+			RetryPeriod:   100 * time.Millisecond,
+		}
+	}
+
+	core1, recorded1 := observer.New(zapcore.InfoLevel)
+	controller1, err := NewController(zap.New(core1), clientSet, time.Hour, 24*time.Hour, nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected falied to initialize controller: %s", err.Error())
+	}
+
+	core2, recorded2 := observer.New(zapcore.InfoLevel)
+	controller2, err := NewController(zap.New(core2), clientSet, time.Hour, 24*time.Hour, nil, nil)
+	if err != nil {
+		t.Fatalf("Unexpected falied to initialize controller: %s", err.Error())
+	}
+
+	stopCh1, errCh1 := make(chan struct{}), make(chan error, 1)
+	go func() { errCh1 <- controller1.RunWithLeaderElection(newLeaderElection("replica1"), stopCh1) }()
+	waitForMessage(t, recorded1, "Starting controller...")
+
+	stopCh2, errCh2 := make(chan struct{}), make(chan error, 1)
+	go func() { errCh2 <- controller2.RunWithLeaderElection(newLeaderElection("replica2"), stopCh2) }()
+	waitForMessage(t, recorded2, "Waiting for leader election...")
+
+	// The second replica does not run while the first replica holds the lease.
+	time.Sleep(300 * time.Millisecond)
+	if recorded2.FilterMessage("Starting controller...").Len() != 0 {
+		t.Fatal("Unexpected controller started without the lease")
+	}
+
+	// The first replica releases the lease on stop, and the second replica takes over.
+	close(stopCh1)
+	if err := <-errCh1; err != nil {
+		t.Fatalf("Unexpected error of stopped replica: %s", err.Error())
+	}
+	waitForMessage(t, recorded2, "Starting controller...")
+
+	// The lease is released only after the first replica terminated.
+	terminated := recorded1.FilterMessage("Terminating controller...").All()
+	started := recorded2.FilterMessage("Starting controller...").All()
+	if len(terminated) != 1 || started[0].Time.Before(terminated[0].Time) {
+		t.Fatalf("Unexpected controller started before the first replica terminated: %v, %v", terminated, started)
+	}
+
+	close(stopCh2)
+	if err := <-errCh2; err != nil {
+		t.Fatalf("Unexpected error of stopped replica: %s", err.Error())
+	}
+}
+
+func waitForMessage(t *testing.T, recorded *observer.ObservedLogs, message string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for recorded.FilterMessage(message).Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Not found expected message: %s", message)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
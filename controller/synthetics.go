@@ -0,0 +1,239 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics"
+
+	v1 "k8s.io/api/networking/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DefaultSyntheticsInterval is the default interval of reconciliation of synthetics tests.
+const DefaultSyntheticsInterval = 10 * time.Minute
+
+// syntheticsDebounce is the delay of reconciliation after Ingresses change,
+// so that a burst of changes, e.g. by a deployment, is reconciled at once. Overwritten in testing.
+var syntheticsDebounce = 10 * time.Second
+
+// syntheticsSyncTimeout is the maximum time to wait for the informer of Ingresses before the first reconciliation.
+const syntheticsSyncTimeout = 30 * time.Second
+
+// RunSynthetics function starts reconciliation loop of synthetics tests that runs at SyntheticsInterval,
+// and after TLS hosts or synthetics annotations of Ingresses change.
+// If stopCh receives message, RunSynthetics cancels the running reconciliation and terminates the loop.
+func (c *Controller) RunSynthetics(stopCh chan struct{}) {
+	c.Logger.Info("Starting synthetics reconciler...", zap.Duration("interval", c.SyntheticsInterval))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	changed := make(chan struct{}, 1)
+	c.watchIngresses(ctx, changed)
+
+	for {
+		c.reconcileSynthetics(ctx)
+
+		if !c.waitSynthetics(ctx, changed) {
+			c.Logger.Info("Terminating synthetics reconciler...")
+			return
+		}
+	}
+}
+
+// waitSynthetics waits for SyntheticsInterval or changes of Ingresses, and returns false if ctx is done.
+func (c *Controller) waitSynthetics(ctx context.Context, changed <-chan struct{}) bool {
+	timer := time.NewTimer(c.SyntheticsInterval)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-changed:
+	}
+
+	c.Logger.Info("Ingresses changed, reconciling synthetics tests", zap.Duration("after", syntheticsDebounce))
+	select {
+	case <-time.After(syntheticsDebounce):
+	case <-ctx.Done():
+		return false
+	}
+
+	// Changes during the debounce are covered by the next reconciliation.
+	select {
+	case <-changed:
+	default:
+	}
+	return true
+}
+
+// watchIngresses starts an informer of Ingresses that sends to changed when synthetics tests may need reconciliation.
+// The informer stops when ctx is done.
+func (c *Controller) watchIngresses(ctx context.Context, changed chan struct{}) {
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+
+	factory := informers.NewSharedInformerFactory(c.Source.ClientSet, 0)
+	informer := factory.Networking().V1().Ingresses().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			notify()
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldIngress, ok := oldObj.(*v1.Ingress)
+			if !ok {
+				return
+			}
+			newIngress, ok := newObj.(*v1.Ingress)
+			if !ok {
+				return
+			}
+			if syntheticsChanged(oldIngress, newIngress) {
+				notify()
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			notify()
+		},
+	})
+	factory.Start(ctx.Done())
+
+	// Ingresses listed at start are reconciled by the first reconciliation.
+	// Without the permission to watch Ingresses, the informer never syncs and tests are reconciled only at SyntheticsInterval.
+	syncCtx, cancel := context.WithTimeout(ctx, syntheticsSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), informer.HasSynced) && ctx.Err() == nil {
+		c.Logger.Warn("Failed to watch Ingresses, synthetics tests are reconciled only at the interval")
+	}
+	select {
+	case <-changed:
+	default:
+	}
+}
+
+// syntheticsChanged returns whether the change of Ingress affects synthetics tests.
+// Other changes, e.g. of the status or NotAfterAnnotation patched by the controller, are ignored.
+func syntheticsChanged(oldIngress, newIngress *v1.Ingress) bool {
+	if !reflect.DeepEqual(oldIngress.Spec.TLS, newIngress.Spec.TLS) {
+		return true
+	}
+	return !reflect.DeepEqual(syntheticsAnnotations(oldIngress.Annotations), syntheticsAnnotations(newIngress.Annotations))
+}
+
+// syntheticsAnnotations returns annotations prefixed by synthetics.AnnotationPrefix.
+func syntheticsAnnotations(annotations map[string]string) map[string]string {
+	filtered := make(map[string]string)
+	for k, v := range annotations {
+		if strings.HasPrefix(k, synthetics.AnnotationPrefix) {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
+// reconcileSynthetics reconciles synthetics tests with TLS endpoints of Ingresses and AdditionalEndpoints.
+// When the safeguard refuses deletion of synthetics tests, reconcileSynthetics sends a critical notice at most once per SyntheticsInterval.
+func (c *Controller) reconcileSynthetics(ctx context.Context) {
+	c.Logger.Info("Reconciling synthetics tests")
+
+	ingresses, err := c.Source.Ingresses()
+	if err != nil {
+		c.Logger.Warn("Failed to list Ingresses for synthetics tests", zap.Error(err))
+		return
+	}
+
+	endpoints := c.syntheticEndpoints(ingresses)
+	for _, e := range c.AdditionalEndpoints {
+		s, err := (synthetics.SyntheticEndpoint{}).FromString(e)
+
+		if err != nil {
+			c.Logger.Warn("Failed to parse synthetic endpoint", zap.Error(err))
+			continue
+		}
+
+		endpoints.Add(s)
+	}
+
+	result, err := c.Synthetics.Reconcile(ctx, endpoints)
+	c.Logger.Info("Reconciled synthetics tests", zap.Object("result", result))
+
+	var massDeletion *synthetics.MassDeletionError
+	if errors.As(err, &massDeletion) {
+		c.Logger.Error("Refused to delete synthetics tests", zap.Int("count", massDeletion.Count), zap.Int("managed", massDeletion.Managed), zap.Error(err))
+		// Reconciliations triggered by changes of Ingresses do not repeat the notice within SyntheticsInterval.
+		if time.Since(c.lastMassDeletionNotice) < c.SyntheticsInterval {
+			return
+		}
+		c.lastMassDeletionNotice = time.Now()
+		c.notice(notifier.Notice{
+			AlertLevel: notifier.AlertLevelCritical,
+			Title:      "Refused to delete synthetics tests",
+			Text:       "The safeguard of synthetics tests refused deletion: " + err.Error() + ". Check whether Ingresses are listed correctly, and raise the limits if the deletion is expected.",
+		})
+	} else if err != nil {
+		c.Logger.Warn("Failed to reconcile synthetics tests", zap.Strings("failed", result.Failed), zap.Error(err))
+	}
+}
+
+// syntheticEndpoints returns non overlapping TLS endpoints of ingresses with settings by their annotations.
+// Endpoints of Ingresses that opted out by synthetics.EnabledAnnotation are excluded.
+func (c *Controller) syntheticEndpoints(ingresses []*source.Ingress) synthetics.SyntheticEndpoints {
+	endpoints := make(synthetics.SyntheticEndpoints)
+
+	for _, ingress := range ingresses {
+		// Synthetics tests can be opted out and configured by annotations of Ingress.
+		enabled, err := synthetics.EnabledFromAnnotations(ingress.Annotations)
+		var settings synthetics.Settings
+		if err == nil {
+			settings, err = synthetics.SettingsFromAnnotations(ingress.Annotations)
+		}
+		if err != nil {
+			c.Logger.Warn("Failed to parse synthetics annotations, using defaults",
+				zap.String("namespace", ingress.Namespace),
+				zap.String("ingress", ingress.Name),
+				zap.Error(err),
+			)
+		}
+		if !enabled {
+			continue
+		}
+
+		for _, tls := range ingress.TLS {
+			for _, tlsEndpoint := range tls.Endpoints {
+				s, err := synthetics.SyntheticEndpoint{}.FromHostPortStr(tlsEndpoint.Hostname, tlsEndpoint.Port)
+
+				if err != nil {
+					c.Logger.Warn("Failed to parse synthetic endpoint", zap.Error(err))
+					continue
+				}
+
+				s.Settings = settings
+				endpoints.Add(s)
+			}
+		}
+	}
+
+	return endpoints
+}
@@ -0,0 +1,287 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier/log"
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics"
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics/datadog"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeSyntheticsClient returns managed tests of names, and records deleted tests.
+type fakeSyntheticsClient struct {
+	names   []string
+	deleted []string
+}
+
+func (f *fakeSyntheticsClient) CreateSyntheticsTest(ctx context.Context, test *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
+	return test, nil
+}
+
+func (f *fakeSyntheticsClient) GetSyntheticsTests(ctx context.Context) ([]datadogV1.SyntheticsAPITest, error) {
+	tests := make([]datadogV1.SyntheticsAPITest, len(f.names))
+	for i, name := range f.names {
+		tests[i].SetName(name)
+		tests[i].SetPublicId(name)
+		tests[i].Tags = []string{"managed-by-cert-expiry-mon"}
+	}
+	return tests, nil
+}
+
+func (f *fakeSyntheticsClient) UpdateSyntheticsTest(ctx context.Context, publicId string, test *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
+	return test, nil
+}
+
+func (f *fakeSyntheticsClient) DeleteSyntheticsTests(ctx context.Context, publicIds []string) error {
+	f.deleted = append(f.deleted, publicIds...)
+	return nil
+}
+
+// fakeSyntheticsProvider records endpoints passed to Reconcile.
+// When reconciled is set, endpoints are also sent to it.
+type fakeSyntheticsProvider struct {
+	endpoints  synthetics.SyntheticEndpoints
+	reconciled chan synthetics.SyntheticEndpoints
+}
+
+func (f *fakeSyntheticsProvider) Reconcile(ctx context.Context, endpoints synthetics.SyntheticEndpoints) (synthetics.Result, error) {
+	f.endpoints = endpoints
+	if f.reconciled != nil {
+		f.reconciled <- endpoints
+	}
+	return synthetics.Result{}, nil
+}
+
+func makeTestIngress(t *testing.T, name string, host string, annotations map[string]string) *v1.Ingress {
+	t.Helper()
+
+	return &v1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "namespace1",
+			Annotations: annotations,
+		},
+		Spec: v1.IngressSpec{
+			TLS: []v1.IngressTLS{{Hosts: []string{host}}},
+		},
+	}
+}
+
+func TestReconcileSyntheticsMassDeletionNotice(t *testing.T) {
+	client := &fakeSyntheticsClient{names: []string{"stale1.example.com-443", "stale2.example.com-443"}}
+	testManager := &datadog.TestManager{
+		Client:         client,
+		Logger:         zap.NewNop(),
+		DefaultTag:     "managed-by-cert-expiry-mon",
+		MaxDeleteCount: 1,
+	}
+
+	core, recorded := observer.New(zapcore.InfoLevel)
+	notifiers := []notifier.Notifier{log.NewNotifier(zap.New(core))}
+
+	controller, err := NewController(zap.NewNop(), fake.NewSimpleClientset(), 10*time.Hour, 48*time.Hour, notifiers, testManager)
+	if err != nil {
+		t.Fatalf("Unexpected falied to initialize controller: %s", err.Error())
+	}
+
+	controller.reconcileSynthetics(context.Background())
+	// Reconciliations within SyntheticsInterval, e.g. triggered by changes of Ingresses, do not repeat the notice.
+	controller.reconcileSynthetics(context.Background())
+
+	if len(client.deleted) != 0 {
+		t.Fatalf("Unexpected deleted tests: %v", client.deleted)
+	}
+	if notices := recorded.FilterMessage("NOTICE"); notices.Len() != 1 {
+		t.Fatalf("Unexpected number of notices: %d", notices.Len())
+	}
+}
+
+func TestReconcileSyntheticsAnnotations(t *testing.T) {
+	clientSet := fake.NewSimpleClientset(
+		makeTestIngress(t, "configured", "a.example.com", map[string]string{
+			synthetics.CheckIntervalAnnotation: "300",
+			synthetics.MentionsAnnotation:      "@slack-sre",
+		}),
+		makeTestIngress(t, "opted-out", "b.example.com", map[string]string{synthetics.EnabledAnnotation: "false"}),
+		makeTestIngress(t, "invalid", "c.example.com", map[string]string{synthetics.CheckIntervalAnnotation: "1"}),
+	)
+
+	provider := &fakeSyntheticsProvider{}
+	controller, err := NewController(zap.NewNop(), clientSet, 10*time.Hour, 48*time.Hour, nil, provider)
+	if err != nil {
+		t.Fatalf("Unexpected falied to initialize controller: %s", err.Error())
+	}
+	controller.AdditionalEndpoints = []string{"d.example.com:8443"}
+
+	controller.reconcileSynthetics(context.Background())
+
+	if len(provider.endpoints) != 3 {
+		t.Fatalf("Unexpected endpoints: %v", provider.endpoints)
+	}
+	configured := provider.endpoints["a.example.com-"+source.DefaultPortNumber]
+	if configured.CheckInterval != 300 || configured.Message("") != "@slack-sre" {
+		t.Fatalf("Unexpected settings of configured endpoint: %+v", configured.Settings)
+	}
+	if invalid, ok := provider.endpoints["c.example.com-"+source.DefaultPortNumber]; !ok || invalid.CheckInterval != 0 {
+		t.Fatalf("Unexpected settings of endpoint with invalid annotations: %+v", invalid.Settings)
+	}
+	if _, ok := provider.endpoints["d.example.com-8443"]; !ok {
+		t.Fatalf("Unexpected endpoints without additional endpoint: %v", provider.endpoints)
+	}
+}
+
+func TestReconcileSyntheticsInvalidEndpoints(t *testing.T) {
+	clientSet := fake.NewSimpleClientset(
+		makeTestIngress(t, "valid", "a.example.com", nil),
+		makeTestIngress(t, "invalid", "foo_bar.example.com", nil),
+	)
+
+	provider := &fakeSyntheticsProvider{}
+	controller, err := NewController(zap.NewNop(), clientSet, 10*time.Hour, 48*time.Hour, nil, provider)
+	if err != nil {
+		t.Fatalf("Unexpected falied to initialize controller: %s", err.Error())
+	}
+	controller.AdditionalEndpoints = []string{"b.example.com:0"}
+
+	controller.reconcileSynthetics(context.Background())
+
+	// Endpoints that cannot be parsed are skipped instead of being reconciled as empty endpoints.
+	if len(provider.endpoints) != 1 {
+		t.Fatalf("Unexpected endpoints: %v", provider.endpoints)
+	}
+	if _, ok := provider.endpoints["a.example.com-"+source.DefaultPortNumber]; !ok {
+		t.Fatalf("Unexpected endpoints without valid endpoint: %v", provider.endpoints)
+	}
+}
+
+func TestRunSynthetics(t *testing.T) {
+	syntheticsDebounce = 10 * time.Millisecond
+	defer func() {
+		syntheticsDebounce = 10 * time.Second
+	}()
+
+	clientSet := fake.NewSimpleClientset(makeTestIngress(t, "ingress1", "a.example.com", nil))
+	provider := &fakeSyntheticsProvider{reconciled: make(chan synthetics.SyntheticEndpoints)}
+	controller, err := NewController(zap.NewNop(), clientSet, 10*time.Hour, 48*time.Hour, nil, provider)
+	if err != nil {
+		t.Fatalf("Unexpected falied to initialize controller: %s", err.Error())
+	}
+	controller.SyntheticsInterval = time.Hour
+
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	go func() {
+		controller.RunSynthetics(stopCh)
+		close(doneCh)
+	}()
+
+	waitReconciled := func(expected int) {
+		t.Helper()
+		select {
+		case endpoints := <-provider.reconciled:
+			if len(endpoints) != expected {
+				t.Fatalf("Unexpected endpoints: %v", endpoints)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Synthetics tests were not reconciled")
+		}
+	}
+
+	// The first reconciliation runs at start.
+	waitReconciled(1)
+
+	// A new Ingress triggers reconciliation before SyntheticsInterval.
+	ingresses := clientSet.NetworkingV1().Ingresses("namespace1")
+	if _, err := ingresses.Create(context.Background(), makeTestIngress(t, "ingress2", "b.example.com", nil), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	waitReconciled(2)
+
+	// Changes unrelated to synthetics tests do not trigger reconciliation.
+	ingress := makeTestIngress(t, "ingress2", "b.example.com", map[string]string{NotAfterAnnotation: "2030-01-01T00:00:00Z"})
+	if _, err := ingresses.Update(context.Background(), ingress, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	select {
+	case <-provider.reconciled:
+		t.Fatal("Unexpected reconciliation by the change of NotAfterAnnotation")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(stopCh)
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RunSynthetics did not terminate by stopCh")
+	}
+}
+
+func TestSyntheticsChanged(t *testing.T) {
+	base := makeTestIngress(t, "ingress1", "a.example.com", map[string]string{"example.com/owner": "sre"})
+
+	tests := []struct {
+		name     string
+		modify   func(ingress *v1.Ingress)
+		expected bool
+	}{
+		{
+			name:     "NoChange",
+			modify:   func(ingress *v1.Ingress) {},
+			expected: false,
+		},
+		{
+			name: "Hosts",
+			modify: func(ingress *v1.Ingress) {
+				ingress.Spec.TLS[0].Hosts = []string{"b.example.com"}
+			},
+			expected: true,
+		},
+		{
+			name: "SyntheticsAnnotation",
+			modify: func(ingress *v1.Ingress) {
+				ingress.Annotations[synthetics.EnabledAnnotation] = "false"
+			},
+			expected: true,
+		},
+		{
+			name: "OtherAnnotation",
+			modify: func(ingress *v1.Ingress) {
+				ingress.Annotations[NotAfterAnnotation] = "2030-01-01T00:00:00Z"
+			},
+			expected: false,
+		},
+		{
+			name: "Status",
+			modify: func(ingress *v1.Ingress) {
+				ingress.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "192.0.2.1"}}
+			},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			modified := base.DeepCopy()
+			test.modify(modified)
+
+			if got := syntheticsChanged(base, modified); got != test.expected {
+				t.Fatalf("Unexpected result: %v, expected %v", got, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Package hostport parses and formats endpoints formatted as `host:port`.
+// It is shared by source and synthetics, so that both handle IPv6 literals and internationalized hostnames in the same way.
+package hostport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// wildcardPrefix is the prefix of wildcard hostnames of Ingress TLS, e.g. `*.example.com`.
+const wildcardPrefix = "*."
+
+// profile converts hostnames to ASCII as DNS lookup does, rejecting names that are not valid hostnames.
+var profile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.VerifyDNSLength(true),
+	idna.StrictDomainName(true),
+)
+
+// Parse parses input formatted as `host`, `host:port`, `[IPv6]` or `[IPv6]:port`, and returns the normalized host and the port.
+// A bare IPv6 literal without brackets is regarded as a host without port. When the port is omitted, defaultPort is returned.
+func Parse(input string, defaultPort int) (string, int, error) {
+	if net.ParseIP(input) != nil || !strings.Contains(input, ":") {
+		host, err := NormalizeHost(input)
+		if err != nil {
+			return "", 0, err
+		}
+		return host, defaultPort, nil
+	}
+
+	if strings.HasPrefix(input, "[") && strings.HasSuffix(input, "]") {
+		host, err := NormalizeHost(input)
+		if err != nil {
+			return "", 0, err
+		}
+		return host, defaultPort, nil
+	}
+
+	host, portStr, err := net.SplitHostPort(input)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid endpoint %q: %s", input, err.Error())
+	}
+	// Brackets are allowed only for IPv6 literals.
+	if strings.HasPrefix(input, "[") && !isIPv6(host) {
+		return "", 0, fmt.Errorf("invalid endpoint %q: brackets are allowed only for IPv6 addresses", input)
+	}
+
+	host, err = NormalizeHost(host)
+	if err != nil {
+		return "", 0, err
+	}
+	port, err := ParsePort(portStr)
+	if err != nil {
+		return "", 0, err
+	}
+	return host, port, nil
+}
+
+// ParsePort parses port formatted as decimal digits, and returns error if it is not between 1 and 65535.
+func ParsePort(port string) (int, error) {
+	if port == "" {
+		return 0, errors.New("missing port")
+	}
+	for _, c := range port {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid port %q: must be a number", port)
+		}
+	}
+
+	p, err := strconv.Atoi(port)
+	if err != nil || p < 1 || p > 65535 {
+		return 0, fmt.Errorf("invalid port %q: must be between 1 and 65535", port)
+	}
+	return p, nil
+}
+
+// NormalizeHost returns host as an IP address or an ASCII hostname.
+// Brackets of IPv6 literals are removed, and internationalized hostnames are converted to punycode.
+// A wildcard hostname, e.g. `*.example.com`, is validated without the wildcard label.
+func NormalizeHost(host string) (string, error) {
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		literal := host[1 : len(host)-1]
+		if !isIPv6(literal) {
+			return "", fmt.Errorf("invalid host %q: brackets are allowed only for IPv6 addresses", host)
+		}
+		host = literal
+	}
+
+	if host == "" {
+		return "", errors.New("missing hostname")
+	}
+	// The profile maps invalid UTF-8 to the replacement character instead of rejecting it.
+	if !utf8.ValidString(host) {
+		return "", fmt.Errorf("invalid hostname %q: must be valid UTF-8", host)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+
+	name := strings.TrimPrefix(host, wildcardPrefix)
+	ascii, err := profile.ToASCII(name)
+	if err != nil {
+		return "", fmt.Errorf("invalid hostname %q: %s", host, err.Error())
+	}
+	if name != host {
+		ascii = wildcardPrefix + ascii
+	}
+	return ascii, nil
+}
+
+// Join returns host and port formatted as `host:port`, enclosing IPv6 addresses in brackets.
+func Join(host string, port int) string {
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+func isIPv6(host string) bool {
+	return strings.Contains(host, ":") && net.ParseIP(host) != nil
+}
@@ -0,0 +1,130 @@
+package hostport
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedHost string
+		expectedPort int
+		expectedErr  bool
+	}{
+		{input: "example.com", expectedHost: "example.com", expectedPort: 443},
+		{input: "example.com:8443", expectedHost: "example.com", expectedPort: 8443},
+		{input: "Example.COM:443", expectedHost: "example.com", expectedPort: 443},
+		{input: "*.example.com:443", expectedHost: "*.example.com", expectedPort: 443},
+		{input: "bücher.example", expectedHost: "xn--bcher-kva.example", expectedPort: 443},
+		{input: "xn--bcher-kva.example:443", expectedHost: "xn--bcher-kva.example", expectedPort: 443},
+		{input: "192.0.2.1:443", expectedHost: "192.0.2.1", expectedPort: 443},
+		{input: "[2001:db8::1]:8443", expectedHost: "2001:db8::1", expectedPort: 8443},
+		{input: "[2001:DB8::1]", expectedHost: "2001:db8::1", expectedPort: 443},
+		{input: "2001:db8::1", expectedHost: "2001:db8::1", expectedPort: 443},
+		{input: "example.com:65535", expectedHost: "example.com", expectedPort: 65535},
+		{input: "", expectedErr: true},
+		{input: ":443", expectedErr: true},
+		{input: "example.com:", expectedErr: true},
+		{input: "example.com:0", expectedErr: true},
+		{input: "example.com:65536", expectedErr: true},
+		{input: "example.com:-1", expectedErr: true},
+		{input: "example.com:+443", expectedErr: true},
+		{input: "example.com:https", expectedErr: true},
+		{input: "example.com:443:443", expectedErr: true},
+		{input: "[example.com]:443", expectedErr: true},
+		{input: "[192.0.2.1]:443", expectedErr: true},
+		{input: "exa mple.com", expectedErr: true},
+		{input: "example..com", expectedErr: true},
+		{input: "\xc30", expectedErr: true},
+		{input: strings.Repeat("a", 64) + ".com", expectedErr: true},
+	}
+
+	for _, test := range tests {
+		host, port, err := Parse(test.input, 443)
+		if (err != nil) != test.expectedErr {
+			t.Fatalf("Unexpected error of %q: %v", test.input, err)
+		}
+		if test.expectedErr {
+			continue
+		}
+		if host != test.expectedHost || port != test.expectedPort {
+			t.Fatalf("Unexpected result of %q: %s %d, expected %s %d", test.input, host, port, test.expectedHost, test.expectedPort)
+		}
+	}
+}
+
+func TestJoin(t *testing.T) {
+	tests := []struct {
+		host     string
+		port     int
+		expected string
+	}{
+		{host: "example.com", port: 443, expected: "example.com:443"},
+		{host: "2001:db8::1", port: 8443, expected: "[2001:db8::1]:8443"},
+	}
+
+	for _, test := range tests {
+		if got := Join(test.host, test.port); got != test.expected {
+			t.Fatalf("Unexpected result of %s %d: %s, expected %s", test.host, test.port, got, test.expected)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"example.com",
+		"example.com:8443",
+		"*.example.com:443",
+		"bücher.example",
+		"192.0.2.1:443",
+		"[2001:db8::1]:8443",
+		"2001:db8::1",
+		"[::ffff:192.0.2.1]",
+		"example.com:0",
+		"[example.com]:443",
+		":443",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		host, port, err := Parse(input, 443)
+		if err != nil {
+			return
+		}
+
+		if host == "" {
+			t.Fatalf("Unexpected empty host of %q", input)
+		}
+		if port < 1 || port > 65535 {
+			t.Fatalf("Unexpected port of %q: %d", input, port)
+		}
+
+		// Joined host and port are parsed into the same host and port.
+		joined := Join(host, port)
+		reparsedHost, reparsedPort, err := Parse(joined, 443)
+		if err != nil {
+			t.Fatalf("Unexpected error of %q joined from %q: %s", joined, input, err.Error())
+		}
+		if reparsedHost != host || reparsedPort != port {
+			t.Fatalf("Unexpected result of %q joined from %q: %s %d, expected %s %d", joined, input, reparsedHost, reparsedPort, host, port)
+		}
+	})
+}
+
+func FuzzParsePort(f *testing.F) {
+	for _, seed := range []string{"443", "1", "65535", "0", "65536", "-1", "+1", "0443", ""} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		port, err := ParsePort(input)
+		if err != nil {
+			return
+		}
+		if port < 1 || port > 65535 {
+			t.Fatalf("Unexpected port of %q: %d", input, port)
+		}
+	})
+}
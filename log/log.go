@@ -10,6 +10,11 @@ import (
 
 // NewLogger creates new logger that defined as zap.Logger
 func NewLogger(levelStr string) (*zap.Logger, error) {
+	return NewLoggerWithOutput(levelStr, "stdout")
+}
+
+// NewLoggerWithOutput creates new logger that writes logs to outputPath, e.g. "stderr".
+func NewLoggerWithOutput(levelStr string, outputPath string) (*zap.Logger, error) {
 	level, err := parseLogLevel(levelStr)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to parse log level: %s", err.Error())
@@ -20,7 +25,7 @@ func NewLogger(levelStr string) (*zap.Logger, error) {
 		DisableStacktrace: true,
 		Development:       false,
 		Encoding:          "json",
-		OutputPaths:       []string{"stdout"},
+		OutputPaths:       []string{outputPath},
 		ErrorOutputPaths:  []string{"stderr"},
 		Sampling: &zap.SamplingConfig{
 			Initial:    100,
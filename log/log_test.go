@@ -16,6 +16,16 @@ func TestNewLogger(t *testing.T) {
 	}
 }
 
+func TestNewLoggerWithOutput(t *testing.T) {
+	if _, err := NewLoggerWithOutput("INFO", "stderr"); err != nil {
+		t.Fatalf("Unexpected fail: %s", err.Error())
+	}
+
+	if _, err := NewLoggerWithOutput("DUMMY", "stderr"); err == nil {
+		t.Fatal("Unexpected success")
+	}
+}
+
 func TestParseLogLevel(t *testing.T) {
 	tests := []struct {
 		arg           string
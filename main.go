@@ -2,25 +2,47 @@ package main
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/mercari/certificate-expiry-monitor-controller/config"
-	"github.com/mercari/certificate-expiry-monitor-controller/controller"
+	ctrl "github.com/mercari/certificate-expiry-monitor-controller/controller"
 	logging "github.com/mercari/certificate-expiry-monitor-controller/log"
 	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier/dryrun"
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier/email"
 	"github.com/mercari/certificate-expiry-monitor-controller/notifier/log"
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier/route"
 	"github.com/mercari/certificate-expiry-monitor-controller/notifier/slack"
-	synthetics "github.com/mercari/certificate-expiry-monitor-controller/synthetics/datadog"
+	"github.com/mercari/certificate-expiry-monitor-controller/report"
+	"github.com/mercari/certificate-expiry-monitor-controller/server"
+	"github.com/mercari/certificate-expiry-monitor-controller/silence"
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics"
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics/checkly"
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics/datadog"
 
+	"go.uber.org/zap"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 )
 
 func main() {
+	if isCheckCommand(os.Args) {
+		os.Exit(runCheck(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	os.Exit(runMain())
 }
 
@@ -33,25 +55,53 @@ func runMain() int {
 	}
 
 	// Setup clientSet from configuration.
-	clientSet, err := newClientSet(env.KubeconfigPath)
+	restConfig, err := newRestConfig(env.KubeconfigPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[ERROR] Failed to create clientSet: %s\n", err.Error())
+		return 1
+	}
+
+	clientSet, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "[ERROR] Failed to create clientSet: %s\n", err.Error())
 		return 1
 	}
 
+	// Setup logger that wrapped zap.Logger to use common settings.
+	logger, err := logging.NewLogger(env.LogLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[ERROR] Failed to create logger: %s\n", err.Error())
+		return 1
+	}
+
 	// Setup notifiers from configuration.
 	// If user specify unsupported notifier name, program returns exit code `1`.
 	notifiers := make([]notifier.Notifier, len(env.Notifiers))
 	for i, name := range env.Notifiers {
 		switch name {
 		case slack.String():
-			sl, err := slack.NewNotifier(env.SlackToken, env.SlackChannel)
+			sl, err := newSlackNotifier(clientSet, env)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "[ERROR] Failed to create slack notifier: %s\n", err.Error())
 				return 1
 			}
 
 			notifiers[i] = sl
+		case email.String():
+			em, err := email.NewNotifier(env.EmailSMTPHost, env.EmailSMTPPort, env.EmailSMTPUsername, env.EmailSMTPPassword, env.EmailFrom, env.EmailTo)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[ERROR] Failed to create email notifier: %s\n", err.Error())
+				return 1
+			}
+
+			tmpl, err := loadTemplate(clientSet, env.TemplateConfigMap, env.EmailTemplatePath, email.TemplateKey())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[ERROR] Failed to load email template: %s\n", err.Error())
+				return 1
+			}
+			em.Template = tmpl
+
+			notifiers[i] = em
 		case log.String():
 			logger, err := logging.NewLogger(log.AlertLogLevel())
 			if err != nil {
@@ -59,79 +109,278 @@ func runMain() int {
 				return 1
 			}
 
-			notifiers[i] = log.NewNotifier(logger)
+			tmpl, err := loadTemplate(clientSet, env.TemplateConfigMap, env.LogTemplatePath, log.TemplateKey())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[ERROR] Failed to load log template: %s\n", err.Error())
+				return 1
+			}
+
+			notifiers[i] = &log.Log{Logger: logger, Template: tmpl}
 		default:
 			fmt.Fprintf(os.Stderr, "[ERROR] Unexpected notifier name: %s\n", name)
 			return 1
 		}
 	}
 
-	// Setup logger that wrapped zap.Logger to use common settings.
-	logger, err := logging.NewLogger(env.LogLevel)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "[ERROR] Failed to create logger: %s\n", err.Error())
-		return 1
+	// In dry-run mode, notifiers log alerts instead of sending them.
+	// Notifiers are wrapped before routing, so that routes are evaluated as usual.
+	if env.DryRun {
+		for i, name := range env.Notifiers {
+			notifiers[i] = dryrun.NewNotifier(logger, name, notifiers[i])
+		}
 	}
 
-	// Create a new synthetics testManager instance
-	testManager := &synthetics.TestManager{}
-	if env.TestManager {
-		testManager, err = synthetics.NewTestManager(env.DatadogAPIKey, env.DatadogAppKey)
+	// Setup routing of alerts from configuration.
+	// When configured, alerts are sent to notifiers through the router.
+	if env.RoutingConfig != "" {
+		routingConfig, err := route.LoadConfig(env.RoutingConfig)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "[ERROR] Failed to create testManager: %s\n", err.Error())
+			fmt.Fprintf(os.Stderr, "[ERROR] Failed to load routing config: %s\n", err.Error())
 			return 1
 		}
-		testManager.Logger = logger
-		testManager.CheckInterval = env.CheckInterval
-		testManager.AlertMessage = env.AlertMessage
-		testManager.Tags = env.Tags
-		testManager.DefaultTag = env.DefaultTag
-		testManager.AdditionalEndpoints = env.AdditionalEndpoints
-		testManager.DefaultLocations = env.DefaultLocations
 
-		// Set control flag to prevent running the synthetics logic in the controller when feature-gated
-		testManager.Enabled = true
+		named := make(map[string]notifier.Notifier, len(notifiers))
+		for i, name := range env.Notifiers {
+			named[name] = notifiers[i]
+		}
+
+		router, err := route.NewRouter(routingConfig, named)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] Failed to create router: %s\n", err.Error())
+			return 1
+		}
+
+		notifiers = []notifier.Notifier{router}
+	}
+
+	// Setup synthetics provider from configuration.
+	// If user specify unsupported provider name, program returns exit code `1`.
+	var provider synthetics.SyntheticsProvider
+	if env.TestManager {
+		provider, err = newSyntheticsProvider(logger, env)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] Failed to create synthetics provider: %s\n", err.Error())
+			return 1
+		}
 	}
 
 	// Create new controller instance.
-	controller, err := controller.NewController(logger, clientSet, env.VerifyInterval, env.AlertThreshold, notifiers, testManager)
+	controller, err := ctrl.NewController(logger, clientSet, env.VerifyInterval, env.AlertThreshold, notifiers, provider)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "[ERROR] Failed to create controller: %s\n", err.Error())
 		return 1
 	}
+	controller.Source.ClusterName = env.ClusterName
+	controller.AdditionalEndpoints = env.AdditionalEndpoints
+	controller.SyntheticsInterval = env.SyntheticsInterval
+	controller.DigestEnabled = env.DigestEnabled
+	controller.DigestInterval = env.DigestInterval
+	controller.RunTimeout = env.RunTimeout
+	source.DialTimeout = env.TLSDialTimeout
+
+	// Setup silences that suppress notifications of matching certificates.
+	if env.SilencesPath != "" {
+		silences, err := silence.LoadSilences(env.SilencesPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] Failed to load silences: %s\n", err.Error())
+			return 1
+		}
+		controller.Silences = silences
+	}
+
+	// Setup recorder of Kubernetes Events on Ingresses.
+	if env.EventsEnabled {
+		broadcaster := record.NewBroadcaster()
+		broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientSet.CoreV1().Events("")})
+		defer broadcaster.Shutdown()
+
+		controller.Recorder = broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "certificate-expiry-monitor-controller"})
+	}
+	controller.AnnotateIngress = env.AnnotateIngress
+
+	// Setup writer of CertificateReport custom resources.
+	if env.ReportsEnabled {
+		dynamicClient, err := dynamic.NewForConfig(restConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] Failed to create dynamic client: %s\n", err.Error())
+			return 1
+		}
+		controller.ReportWriter = report.NewWriter(dynamicClient)
+	}
 
 	// When controller receives SIGINT or SIGTERM,
 	// handleSignal goroutine triggers stopCh to terminate controller.
 	stopCh := make(chan struct{}, 1)
 	go handleSignal(stopCh)
 
+	// Start HTTP server of health checks.
+	// The server keeps running while waiting for leader election, so that probes of standby replicas succeed.
+	if env.HTTPAddr != "" {
+		srv := server.NewServer(env.HTTPAddr, logger, controller)
+		srv.PprofEnabled = env.PprofEnabled
+		go func() {
+			if err := srv.Run(stopCh); err != nil && err != http.ErrServerClosed {
+				logger.Error("Failed to run HTTP server", zap.Error(err))
+			}
+		}()
+	}
+
+	// When leader election is enabled, only the replica holding the Lease runs controller.
+	if env.LeaderElectionEnabled {
+		identity := env.PodName
+		if identity == "" {
+			identity, err = os.Hostname()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[ERROR] Failed to get identity of leader election: %s\n", err.Error())
+				return 1
+			}
+		}
+
+		le := ctrl.LeaderElection{
+			Namespace:     env.LeaderElectionNamespace,
+			Name:          env.LeaderElectionID,
+			Identity:      identity,
+			LeaseDuration: ctrl.DefaultLeaseDuration,
+			RenewDeadline: ctrl.DefaultRenewDeadline,
+			RetryPeriod:   ctrl.DefaultRetryPeriod,
+		}
+		if err := controller.RunWithLeaderElection(le, stopCh); err != nil {
+			fmt.Fprintf(os.Stderr, "[ERROR] Failed to run controller with leader election: %s\n", err.Error())
+			return 1
+		}
+
+		return 0
+	}
+
 	controller.Run(stopCh)
 
 	return 0
 }
 
-// Create new Kubernetes's clientSet.
+// Create new config of Kubernetes's clients.
 // When configured env.KubeconfigPath, read config from env.KubeconfigPath.
 // When not configured env.KubeconfigPath, read internal cluster config.
-func newClientSet(kubeconfigPath string) (*kubernetes.Clientset, error) {
-	var err error
-	var config *rest.Config
-
+func newRestConfig(kubeconfigPath string) (*rest.Config, error) {
 	if kubeconfigPath == "" {
-		config, err = rest.InClusterConfig()
-	} else {
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+		return rest.InClusterConfig()
 	}
+
+	return clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+}
+
+// Create new synthetics provider of env.Provider from configuration.
+// In dry-run mode, the client of the provider logs changes instead of applying them.
+func newSyntheticsProvider(logger *zap.Logger, env config.Env) (synthetics.SyntheticsProvider, error) {
+	assertions := synthetics.Assertions{
+		CertificateDays:  env.CertificateDays,
+		MinTLSVersion:    env.MinTLSVersion,
+		MaxResponseTime:  env.MaxResponseTime,
+		AcceptSelfSigned: &env.AcceptSelfSigned,
+	}
+	// By default, synthetics tests fail when the controller starts alerting.
+	if assertions.CertificateDays == 0 {
+		assertions.CertificateDays = synthetics.DefaultCertificateDays(env.AlertThreshold)
+	}
+
+	switch env.Provider {
+	case checkly.String():
+		m, err := checkly.NewManager(env.ChecklyAPIKey, env.ChecklyAccountID)
+		if err != nil {
+			return nil, err
+		}
+		m.Logger = logger
+		m.CheckInterval = env.CheckInterval
+		m.Tags = env.Tags
+		m.DefaultTag = env.DefaultTag
+		m.Locations = env.ChecklyLocations
+		m.Assertions = assertions
+		m.ClusterName = env.ClusterName
+		m.MaxDeleteCount = env.MaxDeleteCount
+		m.MaxDeleteRatio = env.MaxDeleteRatio
+		m.DeleteAfterRuns = env.DeleteAfterRuns
+		m.RunInterval = env.SyntheticsInterval
+		if env.DryRun {
+			m.Client = checkly.NewDryRunClient(logger, m.Client)
+		}
+
+		return m, nil
+	case datadog.String(), "":
+		testManager, err := datadog.NewTestManager(env.DatadogAPIKey, env.DatadogAppKey, env.DatadogSite)
+		if err != nil {
+			return nil, err
+		}
+		testManager.Logger = logger
+		testManager.CheckInterval = env.CheckInterval
+		testManager.AlertMessage = env.AlertMessage
+		testManager.Tags = env.Tags
+		testManager.DefaultTag = env.DefaultTag
+		testManager.DefaultLocations = env.DefaultLocations
+		testManager.PrivateLocations = env.PrivateLocations
+		testManager.Assertions = assertions
+		testManager.ClusterName = env.ClusterName
+		testManager.MaxDeleteCount = env.MaxDeleteCount
+		testManager.MaxDeleteRatio = env.MaxDeleteRatio
+		testManager.DeleteAfterRuns = env.DeleteAfterRuns
+		testManager.RunInterval = env.SyntheticsInterval
+		if env.DryRun {
+			testManager.Client = datadog.NewDryRunClient(logger, testManager.Client)
+		}
+
+		return testManager, nil
+	default:
+		return nil, fmt.Errorf("Unexpected synthetics provider name: %s", env.Provider)
+	}
+}
+
+// Create new slack notifier from configuration.
+// When configured env.SlackWebhookURLs, alerts are posted to the incoming webhooks.
+// Otherwise, alerts are posted to channels using env.SlackToken.
+func newSlackNotifier(clientSet kubernetes.Interface, env config.Env) (notifier.Notifier, error) {
+	tmpl, err := loadTemplate(clientSet, env.TemplateConfigMap, env.SlackTemplatePath, slack.TemplateKey())
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("Failed to load slack template: %s", err.Error())
 	}
 
-	clientSet, err := kubernetes.NewForConfig(config)
+	if len(env.SlackWebhookURLs) > 0 {
+		wh, err := slack.NewWebhookNotifier(env.SlackWebhookURLs)
+		if err != nil {
+			return nil, err
+		}
+		wh.Template = tmpl
+
+		return wh, nil
+	}
+
+	sl, err := slack.NewNotifier(env.SlackToken, env.SlackChannel)
 	if err != nil {
 		return nil, err
 	}
+	sl.Template = tmpl
+
+	routes, err := slack.ParseRoutes(env.SlackChannelRoutes)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse slack channel routes: %s", err.Error())
+	}
+	sl.Routes = routes
+
+	return sl, nil
+}
+
+// Load alert template for the notifier.
+// When configured path, read the template from the file.
+// When configured configMap as `<namespace>/<name>`, read the template from the key of the ConfigMap.
+// Otherwise, the notifier uses its default message and loadTemplate returns nil.
+func loadTemplate(clientSet kubernetes.Interface, configMap string, path string, key string) (*notifier.Template, error) {
+	if path != "" {
+		return notifier.LoadTemplate(path)
+	}
+
+	if configMap != "" {
+		ref := strings.SplitN(configMap, "/", 2)
+		return notifier.LoadTemplateFromConfigMap(clientSet, ref[0], ref[1], key)
+	}
 
-	return clientSet, nil
+	return nil, nil
 }
 
 // Handling syscall.SIGTERM and syscall.SIGINT
@@ -0,0 +1,127 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+)
+
+// Finding expresses an alert that collected into Digest.
+type Finding struct {
+	Expiration time.Time
+	Ingress    *source.Ingress
+	TLS        *source.IngressTLS
+	Option     Option
+}
+
+// Key identifies the certificate of the finding.
+// Findings of the same certificate have the same key across checks.
+func (f Finding) Key() string {
+	return strings.Join([]string{f.Ingress.ClusterName, f.Ingress.Namespace, f.Ingress.Name, f.TLS.SecretName, strings.Join(f.Hosts(), ",")}, "/")
+}
+
+// Hosts returns list of `host:port` served by the certificate.
+func (f Finding) Hosts() []string {
+	hosts := make([]string, len(f.TLS.Endpoints))
+	for i, e := range f.TLS.Endpoints {
+		hosts[i] = e.String()
+	}
+	return hosts
+}
+
+// DigestGroup expresses findings that have the same level and namespace.
+type DigestGroup struct {
+	AlertLevel AlertLevel
+	Namespace  string
+	Findings   []Finding
+}
+
+// Digest expresses the summary of findings that sent at once instead of one alert per certificate.
+type Digest struct {
+	GeneratedAt time.Time
+	Findings    []Finding
+}
+
+// DigestNotifier interface expresses the notification services that able to send Digest.
+type DigestNotifier interface {
+	Digest(Digest) error
+}
+
+// Count returns the number of findings that have the level.
+func (d Digest) Count(level AlertLevel) int {
+	count := 0
+	for _, f := range d.Findings {
+		if f.Option.AlertLevel == level {
+			count++
+		}
+	}
+	return count
+}
+
+// Summary returns one line summary of the digest, e.g. `2 CRITICAL, 5 WARNING`.
+func (d Digest) Summary() string {
+	return fmt.Sprintf("%d %s, %d %s", d.Count(AlertLevelCritical), AlertLevelCritical, d.Count(AlertLevelWarning), AlertLevelWarning)
+}
+
+// Sorted returns findings sorted by the soonest expiration.
+func (d Digest) Sorted() []Finding {
+	sorted := make([]Finding, len(d.Findings))
+	copy(sorted, d.Findings)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Expiration.Before(sorted[j].Expiration)
+	})
+	return sorted
+}
+
+// Groups returns findings grouped by level and namespace.
+// Groups are ordered by the higher level first, then by namespace.
+// Findings in each group are sorted by the soonest expiration.
+func (d Digest) Groups() []DigestGroup {
+	var groups []DigestGroup
+	index := make(map[string]int)
+
+	for _, f := range d.Sorted() {
+		key := f.Option.AlertLevel.String() + "/" + f.Ingress.Namespace
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, DigestGroup{AlertLevel: f.Option.AlertLevel, Namespace: f.Ingress.Namespace})
+		}
+		groups[i].Findings = append(groups[i].Findings, f)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].AlertLevel != groups[j].AlertLevel {
+			return groups[i].AlertLevel > groups[j].AlertLevel
+		}
+		return groups[i].Namespace < groups[j].Namespace
+	})
+
+	return groups
+}
+
+// Table renders findings sorted by the soonest expiration as a plain text table.
+// When limit is positive, Table renders at most limit rows.
+func (d Digest) Table(limit int) string {
+	sorted := d.Sorted()
+	if limit > 0 && len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DAYS\tLEVEL\tNAMESPACE\tINGRESS\tEXPIRATION\tHOSTS")
+	for _, f := range sorted {
+		days := int64(f.Expiration.Sub(d.GeneratedAt).Hours() / 24)
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", days, f.Option.AlertLevel, f.Ingress.Namespace, f.Ingress.Name, f.Expiration.Format(time.RFC822), strings.Join(f.Hosts(), ","))
+	}
+	w.Flush()
+
+	return buf.String()
+}
@@ -0,0 +1,92 @@
+package notifier
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+)
+
+func TestDigest(t *testing.T) {
+	now := time.Now()
+	digest := Digest{
+		GeneratedAt: now,
+		Findings: []Finding{
+			makeTestFinding(t, "web", "ingress1", now.AddDate(0, 0, 10), AlertLevelWarning),
+			makeTestFinding(t, "payments", "ingress2", now.AddDate(0, 0, -1), AlertLevelCritical),
+			makeTestFinding(t, "payments", "ingress3", now.AddDate(0, 0, 3), AlertLevelWarning),
+			makeTestFinding(t, "payments", "ingress4", now.AddDate(0, 0, 1), AlertLevelWarning),
+		},
+	}
+
+	if digest.Count(AlertLevelWarning) != 3 || digest.Count(AlertLevelCritical) != 1 {
+		t.Fatalf("Unexpected counts: %s", digest.Summary())
+	}
+
+	if digest.Summary() != "1 CRITICAL, 3 WARNING" {
+		t.Fatalf("Unexpected summary: %s", digest.Summary())
+	}
+
+	sorted := digest.Sorted()
+	expectedOrder := []string{"ingress2", "ingress4", "ingress3", "ingress1"}
+	for i, name := range expectedOrder {
+		if sorted[i].Ingress.Name != name {
+			t.Fatalf("Unexpected order at %d: %s", i, sorted[i].Ingress.Name)
+		}
+	}
+
+	groups := digest.Groups()
+	expectedGroups := []struct {
+		level     AlertLevel
+		namespace string
+		count     int
+	}{
+		{level: AlertLevelCritical, namespace: "payments", count: 1},
+		{level: AlertLevelWarning, namespace: "payments", count: 2},
+		{level: AlertLevelWarning, namespace: "web", count: 1},
+	}
+	if len(groups) != len(expectedGroups) {
+		t.Fatalf("Unexpected number of groups: %d", len(groups))
+	}
+	for i, expected := range expectedGroups {
+		if groups[i].AlertLevel != expected.level || groups[i].Namespace != expected.namespace || len(groups[i].Findings) != expected.count {
+			t.Fatalf("Unexpected group at %d: %v", i, groups[i])
+		}
+	}
+	if groups[1].Findings[0].Ingress.Name != "ingress4" {
+		t.Fatalf("Unexpected order in group: %s", groups[1].Findings[0].Ingress.Name)
+	}
+
+	table := digest.Table(2)
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Unexpected number of lines: %s", table)
+	}
+	if !strings.HasPrefix(lines[0], "DAYS") || !strings.Contains(lines[1], "ingress2") || !strings.Contains(lines[2], "ingress4") {
+		t.Fatalf("Unexpected table: %s", table)
+	}
+}
+
+func TestFindingKey(t *testing.T) {
+	f1 := makeTestFinding(t, "payments", "ingress1", time.Now(), AlertLevelWarning)
+	f2 := makeTestFinding(t, "payments", "ingress1", time.Now().AddDate(0, 0, 1), AlertLevelCritical)
+	f3 := makeTestFinding(t, "payments", "ingress2", time.Now(), AlertLevelWarning)
+
+	if f1.Key() != f2.Key() {
+		t.Fatalf("Unexpected different keys: %s, %s", f1.Key(), f2.Key())
+	}
+	if f1.Key() == f3.Key() {
+		t.Fatalf("Unexpected same keys: %s", f1.Key())
+	}
+}
+
+func makeTestFinding(t *testing.T, namespace string, name string, expiration time.Time, level AlertLevel) Finding {
+	t.Helper()
+	return Finding{
+		Expiration: expiration,
+		Ingress:    &source.Ingress{Namespace: namespace, Name: name},
+		TLS:        makeTestIngressTLS(t),
+		Option:     Option{AlertLevel: level},
+	}
+}
@@ -0,0 +1,111 @@
+package dryrun
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+)
+
+// destinationer is implemented by notifiers that send alerts to the destination selected by Ingress,
+// e.g. the Slack channel.
+type destinationer interface {
+	Destination(ingress *source.Ingress) string
+}
+
+// DryRun struct implements notifier.Notifier, notifier.Resolver, notifier.DigestNotifier and notifier.NoticeNotifier interface.
+// DryRun struct logs what Notifier would send instead of sending it.
+type DryRun struct {
+	Notifier notifier.Notifier
+	Name     string
+	Logger   *zap.Logger
+}
+
+// NewNotifier returns new instance of DryRun that wraps n.
+// name is the name of n in NOTIFIERS.
+func NewNotifier(logger *zap.Logger, name string, n notifier.Notifier) *DryRun {
+	return &DryRun{
+		Notifier: n,
+		Name:     name,
+		Logger:   logger,
+	}
+}
+
+// Alert defined by notifier.Notifier interface.
+// This implementation logs the alert that Notifier would send.
+func (d *DryRun) Alert(expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS, opt notifier.Option) error {
+	d.logAlert(notifier.Finding{Expiration: expiration, Ingress: ingress, TLS: tls, Option: opt})
+	return nil
+}
+
+// Resolve defined by notifier.Resolver interface.
+// This implementation logs Resolve at debug level if Notifier implements notifier.Resolver,
+// since Resolve is called for every certificate that has not reached the threshold.
+func (d *DryRun) Resolve(expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS) error {
+	if _, ok := d.Notifier.(notifier.Resolver); !ok {
+		return nil
+	}
+
+	d.Logger.Debug("DRY RUN: Resolve", d.fields(notifier.Finding{Expiration: expiration, Ingress: ingress, TLS: tls})...)
+	return nil
+}
+
+// Digest defined by notifier.DigestNotifier interface.
+// This implementation logs the digest if Notifier implements notifier.DigestNotifier,
+// otherwise it logs each finding as an alert in the same way as notifiers without digest support receive.
+func (d *DryRun) Digest(digest notifier.Digest) error {
+	if _, ok := d.Notifier.(notifier.DigestNotifier); !ok {
+		for _, f := range digest.Findings {
+			d.logAlert(f)
+		}
+		return nil
+	}
+
+	d.Logger.Info("DRY RUN: Digest",
+		zap.String("notifier", d.Name),
+		zap.String("summary", digest.Summary()),
+		zap.Int(notifier.AlertLevelCritical.String(), digest.Count(notifier.AlertLevelCritical)),
+		zap.Int(notifier.AlertLevelWarning.String(), digest.Count(notifier.AlertLevelWarning)),
+	)
+	return nil
+}
+
+// Notice defined by notifier.NoticeNotifier interface.
+// This implementation logs the notice if Notifier implements notifier.NoticeNotifier.
+func (d *DryRun) Notice(notice notifier.Notice) error {
+	if _, ok := d.Notifier.(notifier.NoticeNotifier); !ok {
+		return nil
+	}
+
+	d.Logger.Info("DRY RUN: Notice",
+		zap.String("notifier", d.Name),
+		zap.String("level", notice.AlertLevel.String()),
+		zap.String("title", notice.Title),
+		zap.String("text", notice.Text),
+	)
+	return nil
+}
+
+func (d *DryRun) logAlert(f notifier.Finding) {
+	fields := append(d.fields(f), zap.String("level", f.Option.AlertLevel.String()))
+	d.Logger.Info("DRY RUN: Alert", fields...)
+}
+
+// fields returns logging fields of the certificate of the finding.
+func (d *DryRun) fields(f notifier.Finding) []zap.Field {
+	fields := []zap.Field{zap.String("notifier", d.Name)}
+	if dst, ok := d.Notifier.(destinationer); ok {
+		fields = append(fields, zap.String("destination", dst.Destination(f.Ingress)))
+	}
+
+	return append(fields,
+		zap.String("cluster", f.Ingress.ClusterName),
+		zap.String("namespace", f.Ingress.Namespace),
+		zap.String("ingress", f.Ingress.Name),
+		zap.String("secret", f.TLS.SecretName),
+		zap.Strings("hosts", f.Hosts()),
+		zap.Time("expiration", f.Expiration),
+	)
+}
@@ -0,0 +1,154 @@
+package dryrun
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier/slack"
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+)
+
+// fakeNotifier fails the test when it receives alerts.
+type fakeNotifier struct {
+	t *testing.T
+}
+
+func (f *fakeNotifier) Alert(expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS, opt notifier.Option) error {
+	f.t.Fatalf("Unexpected alert: %s/%s", ingress.Namespace, ingress.Name)
+	return nil
+}
+
+func TestAlert(t *testing.T) {
+	type TestCase struct {
+		notifier            notifier.Notifier
+		expectedDestination string
+	}
+
+	tests := []TestCase{
+		{
+			// APIClient is nil, so Slack panics if the alert is sent.
+			notifier:            &slack.Slack{ChannelName: "alerts"},
+			expectedDestination: "alerts",
+		},
+		{
+			notifier:            &fakeNotifier{t: t},
+			expectedDestination: "",
+		},
+	}
+
+	for _, test := range tests {
+		core, recorded := observer.New(zapcore.InfoLevel)
+		d := NewNotifier(zap.New(core), "test", test.notifier)
+
+		if err := d.Alert(time.Now(), makeTestIngress(t), makeTestIngressTLS(t), notifier.Option{AlertLevel: notifier.AlertLevelCritical}); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		logs := recorded.FilterMessage("DRY RUN: Alert")
+		if logs.Len() != 1 {
+			t.Fatalf("Unexpected number of logs: %d", logs.Len())
+		}
+		if logs.FilterField(zap.String("level", "CRITICAL")).Len() != 1 {
+			t.Fatalf("Unexpected log: %v", logs.All()[0].Context)
+		}
+		if logs.FilterField(zap.Strings("hosts", []string{"example.com:443"})).Len() != 1 {
+			t.Fatalf("Unexpected log: %v", logs.All()[0].Context)
+		}
+
+		destination, ok := logs.All()[0].ContextMap()["destination"]
+		if test.expectedDestination == "" {
+			if ok {
+				t.Fatalf("Unexpected destination: %v", destination)
+			}
+		} else if destination != test.expectedDestination {
+			t.Fatalf("Unexpected destination: %v", destination)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	type TestCase struct {
+		notifier      notifier.Notifier
+		expectedCount int
+	}
+
+	tests := []TestCase{
+		{notifier: &slack.Slack{ChannelName: "alerts"}, expectedCount: 1},
+		{notifier: &fakeNotifier{t: t}, expectedCount: 0},
+	}
+
+	for _, test := range tests {
+		core, recorded := observer.New(zapcore.DebugLevel)
+		d := NewNotifier(zap.New(core), "test", test.notifier)
+
+		if err := d.Resolve(time.Now(), makeTestIngress(t), makeTestIngressTLS(t)); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		if got := recorded.FilterMessage("DRY RUN: Resolve").Len(); got != test.expectedCount {
+			t.Fatalf("Unexpected number of logs: %d, expected %d", got, test.expectedCount)
+		}
+	}
+}
+
+func TestDigest(t *testing.T) {
+	type TestCase struct {
+		notifier            notifier.Notifier
+		expectedDigestCount int
+		expectedAlertCount  int
+	}
+
+	tests := []TestCase{
+		{notifier: &slack.Slack{ChannelName: "alerts"}, expectedDigestCount: 1, expectedAlertCount: 0},
+		// Notifiers without digest support receive each finding as an alert.
+		{notifier: &fakeNotifier{t: t}, expectedDigestCount: 0, expectedAlertCount: 2},
+	}
+
+	now := time.Now()
+	digest := notifier.Digest{
+		GeneratedAt: now,
+		Findings: []notifier.Finding{
+			{Expiration: now.AddDate(0, 0, 3), Ingress: makeTestIngress(t), TLS: makeTestIngressTLS(t), Option: notifier.Option{AlertLevel: notifier.AlertLevelWarning}},
+			{Expiration: now.AddDate(0, 0, 1), Ingress: makeTestIngress(t), TLS: makeTestIngressTLS(t), Option: notifier.Option{AlertLevel: notifier.AlertLevelCritical}},
+		},
+	}
+
+	for _, test := range tests {
+		core, recorded := observer.New(zapcore.InfoLevel)
+		d := NewNotifier(zap.New(core), "test", test.notifier)
+
+		if err := d.Digest(digest); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+
+		if got := recorded.FilterMessage("DRY RUN: Digest").Len(); got != test.expectedDigestCount {
+			t.Fatalf("Unexpected number of digest logs: %d, expected %d", got, test.expectedDigestCount)
+		}
+		if got := recorded.FilterMessage("DRY RUN: Alert").Len(); got != test.expectedAlertCount {
+			t.Fatalf("Unexpected number of alert logs: %d, expected %d", got, test.expectedAlertCount)
+		}
+	}
+}
+
+func makeTestIngress(t *testing.T) *source.Ingress {
+	t.Helper()
+	return &source.Ingress{
+		ClusterName: "DummyClusterName",
+		Namespace:   "DummyNamespace",
+		Name:        "DummyName",
+		TLS:         []*source.IngressTLS{},
+	}
+}
+
+func makeTestIngressTLS(t *testing.T) *source.IngressTLS {
+	t.Helper()
+	return &source.IngressTLS{
+		Endpoints:  []*source.TLSEndpoint{{Hostname: "example.com", Port: "443"}},
+		SecretName: "DummySecretName",
+	}
+}
@@ -0,0 +1,123 @@
+package email
+
+import (
+	"errors"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+)
+
+const (
+	// notifierName used by pattern match when parse interpret options.
+	notifierName = "email"
+
+	// templateKey is the key of the alert template in the template ConfigMap.
+	templateKey = "email.tmpl"
+)
+
+// Sender interface defines behavior to send mail.
+// Sender interface defined to wrap the library: net/smtp
+type Sender interface {
+	SendMail(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// smtpSender implements Sender using net/smtp.
+type smtpSender struct{}
+
+func (smtpSender) SendMail(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	return smtp.SendMail(addr, a, from, to, msg)
+}
+
+// Email struct implements notifier.Notifier, notifier.DigestNotifier and notifier.NoticeNotifier interface.
+// Email struct sends alert as plain text mail over SMTP.
+type Email struct {
+	Sender Sender
+	Addr   string
+	Auth   smtp.Auth
+	From   string
+	To     []string
+
+	// Template overrides the default body of the alert when it is set.
+	Template *notifier.Template
+}
+
+// NewNotifier function returns new instance of Email.
+// When username is empty, Email sends mail without authentication.
+func NewNotifier(host string, port int, username string, password string, from string, to []string) (*Email, error) {
+	if host == "" {
+		return nil, errors.New("smtp host is missing")
+	}
+
+	if from == "" {
+		return nil, errors.New("from address is missing")
+	}
+
+	if len(to) == 0 {
+		return nil, errors.New("to address is missing")
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &Email{
+		Sender: smtpSender{},
+		Addr:   net.JoinHostPort(host, strconv.Itoa(port)),
+		Auth:   auth,
+		From:   from,
+		To:     to,
+	}, nil
+}
+
+// String function used by pattern match when parse interpret options.
+func String() string {
+	return notifierName
+}
+
+// TemplateKey returns the key of the alert template in the template ConfigMap.
+func TemplateKey() string {
+	return templateKey
+}
+
+// Alert defined by notifier.Notifier interface.
+// This implementation sends mail that includes infromation about ingress and TLS and those deadline.
+func (e *Email) Alert(expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS, opt notifier.Option) error {
+	body := newAlertBody(expiration, ingress, tls)
+	if e.Template != nil {
+		text, err := e.Template.Execute(notifier.NewAlertContext(opt.CheckTime(), expiration, ingress, tls, opt))
+		if err != nil {
+			return err
+		}
+		body = text
+	}
+
+	return e.send(newAlertSubject(expiration, ingress, opt.AlertLevel), body)
+}
+
+// Destination returns the recipients of the alert.
+// It is used to log the destination in dry-run mode.
+func (e *Email) Destination(ingress *source.Ingress) string {
+	return strings.Join(e.To, ",")
+}
+
+// Digest defined by notifier.DigestNotifier interface.
+// This implementation sends one mail that includes counts per level and namespace and the table of the soonest expirations.
+func (e *Email) Digest(digest notifier.Digest) error {
+	return e.send(newDigestSubject(digest), newDigestBody(digest))
+}
+
+// Notice defined by notifier.NoticeNotifier interface.
+// This implementation sends the notice as plain text mail.
+func (e *Email) Notice(notice notifier.Notice) error {
+	return e.send(newNoticeSubject(notice), notice.Text)
+}
+
+func (e *Email) send(subject string, body string) error {
+	return e.Sender.SendMail(e.Addr, e.Auth, e.From, e.To, newMessage(e.From, e.To, subject, body, time.Now()))
+}
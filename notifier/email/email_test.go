@@ -0,0 +1,190 @@
+package email
+
+import (
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+)
+
+type fakeSender struct {
+	Addr     string
+	From     string
+	To       []string
+	Messages []string
+	Err      error
+}
+
+func (f *fakeSender) SendMail(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Addr = addr
+	f.From = from
+	f.To = to
+	f.Messages = append(f.Messages, string(msg))
+	return nil
+}
+
+func TestNewNotifier(t *testing.T) {
+	type testArg struct {
+		host     string
+		port     int
+		username string
+		from     string
+		to       []string
+	}
+
+	tests := []struct {
+		arg     testArg
+		success bool
+	}{
+		{
+			arg:     testArg{host: "smtp.example.com", port: 587, username: "user", from: "monitor@example.com", to: []string{"team@example.com"}},
+			success: true,
+		},
+		{
+			arg:     testArg{host: "smtp.example.com", port: 25, from: "monitor@example.com", to: []string{"team@example.com"}},
+			success: true,
+		},
+		{
+			arg:     testArg{port: 587, from: "monitor@example.com", to: []string{"team@example.com"}},
+			success: false,
+		},
+		{
+			arg:     testArg{host: "smtp.example.com", port: 587, to: []string{"team@example.com"}},
+			success: false,
+		},
+		{
+			arg:     testArg{host: "smtp.example.com", port: 587, from: "monitor@example.com"},
+			success: false,
+		},
+	}
+
+	for _, test := range tests {
+		e, err := NewNotifier(test.arg.host, test.arg.port, test.arg.username, "password", test.arg.from, test.arg.to)
+
+		if (err == nil) != test.success {
+			if test.success {
+				t.Fatalf("Unexpected failed to initialize notifier: %s", err.Error())
+			} else {
+				t.Fatalf("Unexpected successed to initialize notifier")
+			}
+		}
+
+		if err == nil && (e.Auth != nil) != (test.arg.username != "") {
+			t.Fatalf("Unexpected auth: %v", e.Auth)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	if String() != notifierName {
+		t.Fatal("Unmatch return value of String() with notifierName")
+	}
+}
+
+func TestAlert(t *testing.T) {
+	sender := &fakeSender{}
+	e := makeTestEmail(t, sender)
+
+	err := e.Alert(time.Now().AddDate(0, 0, 5).Add(time.Hour*12), makeTestIngress(t), makeTestIngressTLS(t), notifier.Option{AlertLevel: notifier.AlertLevelWarning})
+	if err != nil {
+		t.Fatalf("Unexpected result: %s", err.Error())
+	}
+
+	if sender.Addr != "smtp.example.com:587" || sender.From != "monitor@example.com" || len(sender.To) != 1 {
+		t.Fatalf("Unexpected envelope: %s, %s, %v", sender.Addr, sender.From, sender.To)
+	}
+
+	msg := sender.Messages[0]
+	if !strings.Contains(msg, "[WARNING] TLS certificate of DummyNamespace/DummyName will expire within 5 days") {
+		t.Fatalf("Unexpected subject: %s", msg)
+	}
+	if !strings.Contains(msg, "Hosts: host01.example.com:443, host02.example.com:443") {
+		t.Fatalf("Unexpected body: %s", msg)
+	}
+
+	tmpl, err := notifier.NewTemplate("test", "Runbook: {{ index .Annotations \"runbook\" }}")
+	if err != nil {
+		t.Fatalf("Unexpected failed to parse template: %s", err.Error())
+	}
+	e.Template = tmpl
+	ingress := makeTestIngress(t)
+	ingress.Annotations = map[string]string{"runbook": "https://runbook.example.com"}
+
+	if err := e.Alert(time.Now(), ingress, makeTestIngressTLS(t), notifier.Option{AlertLevel: notifier.AlertLevelCritical}); err != nil {
+		t.Fatalf("Unexpected result: %s", err.Error())
+	}
+	if !strings.HasSuffix(sender.Messages[1], "\r\n\r\nRunbook: https://runbook.example.com") {
+		t.Fatalf("Unexpected body: %s", sender.Messages[1])
+	}
+
+	sender.Err = errors.New("connection refused")
+	if err := e.Alert(time.Now(), makeTestIngress(t), makeTestIngressTLS(t), notifier.Option{AlertLevel: notifier.AlertLevelCritical}); err == nil {
+		t.Fatal("Unexpected result: Alert should be fail")
+	}
+}
+
+func TestDigest(t *testing.T) {
+	sender := &fakeSender{}
+	e := makeTestEmail(t, sender)
+
+	now := time.Now()
+	digest := notifier.Digest{
+		GeneratedAt: now,
+		Findings: []notifier.Finding{
+			{Expiration: now.AddDate(0, 0, 3), Ingress: makeTestIngress(t), TLS: makeTestIngressTLS(t), Option: notifier.Option{AlertLevel: notifier.AlertLevelWarning}},
+			{Expiration: now.AddDate(0, 0, -3), Ingress: makeTestIngress(t), TLS: makeTestIngressTLS(t), Option: notifier.Option{AlertLevel: notifier.AlertLevelCritical}},
+		},
+	}
+
+	if err := e.Digest(digest); err != nil {
+		t.Fatalf("Unexpected result: %s", err.Error())
+	}
+
+	if len(sender.Messages) != 1 {
+		t.Fatalf("Unexpected number of messages: %d", len(sender.Messages))
+	}
+	msg := sender.Messages[0]
+	for _, expected := range []string{"Certificate expiry digest: 1 CRITICAL, 1 WARNING", "CRITICAL DummyNamespace: 1", "WARNING DummyNamespace: 1", "DAYS"} {
+		if !strings.Contains(msg, expected) {
+			t.Fatalf("Message not includes %q: %s", expected, msg)
+		}
+	}
+}
+
+func makeTestEmail(t *testing.T, sender Sender) *Email {
+	t.Helper()
+	return &Email{
+		Sender: sender,
+		Addr:   "smtp.example.com:587",
+		From:   "monitor@example.com",
+		To:     []string{"team@example.com"},
+	}
+}
+
+func makeTestIngress(t *testing.T) *source.Ingress {
+	t.Helper()
+	return &source.Ingress{
+		ClusterName: "DummyClusterName",
+		Namespace:   "DummyNamespace",
+		Name:        "DummyName",
+		TLS:         []*source.IngressTLS{},
+	}
+}
+
+func makeTestIngressTLS(t *testing.T) *source.IngressTLS {
+	t.Helper()
+	return &source.IngressTLS{
+		Endpoints: []*source.TLSEndpoint{
+			source.NewTLSEndpoint("host01.example.com", "443"),
+			source.NewTLSEndpoint("host02.example.com", "443"),
+		},
+		SecretName: "DummySecretName",
+	}
+}
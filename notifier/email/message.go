@@ -0,0 +1,83 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+)
+
+// newAlertSubject creates subject of the alert mail.
+func newAlertSubject(expiration time.Time, ingress *source.Ingress, alertLevel notifier.AlertLevel) string {
+	switch alertLevel {
+	case notifier.AlertLevelCritical:
+		days := int64(time.Since(expiration).Hours() / 24)
+		return fmt.Sprintf("[CRITICAL] TLS certificate of %s/%s already expired at %d days ago", ingress.Namespace, ingress.Name, days)
+	case notifier.AlertLevelWarning:
+		days := int64(time.Until(expiration).Hours() / 24)
+		return fmt.Sprintf("[WARNING] TLS certificate of %s/%s will expire within %d days", ingress.Namespace, ingress.Name, days)
+	}
+
+	return ""
+}
+
+// newAlertBody creates body of the alert mail.
+func newAlertBody(expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS) string {
+	hosts := make([]string, len(tls.Endpoints))
+	for i, e := range tls.Endpoints {
+		hosts[i] = e.String()
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Cluster: %s\n", ingress.ClusterName)
+	fmt.Fprintf(&buf, "Namespace: %s\n", ingress.Namespace)
+	fmt.Fprintf(&buf, "Ingress: %s\n", ingress.Name)
+	fmt.Fprintf(&buf, "TLS secret name: %s\n", tls.SecretName)
+	fmt.Fprintf(&buf, "Expiration: %s\n", expiration.Format(time.RFC822))
+	fmt.Fprintf(&buf, "Hosts: %s\n", strings.Join(hosts, ", "))
+
+	return buf.String()
+}
+
+// newDigestSubject creates subject of the digest mail.
+func newDigestSubject(digest notifier.Digest) string {
+	return fmt.Sprintf("Certificate expiry digest: %s", digest.Summary())
+}
+
+// newNoticeSubject creates subject of the notice mail.
+func newNoticeSubject(notice notifier.Notice) string {
+	return fmt.Sprintf("[%s] %s", notice.AlertLevel, notice.Title)
+}
+
+// newDigestBody creates body of the digest mail.
+func newDigestBody(digest notifier.Digest) string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "%s\n\n", digest.Summary())
+	for _, g := range digest.Groups() {
+		fmt.Fprintf(&buf, "%s %s: %d\n", g.AlertLevel, g.Namespace, len(g.Findings))
+	}
+	fmt.Fprintf(&buf, "\n%s", digest.Table(0))
+
+	return buf.String()
+}
+
+// newMessage creates plain text mail formatted as RFC 5322.
+func newMessage(from string, to []string, subject string, body string, date time.Time) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(&buf, "\r\n")
+	buf.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+
+	return buf.Bytes()
+}
@@ -0,0 +1,39 @@
+package email
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+)
+
+func TestNewMessage(t *testing.T) {
+	date := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	msg := string(newMessage("monitor@example.com", []string{"a@example.com", "b@example.com"}, "[WARNING] subject", "line1\nline2\n", date))
+
+	expectedHeaders := []string{
+		"From: monitor@example.com\r\n",
+		"To: a@example.com, b@example.com\r\n",
+		"Subject: [WARNING] subject\r\n",
+		"Date: Fri, 02 Jan 2026 03:04:05 +0000\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+	}
+	for _, expected := range expectedHeaders {
+		if !strings.Contains(msg, expected) {
+			t.Fatalf("Message not includes %q: %s", expected, msg)
+		}
+	}
+
+	if !strings.HasSuffix(msg, "\r\n\r\nline1\r\nline2\r\n") {
+		t.Fatalf("Unexpected body: %q", msg)
+	}
+}
+
+func TestNewAlertSubject(t *testing.T) {
+	ingress := makeTestIngress(t)
+
+	if actual := newAlertSubject(time.Now().AddDate(0, 0, -5), ingress, notifier.AlertLevelCritical); !strings.HasPrefix(actual, "[CRITICAL]") || !strings.Contains(actual, "5 days ago") {
+		t.Fatalf("Unexpected subject: %s", actual)
+	}
+}
@@ -17,12 +17,18 @@ const (
 
 	// notifierName used by pattern match when parse interpret options.
 	notifierName = "log"
+
+	// templateKey is the key of the alert template in the template ConfigMap.
+	templateKey = "log.tmpl"
 )
 
-// Log struct implements notifier.Notifier interface.
+// Log struct implements notifier.Notifier, notifier.DigestNotifier and notifier.NoticeNotifier interface.
 // Log struct output alert information using application logger.
 type Log struct {
 	Logger *zap.Logger
+
+	// Template renders an additional `Message` field when it is set.
+	Template *notifier.Template
 }
 
 // NewNotifier function returns new instance of Log.
@@ -42,14 +48,55 @@ func AlertLogLevel() string {
 	return alertLogLevel
 }
 
+// TemplateKey returns the key of the alert template in the template ConfigMap.
+func TemplateKey() string {
+	return templateKey
+}
+
 // Alert defined by notifier.Notifier interface.
 // This function create and print fields using log package.
 func (log *Log) Alert(expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS, opt notifier.Option) error {
 	fields := loggingFields(ingress.ClusterName, ingress.Namespace, ingress.Name, tls.SecretName, expiration, tls.Endpoints, opt.AlertLevel)
+	if log.Template != nil {
+		message, err := log.Template.Execute(notifier.NewAlertContext(opt.CheckTime(), expiration, ingress, tls, opt))
+		if err != nil {
+			return err
+		}
+		fields = append(fields, zap.String("Message", message))
+	}
+
 	log.Logger.Error("ALERT", fields...)
 	return nil
 }
 
+// Notice defined by notifier.NoticeNotifier interface.
+// This function prints the notice as one log.
+func (log *Log) Notice(notice notifier.Notice) error {
+	log.Logger.Error("NOTICE",
+		zap.String("Level", notice.AlertLevel.String()),
+		zap.String("Title", notice.Title),
+		zap.String("Text", notice.Text),
+	)
+	return nil
+}
+
+// Digest defined by notifier.DigestNotifier interface.
+// This function prints counts per level and namespace and the table of the soonest expirations as one log.
+func (log *Log) Digest(digest notifier.Digest) error {
+	namespaces := make(map[string]int)
+	for _, g := range digest.Groups() {
+		namespaces[g.AlertLevel.String()+"/"+g.Namespace] = len(g.Findings)
+	}
+
+	log.Logger.Error("DIGEST",
+		zap.Int(notifier.AlertLevelCritical.String(), digest.Count(notifier.AlertLevelCritical)),
+		zap.Int(notifier.AlertLevelWarning.String(), digest.Count(notifier.AlertLevelWarning)),
+		zap.Any("Namespaces", namespaces),
+		zap.String("Expirations", digest.Table(0)),
+	)
+	return nil
+}
+
 func loggingFields(
 	cluster string,
 	namespace string,
@@ -60,21 +107,13 @@ func loggingFields(
 	alertLevel notifier.AlertLevel,
 ) []zapcore.Field {
 
-	var level string
-	switch alertLevel {
-	case notifier.AlertLevelWarning:
-		level = "WARNING"
-	case notifier.AlertLevelCritical:
-		level = "CRITICAL"
-	}
-
 	hosts := make([]string, len(endpoints))
 	for i, e := range endpoints {
-		hosts[i] = e.Hostname + ":" + e.Port
+		hosts[i] = e.String()
 	}
 
 	return []zapcore.Field{
-		zap.String("Level", level),
+		zap.String("Level", alertLevel.String()),
 		zap.String("ClusterName", cluster),
 		zap.String("Namespace", namespace),
 		zap.String("Ingress", name),
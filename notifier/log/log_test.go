@@ -61,6 +61,71 @@ func TestAlert(t *testing.T) {
 	}
 }
 
+func TestAlertWithTemplate(t *testing.T) {
+	tmpl, err := notifier.NewTemplate("test", "{{ .Level }} {{ .Namespace }}/{{ .Ingress }}")
+	if err != nil {
+		t.Fatalf("Unexpected failed to parse template: %s", err.Error())
+	}
+
+	core, recorded := observer.New(zapcore.InfoLevel)
+	l := &Log{Logger: zap.New(core), Template: tmpl}
+	if err := l.Alert(time.Now(), makeTestIngress(t), makeTestIngressTLS(t), notifier.Option{AlertLevel: notifier.AlertLevelCritical}); err != nil {
+		t.Fatalf("Unexpected result: %s", err.Error())
+	}
+
+	expectedField := zap.String("Message", "CRITICAL DummyNamespace/DummyName")
+	if recorded.FilterField(expectedField).Len() != 1 {
+		t.Fatalf("Not found expected value: { %s: %s }", expectedField.Key, expectedField.String)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	core, recorded := observer.New(zapcore.InfoLevel)
+	l := NewNotifier(zap.New(core)).(*Log)
+
+	now := time.Now()
+	digest := notifier.Digest{
+		GeneratedAt: now,
+		Findings: []notifier.Finding{
+			{Expiration: now.AddDate(0, 0, 3), Ingress: makeTestIngress(t), TLS: makeTestIngressTLS(t), Option: notifier.Option{AlertLevel: notifier.AlertLevelWarning}},
+			{Expiration: now.AddDate(0, 0, 5), Ingress: makeTestIngress(t), TLS: makeTestIngressTLS(t), Option: notifier.Option{AlertLevel: notifier.AlertLevelWarning}},
+		},
+	}
+
+	if err := l.Digest(digest); err != nil {
+		t.Fatalf("Unexpected result: %s", err.Error())
+	}
+
+	logs := recorded.FilterMessage("DIGEST")
+	if logs.Len() != 1 {
+		t.Fatalf("Unexpected number of digest logs: %d", logs.Len())
+	}
+
+	expectedField := zap.Int("WARNING", 2)
+	if logs.FilterField(expectedField).Len() != 1 {
+		t.Fatalf("Not found expected value: { %s: %d }", expectedField.Key, expectedField.Integer)
+	}
+}
+
+func TestNotice(t *testing.T) {
+	core, recorded := observer.New(zapcore.InfoLevel)
+	l := NewNotifier(zap.New(core)).(*Log)
+
+	if err := l.Notice(notifier.Notice{AlertLevel: notifier.AlertLevelCritical, Title: "title", Text: "text"}); err != nil {
+		t.Fatalf("Unexpected result: %s", err.Error())
+	}
+
+	logs := recorded.FilterMessage("NOTICE")
+	if logs.Len() != 1 {
+		t.Fatalf("Unexpected number of notice logs: %d", logs.Len())
+	}
+
+	expectedField := zap.String("Level", "CRITICAL")
+	if logs.FilterField(expectedField).Len() != 1 {
+		t.Fatalf("Not found expected value: { %s: %s }", expectedField.Key, expectedField.String)
+	}
+}
+
 func makeTestIngress(t *testing.T) *source.Ingress {
 	t.Helper()
 	return &source.Ingress{
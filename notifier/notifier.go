@@ -1,6 +1,7 @@
 package notifier
 
 import (
+	"crypto/x509"
 	"time"
 
 	"github.com/mercari/certificate-expiry-monitor-controller/source"
@@ -17,9 +18,36 @@ const (
 	AlertLevelCritical
 )
 
+// String returns the upper case name of the level, e.g. `WARNING`.
+func (l AlertLevel) String() string {
+	switch l {
+	case AlertLevelWarning:
+		return "WARNING"
+	case AlertLevelCritical:
+		return "CRITICAL"
+	}
+	return ""
+}
+
 // Option struct provides configration about notification.
 type Option struct {
 	AlertLevel AlertLevel
+
+	// Certificate is the end-user certificate that triggered the alert.
+	// It may be nil when the caller has no certificate details.
+	Certificate *x509.Certificate
+
+	// CheckedAt is the time of the check that triggered the alert.
+	// It may be zero when the caller has no check time, and then the time of the notification is used.
+	CheckedAt time.Time
+}
+
+// CheckTime returns CheckedAt, or the current time if CheckedAt is zero.
+func (o Option) CheckTime() time.Time {
+	if o.CheckedAt.IsZero() {
+		return time.Now()
+	}
+	return o.CheckedAt
 }
 
 // Notifier interface expresses the notification services that able to send Alert.
@@ -27,3 +55,23 @@ type Option struct {
 type Notifier interface {
 	Alert(time.Time, *source.Ingress, *source.IngressTLS, Option) error
 }
+
+// Resolver interface expresses the notification services that track alerts across checks.
+// Controller calls Resolve when the certificate no longer reaches the threshold,
+// e.g. after the certificate has been renewed.
+type Resolver interface {
+	Resolve(time.Time, *source.Ingress, *source.IngressTLS) error
+}
+
+// Notice expresses an alert about the controller itself rather than a certificate,
+// e.g. the safeguard of synthetics tests refused to delete tests.
+type Notice struct {
+	AlertLevel AlertLevel
+	Title      string
+	Text       string
+}
+
+// NoticeNotifier interface expresses the notification services that able to send Notice.
+type NoticeNotifier interface {
+	Notice(Notice) error
+}
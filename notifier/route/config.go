@@ -0,0 +1,164 @@
+package route
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Config expresses the routing tree of alerts and the receivers.
+type Config struct {
+	Receivers []Receiver    `json:"receivers"`
+	Route     *Route        `json:"route"`
+	Mute      []MuteRule    `json:"mute,omitempty"`
+	Inhibit   []InhibitRule `json:"inhibit,omitempty"`
+}
+
+// Receiver expresses a named set of notifiers.
+// Notifiers are names of configured NOTIFIERS, e.g. `slack` or `log`.
+type Receiver struct {
+	Name      string   `json:"name"`
+	Notifiers []string `json:"notifiers"`
+}
+
+// Matchers expresses conditions on labels of an alert.
+// Values are glob patterns, where `*` and `?` also match `/`, e.g. of URLs in annotations.
+type Matchers map[string]string
+
+// MuteRule drops alerts that match all matchers.
+// When Until is set, the rule is effective until the time.
+type MuteRule struct {
+	Match Matchers   `json:"match"`
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// InhibitRule drops alerts that match TargetMatch while an alert that matches SourceMatch
+// and has the same values for Equal labels has been sent.
+type InhibitRule struct {
+	SourceMatch Matchers `json:"source_match"`
+	TargetMatch Matchers `json:"target_match"`
+	Equal       []string `json:"equal,omitempty"`
+}
+
+// LoadConfig reads and validates the routing configuration in path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseConfig(data)
+}
+
+// ParseConfig parses and validates the routing configuration formatted as YAML.
+func ParseConfig(data []byte) (*Config, error) {
+	var config Config
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, err
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// validate validates references to receivers and patterns of matchers.
+func (c *Config) validate() error {
+	if c.Route == nil {
+		return fmt.Errorf("route is missing")
+	}
+
+	if c.Route.Receiver == "" {
+		return fmt.Errorf("receiver of the root route is missing")
+	}
+
+	receivers := make(map[string]bool)
+	for _, r := range c.Receivers {
+		if r.Name == "" {
+			return fmt.Errorf("receiver name is missing")
+		}
+		if receivers[r.Name] {
+			return fmt.Errorf("receiver %q is duplicated", r.Name)
+		}
+		receivers[r.Name] = true
+	}
+
+	var validateRoute func(r *Route) error
+	validateRoute = func(r *Route) error {
+		if r.Receiver != "" && !receivers[r.Receiver] {
+			return fmt.Errorf("receiver %q is not defined", r.Receiver)
+		}
+		if err := r.Match.validate(); err != nil {
+			return err
+		}
+		for _, child := range r.Routes {
+			if err := validateRoute(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := validateRoute(c.Route); err != nil {
+		return err
+	}
+
+	for _, m := range c.Mute {
+		if len(m.Match) == 0 {
+			return fmt.Errorf("matchers of mute rule are missing")
+		}
+		if err := m.Match.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, i := range c.Inhibit {
+		if len(i.SourceMatch) == 0 || len(i.TargetMatch) == 0 {
+			return fmt.Errorf("matchers of inhibit rule are missing")
+		}
+		if err := i.SourceMatch.validate(); err != nil {
+			return err
+		}
+		if err := i.TargetMatch.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Match reports whether labels satisfy all matchers.
+// A matcher of a missing label is matched as an empty value.
+func (m Matchers) Match(labels map[string]string) bool {
+	for key, pattern := range m {
+		if ok, _ := globMatch(pattern, labels[key]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Matchers) validate() error {
+	for key, pattern := range m {
+		if !isLabelKey(key) {
+			return fmt.Errorf("unexpected matcher key %q", key)
+		}
+		if _, err := compileGlob(pattern); err != nil {
+			return fmt.Errorf("invalid pattern %q of matcher %q: %s", pattern, key, err.Error())
+		}
+	}
+	return nil
+}
+
+func isLabelKey(key string) bool {
+	switch key {
+	case LabelLevel, LabelType, LabelCluster, LabelNamespace, LabelIngress, LabelSecret:
+		return true
+	}
+	return (strings.HasPrefix(key, LabelPrefix) && len(key) > len(LabelPrefix)) ||
+		(strings.HasPrefix(key, AnnotationPrefix) && len(key) > len(AnnotationPrefix))
+}
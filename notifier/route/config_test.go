@@ -0,0 +1,157 @@
+package route
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testConfig = `
+receivers:
+  - name: platform
+    notifiers: [log]
+  - name: pager
+    notifiers: [email, slack]
+  - name: chat
+    notifiers: [slack]
+route:
+  receiver: platform
+  routes:
+    - match:
+        level: CRITICAL
+      receiver: pager
+      continue: true
+    - match:
+        namespace: payments-*
+      receiver: chat
+mute:
+  - match:
+      namespace: sandbox-*
+inhibit:
+  - source_match:
+      level: CRITICAL
+    target_match:
+      level: WARNING
+    equal: [namespace]
+`
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		arg     string
+		success bool
+	}{
+		{
+			arg:     testConfig,
+			success: true,
+		},
+		{
+			// route is missing
+			arg:     "receivers: [{name: platform, notifiers: [log]}]",
+			success: false,
+		},
+		{
+			// receiver of the root route is missing
+			arg:     "receivers: [{name: platform, notifiers: [log]}]\nroute: {routes: [{receiver: platform}]}",
+			success: false,
+		},
+		{
+			// undefined receiver
+			arg:     "receivers: [{name: platform, notifiers: [log]}]\nroute: {receiver: pager}",
+			success: false,
+		},
+		{
+			// duplicated receiver
+			arg:     "receivers: [{name: platform, notifiers: [log]}, {name: platform, notifiers: [slack]}]\nroute: {receiver: platform}",
+			success: false,
+		},
+		{
+			// unknown matcher key
+			arg:     "receivers: [{name: platform, notifiers: [log]}]\nroute: {receiver: platform, routes: [{match: {owner: foo}}]}",
+			success: false,
+		},
+		{
+			// invalid pattern
+			arg:     "receivers: [{name: platform, notifiers: [log]}]\nroute: {receiver: platform}\nmute: [{match: {namespace: '['}}]",
+			success: false,
+		},
+		{
+			// unknown field
+			arg:     "receivers: [{name: platform, notifiers: [log]}]\nroute: {receiver: platform, matchers: {level: WARNING}}",
+			success: false,
+		},
+		{
+			// inhibit rule without target
+			arg:     "receivers: [{name: platform, notifiers: [log]}]\nroute: {receiver: platform}\ninhibit: [{source_match: {level: CRITICAL}}]",
+			success: false,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ParseConfig([]byte(test.arg))
+		if (err == nil) != test.success {
+			t.Fatalf("Unexpected result when parse %q: %v", test.arg, err)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "route")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "routing.yaml")
+	if err := ioutil.WriteFile(path, []byte(testConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Unexpected failed to load config: %s", err.Error())
+	}
+	if len(config.Receivers) != 3 || len(config.Route.Routes) != 2 || len(config.Mute) != 1 || len(config.Inhibit) != 1 {
+		t.Fatalf("Unexpected config: %v", config)
+	}
+
+	if _, err := LoadConfig(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Fatal("Unexpected success to load missing config")
+	}
+}
+
+func TestMatchersMatch(t *testing.T) {
+	labels := map[string]string{
+		"level":                          "WARNING",
+		"namespace":                      "payments-api",
+		"label.team":                     "payments",
+		"annotation.example.com/owner":   "payments/checkout",
+		"annotation.example.com/runbook": "https://runbook.example.com/payments",
+	}
+
+	tests := []struct {
+		matchers Matchers
+		expected bool
+	}{
+		{matchers: Matchers{}, expected: true},
+		{matchers: Matchers{"level": "WARNING"}, expected: true},
+		{matchers: Matchers{"level": "WARNING", "namespace": "payments-*"}, expected: true},
+		{matchers: Matchers{"level": "CRITICAL", "namespace": "payments-*"}, expected: false},
+		{matchers: Matchers{"label.team": "payments"}, expected: true},
+		{matchers: Matchers{"label.owner": "payments"}, expected: false},
+		{matchers: Matchers{"label.owner": ""}, expected: true},
+		{matchers: Matchers{"annotation.example.com/owner": "payments/*"}, expected: true},
+		{matchers: Matchers{"annotation.example.com/owner": "*"}, expected: true},
+		{matchers: Matchers{"annotation.example.com/runbook": "https://*.example.com/*"}, expected: true},
+		{matchers: Matchers{"annotation.example.com/owner": "payments-*"}, expected: false},
+		{matchers: Matchers{"namespace": "payments-[a-z]??"}, expected: true},
+		{matchers: Matchers{"namespace": "payments-[!a]*"}, expected: false},
+		{matchers: Matchers{"namespace": "payments.api"}, expected: false},
+	}
+
+	for _, test := range tests {
+		if actual := test.matchers.Match(labels); actual != test.expected {
+			t.Fatalf("Unexpected result of %v: %t", test.matchers, actual)
+		}
+	}
+}
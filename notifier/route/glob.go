@@ -0,0 +1,64 @@
+package route
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var errBadPattern = errors.New("syntax error in pattern")
+
+// globMatch reports whether value matches the glob pattern.
+// The syntax is the same as path.Match, except that `*` and `?` also match `/`,
+// since values of labels and annotations, e.g. URLs, are not paths.
+func globMatch(pattern string, value string) (bool, error) {
+	re, err := compileGlob(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(value), nil
+}
+
+// compileGlob converts the glob pattern to a regular expression that matches the whole value.
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString(`(?s)^`)
+
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '*':
+			b.WriteString(`.*`)
+		case '?':
+			b.WriteString(`.`)
+		case '\\':
+			i++
+			if i == len(pattern) {
+				return nil, errBadPattern
+			}
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end <= 0 {
+				return nil, errBadPattern
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "^") || strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			if class == "^" {
+				return nil, errBadPattern
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	b.WriteString(`$`)
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, errBadPattern
+	}
+	return re, nil
+}
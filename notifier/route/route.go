@@ -0,0 +1,95 @@
+package route
+
+import (
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+)
+
+// The following labels are available in matchers.
+const (
+	LabelLevel     = "level"     // `WARNING` or `CRITICAL`
+	LabelType      = "type"      // `expiring` or `expired`
+	LabelCluster   = "cluster"   // Cluster name of the Ingress
+	LabelNamespace = "namespace" // Namespace of the Ingress
+	LabelIngress   = "ingress"   // Name of the Ingress
+	LabelSecret    = "secret"    // Name of the TLS secret
+
+	// LabelPrefix and AnnotationPrefix are prefixes of labels that refer to labels and annotations of the Ingress,
+	// e.g. `label.team` or `annotation.example.com/owner`.
+	LabelPrefix      = "label."
+	AnnotationPrefix = "annotation."
+)
+
+// The following values are types of findings.
+const (
+	TypeExpiring = "expiring"
+	TypeExpired  = "expired"
+)
+
+// Route expresses a node of the routing tree.
+// When an alert matches the route, the alert is sent to Receiver, or to the receivers of matching child routes.
+// Child routes are evaluated in order and the evaluation stops at the first matching child,
+// unless the child sets Continue.
+type Route struct {
+	Receiver string   `json:"receiver,omitempty"`
+	Match    Matchers `json:"match,omitempty"`
+	Continue bool     `json:"continue,omitempty"`
+	Routes   []*Route `json:"routes,omitempty"`
+}
+
+// Receivers returns names of receivers that the alert with labels is routed to.
+// The root route matches all alerts regardless of its matchers.
+func (r *Route) Receivers(labels map[string]string) []string {
+	return r.receivers(labels, "")
+}
+
+func (r *Route) receivers(labels map[string]string, inherited string) []string {
+	receiver := r.Receiver
+	if receiver == "" {
+		receiver = inherited
+	}
+
+	var result []string
+	matched := false
+	for _, child := range r.Routes {
+		if !child.Match.Match(labels) {
+			continue
+		}
+
+		matched = true
+		result = append(result, child.receivers(labels, receiver)...)
+		if !child.Continue {
+			break
+		}
+	}
+
+	if !matched {
+		return []string{receiver}
+	}
+
+	return result
+}
+
+// Labels returns labels of the finding used by matchers.
+func Labels(f notifier.Finding) map[string]string {
+	labels := map[string]string{
+		LabelLevel:     f.Option.AlertLevel.String(),
+		LabelType:      TypeExpiring,
+		LabelCluster:   f.Ingress.ClusterName,
+		LabelNamespace: f.Ingress.Namespace,
+		LabelIngress:   f.Ingress.Name,
+		LabelSecret:    f.TLS.SecretName,
+	}
+
+	if f.Option.AlertLevel == notifier.AlertLevelCritical {
+		labels[LabelType] = TypeExpired
+	}
+
+	for k, v := range f.Ingress.Labels {
+		labels[LabelPrefix+k] = v
+	}
+	for k, v := range f.Ingress.Annotations {
+		labels[AnnotationPrefix+k] = v
+	}
+
+	return labels
+}
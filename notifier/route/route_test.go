@@ -0,0 +1,89 @@
+package route
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+)
+
+func TestRouteReceivers(t *testing.T) {
+	root := &Route{
+		Receiver: "platform",
+		Routes: []*Route{
+			{Match: Matchers{"level": "CRITICAL"}, Receiver: "pager", Continue: true},
+			{
+				Match:    Matchers{"namespace": "payments-*"},
+				Receiver: "payments",
+				Routes: []*Route{
+					{Match: Matchers{"label.tier": "frontend"}, Receiver: "payments-web"},
+				},
+			},
+			{Match: Matchers{"namespace": "payments-*"}, Receiver: "unreachable"},
+			// Empty receiver inherits the parent.
+			{Match: Matchers{"namespace": "web"}},
+		},
+	}
+
+	tests := []struct {
+		labels   map[string]string
+		expected []string
+	}{
+		{
+			labels:   map[string]string{"level": "WARNING", "namespace": "default"},
+			expected: []string{"platform"},
+		},
+		{
+			labels:   map[string]string{"level": "CRITICAL", "namespace": "default"},
+			expected: []string{"pager"},
+		},
+		{
+			labels:   map[string]string{"level": "CRITICAL", "namespace": "payments-api"},
+			expected: []string{"pager", "payments"},
+		},
+		{
+			labels:   map[string]string{"level": "WARNING", "namespace": "payments-api", "label.tier": "frontend"},
+			expected: []string{"payments-web"},
+		},
+		{
+			labels:   map[string]string{"level": "WARNING", "namespace": "web"},
+			expected: []string{"platform"},
+		},
+	}
+
+	for _, test := range tests {
+		if actual := root.Receivers(test.labels); !reflect.DeepEqual(actual, test.expected) {
+			t.Fatalf("Unexpected receivers of %v: %v, expected %v", test.labels, actual, test.expected)
+		}
+	}
+}
+
+func TestLabels(t *testing.T) {
+	f := notifier.Finding{
+		Ingress: &source.Ingress{
+			ClusterName: "cluster",
+			Namespace:   "namespace",
+			Name:        "ingress",
+			Labels:      map[string]string{"team": "foo"},
+			Annotations: map[string]string{"example.com/owner": "bar"},
+		},
+		TLS:    &source.IngressTLS{SecretName: "secret"},
+		Option: notifier.Option{AlertLevel: notifier.AlertLevelCritical},
+	}
+
+	expected := map[string]string{
+		"level":                        "CRITICAL",
+		"type":                         "expired",
+		"cluster":                      "cluster",
+		"namespace":                    "namespace",
+		"ingress":                      "ingress",
+		"secret":                       "secret",
+		"label.team":                   "foo",
+		"annotation.example.com/owner": "bar",
+	}
+
+	if actual := Labels(f); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Unexpected labels: %v", actual)
+	}
+}
@@ -0,0 +1,228 @@
+package route
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/multierr"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+)
+
+// DefaultInhibitWindow is the period that a sent alert inhibits other alerts.
+// It covers the maximum INTERVAL, so that the alert is kept until the next verification.
+const DefaultInhibitWindow = 24 * time.Hour
+
+// Router struct implements notifier.Notifier, notifier.Resolver, notifier.DigestNotifier and notifier.NoticeNotifier interface.
+// Router struct sends each alert to the receivers selected by the routing tree.
+type Router struct {
+	Config    *Config
+	Receivers map[string][]notifier.Notifier
+
+	// InhibitWindow is the period that a sent alert is used as the source of inhibit rules.
+	InhibitWindow time.Duration
+
+	// now returns the current time. Used in testing.
+	now func() time.Time
+
+	// active holds labels of sent alerts by the key of finding.
+	active map[string]activeAlert
+}
+
+type activeAlert struct {
+	labels map[string]string
+	sentAt time.Time
+}
+
+// NewRouter function returns new instance of Router.
+// notifiers are configured notifiers by name, that receivers of config refer to.
+func NewRouter(config *Config, notifiers map[string]notifier.Notifier) (*Router, error) {
+	receivers := make(map[string][]notifier.Notifier)
+	for _, r := range config.Receivers {
+		for _, name := range r.Notifiers {
+			n, ok := notifiers[name]
+			if !ok {
+				return nil, fmt.Errorf("notifier %q of receiver %q is not configured in NOTIFIERS", name, r.Name)
+			}
+			receivers[r.Name] = append(receivers[r.Name], n)
+		}
+	}
+
+	return &Router{
+		Config:        config,
+		Receivers:     receivers,
+		InhibitWindow: DefaultInhibitWindow,
+		now:           time.Now,
+		active:        make(map[string]activeAlert),
+	}, nil
+}
+
+// Alert defined by notifier.Notifier interface.
+// This implementation drops muted and inhibited alerts, and sends the others to the routed receivers.
+func (r *Router) Alert(expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS, opt notifier.Option) error {
+	finding := notifier.Finding{Expiration: expiration, Ingress: ingress, TLS: tls, Option: opt}
+	labels := Labels(finding)
+
+	if r.muted(labels) || r.inhibited(finding.Key(), labels) {
+		delete(r.active, finding.Key())
+		return nil
+	}
+	r.active[finding.Key()] = activeAlert{labels: labels, sentAt: r.now()}
+
+	var errs error
+	for _, n := range r.notifiers(labels) {
+		errs = multierr.Append(errs, n.Alert(expiration, ingress, tls, opt))
+	}
+
+	return errs
+}
+
+// Resolve defined by notifier.Resolver interface.
+// This implementation forgets the alert and sends Resolve to all notifiers,
+// since the certificate may have been alerted before routes were changed.
+func (r *Router) Resolve(expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS) error {
+	delete(r.active, notifier.Finding{Ingress: ingress, TLS: tls}.Key())
+
+	var errs error
+	for _, n := range r.all() {
+		if resolver, ok := n.(notifier.Resolver); ok {
+			errs = multierr.Append(errs, resolver.Resolve(expiration, ingress, tls))
+		}
+	}
+
+	return errs
+}
+
+// Digest defined by notifier.DigestNotifier interface.
+// This implementation drops muted and inhibited findings, and sends each notifier the digest of findings routed to it.
+// Notifiers that do not support digest receive the findings as individual alerts.
+func (r *Router) Digest(digest notifier.Digest) error {
+	labels := make([]map[string]string, len(digest.Findings))
+	for i, f := range digest.Findings {
+		labels[i] = Labels(f)
+	}
+
+	// Register all findings first, so that inhibit rules do not depend on the order of findings.
+	for i, f := range digest.Findings {
+		r.active[f.Key()] = activeAlert{labels: labels[i], sentAt: r.now()}
+	}
+
+	var order []notifier.Notifier
+	routed := make(map[notifier.Notifier][]notifier.Finding)
+	for i, f := range digest.Findings {
+		if r.muted(labels[i]) || r.inhibited(f.Key(), labels[i]) {
+			delete(r.active, f.Key())
+			continue
+		}
+
+		for _, n := range r.notifiers(labels[i]) {
+			if _, ok := routed[n]; !ok {
+				order = append(order, n)
+			}
+			routed[n] = append(routed[n], f)
+		}
+	}
+
+	var errs error
+	for _, n := range order {
+		if d, ok := n.(notifier.DigestNotifier); ok {
+			errs = multierr.Append(errs, d.Digest(notifier.Digest{GeneratedAt: digest.GeneratedAt, Findings: routed[n]}))
+			continue
+		}
+
+		for _, f := range routed[n] {
+			errs = multierr.Append(errs, n.Alert(f.Expiration, f.Ingress, f.TLS, f.Option))
+		}
+	}
+
+	return errs
+}
+
+// Notice defined by notifier.NoticeNotifier interface.
+// Notices are not about Ingresses, so this implementation sends the notice to all receivers that support notices
+// regardless of routes, mute and inhibit rules.
+func (r *Router) Notice(notice notifier.Notice) error {
+	var errs error
+	for _, n := range r.all() {
+		if nn, ok := n.(notifier.NoticeNotifier); ok {
+			errs = multierr.Append(errs, nn.Notice(notice))
+		}
+	}
+	return errs
+}
+
+// muted reports whether any mute rule matches labels.
+func (r *Router) muted(labels map[string]string) bool {
+	now := r.now()
+	for _, m := range r.Config.Mute {
+		if m.Until != nil && now.After(*m.Until) {
+			continue
+		}
+		if m.Match.Match(labels) {
+			return true
+		}
+	}
+	return false
+}
+
+// inhibited reports whether any other active alert inhibits the alert with labels.
+func (r *Router) inhibited(key string, labels map[string]string) bool {
+	now := r.now()
+	for _, rule := range r.Config.Inhibit {
+		if !rule.TargetMatch.Match(labels) {
+			continue
+		}
+
+		for k, a := range r.active {
+			if k == key || now.Sub(a.sentAt) > r.InhibitWindow {
+				continue
+			}
+			if !rule.SourceMatch.Match(a.labels) {
+				continue
+			}
+
+			equal := true
+			for _, l := range rule.Equal {
+				if a.labels[l] != labels[l] {
+					equal = false
+					break
+				}
+			}
+			if equal {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// notifiers returns unique notifiers of the receivers that labels are routed to.
+func (r *Router) notifiers(labels map[string]string) []notifier.Notifier {
+	var result []notifier.Notifier
+	seen := make(map[notifier.Notifier]bool)
+	for _, name := range r.Config.Route.Receivers(labels) {
+		for _, n := range r.Receivers[name] {
+			if !seen[n] {
+				seen[n] = true
+				result = append(result, n)
+			}
+		}
+	}
+	return result
+}
+
+// all returns unique notifiers of all receivers.
+func (r *Router) all() []notifier.Notifier {
+	var result []notifier.Notifier
+	seen := make(map[notifier.Notifier]bool)
+	for _, rcv := range r.Config.Receivers {
+		for _, n := range r.Receivers[rcv.Name] {
+			if !seen[n] {
+				seen[n] = true
+				result = append(result, n)
+			}
+		}
+	}
+	return result
+}
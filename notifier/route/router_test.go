@@ -0,0 +1,189 @@
+package route
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+)
+
+type fakeNotifier struct {
+	alerts   []string
+	resolves []string
+	digests  [][]string
+	notices  []string
+}
+
+func (f *fakeNotifier) Alert(expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS, opt notifier.Option) error {
+	f.alerts = append(f.alerts, ingress.Namespace+"/"+ingress.Name)
+	return nil
+}
+
+func (f *fakeNotifier) Resolve(expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS) error {
+	f.resolves = append(f.resolves, ingress.Namespace+"/"+ingress.Name)
+	return nil
+}
+
+type fakeDigestNotifier struct {
+	fakeNotifier
+}
+
+func (f *fakeDigestNotifier) Digest(digest notifier.Digest) error {
+	var names []string
+	for _, finding := range digest.Findings {
+		names = append(names, finding.Ingress.Namespace+"/"+finding.Ingress.Name)
+	}
+	f.digests = append(f.digests, names)
+	return nil
+}
+
+func (f *fakeDigestNotifier) Notice(notice notifier.Notice) error {
+	f.notices = append(f.notices, notice.Title)
+	return nil
+}
+
+func TestNewRouter(t *testing.T) {
+	config, err := ParseConfig([]byte(testConfig))
+	if err != nil {
+		t.Fatalf("Unexpected failed to parse config: %s", err.Error())
+	}
+
+	if _, err := NewRouter(config, map[string]notifier.Notifier{"log": &fakeNotifier{}, "email": &fakeNotifier{}, "slack": &fakeNotifier{}}); err != nil {
+		t.Fatalf("Unexpected failed to create router: %s", err.Error())
+	}
+
+	if _, err := NewRouter(config, map[string]notifier.Notifier{"log": &fakeNotifier{}}); err == nil {
+		t.Fatal("Unexpected success to create router with missing notifiers")
+	}
+}
+
+func TestRouterAlert(t *testing.T) {
+	log, email, slack := &fakeNotifier{}, &fakeNotifier{}, &fakeNotifier{}
+	router := makeTestRouter(t, map[string]notifier.Notifier{"log": log, "email": email, "slack": slack})
+
+	steps := []struct {
+		namespace        string
+		name             string
+		level            notifier.AlertLevel
+		resolve          bool
+		expectedLog      int
+		expectedEmail    int
+		expectedSlack    int
+		expectedResolves int
+	}{
+		// WARNING in default namespace goes to platform.
+		{namespace: "default", name: "ingress1", level: notifier.AlertLevelWarning, expectedLog: 1},
+		// CRITICAL in payments goes to pager and chat, and slack receives it once.
+		{namespace: "payments-api", name: "ingress2", level: notifier.AlertLevelCritical, expectedLog: 1, expectedEmail: 1, expectedSlack: 1},
+		// Alerts in sandbox namespaces are muted.
+		{namespace: "sandbox-1", name: "ingress3", level: notifier.AlertLevelCritical, expectedLog: 1, expectedEmail: 1, expectedSlack: 1},
+		// WARNING in the same namespace as CRITICAL is inhibited.
+		{namespace: "payments-api", name: "ingress4", level: notifier.AlertLevelWarning, expectedLog: 1, expectedEmail: 1, expectedSlack: 1},
+		// Resolve is sent to all notifiers and stops inhibition.
+		{namespace: "payments-api", name: "ingress2", resolve: true, expectedLog: 1, expectedEmail: 1, expectedSlack: 1, expectedResolves: 1},
+		{namespace: "payments-api", name: "ingress4", level: notifier.AlertLevelWarning, expectedLog: 1, expectedEmail: 1, expectedSlack: 2, expectedResolves: 1},
+	}
+
+	for i, step := range steps {
+		ingress := &source.Ingress{Namespace: step.namespace, Name: step.name}
+		tls := &source.IngressTLS{}
+
+		var err error
+		if step.resolve {
+			err = router.Resolve(time.Now(), ingress, tls)
+		} else {
+			err = router.Alert(time.Now(), ingress, tls, notifier.Option{AlertLevel: step.level})
+		}
+		if err != nil {
+			t.Fatalf("Unexpected result in step %d: %s", i, err.Error())
+		}
+
+		if len(log.alerts) != step.expectedLog || len(email.alerts) != step.expectedEmail || len(slack.alerts) != step.expectedSlack {
+			t.Fatalf("Unexpected alerts in step %d: log %v, email %v, slack %v", i, log.alerts, email.alerts, slack.alerts)
+		}
+		for _, n := range []*fakeNotifier{log, email, slack} {
+			if len(n.resolves) != step.expectedResolves {
+				t.Fatalf("Unexpected resolves in step %d: %v", i, n.resolves)
+			}
+		}
+	}
+}
+
+func TestRouterInhibitWindow(t *testing.T) {
+	log, email, slack := &fakeNotifier{}, &fakeNotifier{}, &fakeNotifier{}
+	router := makeTestRouter(t, map[string]notifier.Notifier{"log": log, "email": email, "slack": slack})
+
+	now := time.Now()
+	router.now = func() time.Time { return now }
+	router.Alert(now, &source.Ingress{Namespace: "default", Name: "ingress1"}, &source.IngressTLS{}, notifier.Option{AlertLevel: notifier.AlertLevelCritical})
+
+	// The source alert has expired.
+	router.now = func() time.Time { return now.Add(DefaultInhibitWindow + time.Minute) }
+	router.Alert(now, &source.Ingress{Namespace: "default", Name: "ingress2"}, &source.IngressTLS{}, notifier.Option{AlertLevel: notifier.AlertLevelWarning})
+
+	if len(log.alerts) != 1 {
+		t.Fatalf("Unexpected inhibited alert: %v", log.alerts)
+	}
+}
+
+func TestRouterDigest(t *testing.T) {
+	log, email, slack := &fakeDigestNotifier{}, &fakeNotifier{}, &fakeDigestNotifier{}
+	router := makeTestRouter(t, map[string]notifier.Notifier{"log": log, "email": email, "slack": slack})
+
+	findings := []notifier.Finding{
+		{Ingress: &source.Ingress{Namespace: "default", Name: "ingress1"}, TLS: &source.IngressTLS{}, Option: notifier.Option{AlertLevel: notifier.AlertLevelWarning}},
+		// The WARNING precedes the CRITICAL that inhibits it.
+		{Ingress: &source.Ingress{Namespace: "payments-api", Name: "ingress2"}, TLS: &source.IngressTLS{}, Option: notifier.Option{AlertLevel: notifier.AlertLevelWarning}},
+		{Ingress: &source.Ingress{Namespace: "payments-api", Name: "ingress3"}, TLS: &source.IngressTLS{}, Option: notifier.Option{AlertLevel: notifier.AlertLevelCritical}},
+		{Ingress: &source.Ingress{Namespace: "sandbox-1", Name: "ingress4"}, TLS: &source.IngressTLS{}, Option: notifier.Option{AlertLevel: notifier.AlertLevelCritical}},
+	}
+
+	if err := router.Digest(notifier.Digest{GeneratedAt: time.Now(), Findings: findings}); err != nil {
+		t.Fatalf("Unexpected result: %s", err.Error())
+	}
+
+	if len(log.digests) != 1 || len(log.digests[0]) != 1 || log.digests[0][0] != "default/ingress1" {
+		t.Fatalf("Unexpected digests of log: %v", log.digests)
+	}
+	if len(slack.digests) != 1 || len(slack.digests[0]) != 1 || slack.digests[0][0] != "payments-api/ingress3" {
+		t.Fatalf("Unexpected digests of slack: %v", slack.digests)
+	}
+	// The notifier without digest support receives individual alerts.
+	if len(email.alerts) != 1 || email.alerts[0] != "payments-api/ingress3" {
+		t.Fatalf("Unexpected alerts of email: %v", email.alerts)
+	}
+}
+
+func TestRouterNotice(t *testing.T) {
+	log, email, slack := &fakeDigestNotifier{}, &fakeNotifier{}, &fakeDigestNotifier{}
+	router := makeTestRouter(t, map[string]notifier.Notifier{"log": log, "email": email, "slack": slack})
+
+	if err := router.Notice(notifier.Notice{AlertLevel: notifier.AlertLevelCritical, Title: "title"}); err != nil {
+		t.Fatalf("Unexpected result: %s", err.Error())
+	}
+
+	// Notices are sent to all receivers that support notices regardless of routes.
+	if len(log.notices) != 1 || len(slack.notices) != 1 {
+		t.Fatalf("Unexpected notices: log %v, slack %v", log.notices, slack.notices)
+	}
+	if len(email.notices) != 0 {
+		t.Fatalf("Unexpected notices of email: %v", email.notices)
+	}
+}
+
+func makeTestRouter(t *testing.T, notifiers map[string]notifier.Notifier) *Router {
+	t.Helper()
+
+	config, err := ParseConfig([]byte(testConfig))
+	if err != nil {
+		t.Fatalf("Unexpected failed to parse config: %s", err.Error())
+	}
+
+	router, err := NewRouter(config, notifiers)
+	if err != nil {
+		t.Fatalf("Unexpected failed to create router: %s", err.Error())
+	}
+
+	return router
+}
@@ -5,52 +5,152 @@ import (
 	"strings"
 	"time"
 
-	libSlack "github.com/nlopes/slack"
+	libSlack "github.com/slack-go/slack"
 
 	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
 	"github.com/mercari/certificate-expiry-monitor-controller/source"
 )
 
-// newPostParameters creates params to pass PostMessage function.
-func newPostParameters(expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS, alertLevel notifier.AlertLevel) libSlack.PostMessageParameters {
-	var color, preText string
+// newHeadline creates the default headline of the parent message at now, the time of the check.
+func newHeadline(now time.Time, expiration time.Time, alertLevel notifier.AlertLevel) string {
+	var headline string
 
 	switch alertLevel {
 	case notifier.AlertLevelCritical:
-		color = "danger"
-		days := int64(time.Since(expiration).Hours() / 24)
-		preText = fmt.Sprintf("[CRITICAL] TLS certificate already expired at %d days ago", days)
+		days := int64(now.Sub(expiration).Hours() / 24)
+		headline = fmt.Sprintf(":rotating_light: [CRITICAL] TLS certificate already expired at %d days ago", days)
 	case notifier.AlertLevelWarning:
-		color = "warning"
-		days := int64(time.Until(expiration).Hours() / 24)
-		preText = fmt.Sprintf("[WARNING] TLS certificate will expire within %d days", days)
+		days := int64(expiration.Sub(now).Hours() / 24)
+		headline = fmt.Sprintf(":warning: [WARNING] TLS certificate will expire within %d days", days)
 	}
 
-	return libSlack.PostMessageParameters{
-		Username: "Certificate Expiry Monitor",
-		Attachments: []libSlack.Attachment{
-			libSlack.Attachment{
-				Color:   color,
-				Pretext: preText,
-				Fields:  newAttachmentFields(ingress.ClusterName, ingress.Namespace, ingress.Name, tls.SecretName, expiration, tls.Endpoints),
-			},
-		},
+	return headline
+}
+
+// renderHeadline renders the headline by the template.
+// If the template is nil, renderHeadline returns the default headline.
+func renderHeadline(tmpl *notifier.Template, expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS, opt notifier.Option) (string, error) {
+	if tmpl == nil {
+		return newHeadline(opt.CheckTime(), expiration, opt.AlertLevel), nil
 	}
+
+	return tmpl.Execute(notifier.NewAlertContext(opt.CheckTime(), expiration, ingress, tls, opt))
+}
+
+// newResolvedHeadline creates the headline of the parent message after the certificate has been renewed.
+func newResolvedHeadline(expiration time.Time) string {
+	return fmt.Sprintf(":white_check_mark: [RESOLVED] TLS certificate has been renewed and expires at %s", expiration.Format(time.RFC822))
 }
 
-// newAttachmentFields creates attachment filed slice that may included in PostParameter.
-func newAttachmentFields(cluster string, namespace string, name string, secret string, expiration time.Time, endpoints []*source.TLSEndpoint) []libSlack.AttachmentField {
+// newFollowUpText creates the text of the threaded reply that posted on each subsequent check at now.
+func newFollowUpText(now time.Time, expiration time.Time, alertLevel notifier.AlertLevel, previous notifier.AlertLevel) string {
+	remaining := fmt.Sprintf("%d days remaining", int64(expiration.Sub(now).Hours()/24))
+	if expiration.Before(now) {
+		remaining = fmt.Sprintf("already expired at %d days ago", int64(now.Sub(expiration).Hours()/24))
+	}
+
+	if alertLevel != previous {
+		return fmt.Sprintf("Alert level changed from %s to %s (%s)", previous, alertLevel, remaining)
+	}
+
+	return fmt.Sprintf("Still %s (%s)", alertLevel, remaining)
+}
+
+// newMessageBlocks creates Block Kit blocks of the parent message.
+func newMessageBlocks(headline string, expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS) []libSlack.Block {
+	return []libSlack.Block{
+		libSlack.NewSectionBlock(libSlack.NewTextBlockObject(libSlack.MarkdownType, headline, false, false), nil, nil),
+		libSlack.NewSectionBlock(nil, newFieldBlocks(ingress.ClusterName, ingress.Namespace, ingress.Name, tls.SecretName, expiration, tls.Endpoints), nil),
+	}
+}
+
+// newFieldBlocks creates text objects used as fields of the section block.
+func newFieldBlocks(cluster string, namespace string, name string, secret string, expiration time.Time, endpoints []*source.TLSEndpoint) []*libSlack.TextBlockObject {
 	hosts := make([]string, len(endpoints))
 	for i, e := range endpoints {
-		hosts[i] = e.Hostname + ":" + e.Port
+		hosts[i] = e.String()
+	}
+
+	fields := []struct {
+		title string
+		value string
+	}{
+		{title: "Cluster", value: cluster},
+		{title: "Namespace", value: namespace},
+		{title: "Ingress", value: name},
+		{title: "TLS secret name", value: secret},
+		{title: "Expiration", value: expiration.Format(time.RFC822)},
+		{title: "Hosts", value: strings.Join(hosts, "\n")},
+	}
+
+	objects := make([]*libSlack.TextBlockObject, len(fields))
+	for i, f := range fields {
+		objects[i] = libSlack.NewTextBlockObject(libSlack.MarkdownType, fmt.Sprintf("*%s*\n%s", f.title, f.value), false, false)
+	}
+
+	return objects
+}
+
+const (
+	// maxDigestRows is the number of rows in the table of the digest message.
+	maxDigestRows = 30
+
+	// maxDigestGroups is the number of groups listed in the digest message.
+	// Slack limits the number of blocks in a message to 50.
+	maxDigestGroups = 40
+
+	// maxTextLength is the maximum length of text in a block.
+	maxTextLength = 3000
+)
+
+// newDigestHeadline creates the headline of the digest message.
+func newDigestHeadline(digest notifier.Digest) string {
+	return fmt.Sprintf(":calendar: Certificate expiry digest: %s", digest.Summary())
+}
+
+// newDigestBlocks creates Block Kit blocks of the digest message.
+// The message lists counts per level and namespace, and the table of the soonest expirations.
+func newDigestBlocks(headline string, digest notifier.Digest) []libSlack.Block {
+	blocks := []libSlack.Block{
+		libSlack.NewSectionBlock(libSlack.NewTextBlockObject(libSlack.MarkdownType, headline, false, false), nil, nil),
+	}
+
+	groups := digest.Groups()
+	for i, g := range groups {
+		if i == maxDigestGroups {
+			text := fmt.Sprintf("... and %d more groups", len(groups)-maxDigestGroups)
+			blocks = append(blocks, libSlack.NewContextBlock("", libSlack.NewTextBlockObject(libSlack.MarkdownType, text, false, false)))
+			break
+		}
+
+		names := make([]string, len(g.Findings))
+		for j, f := range g.Findings {
+			names[j] = f.Ingress.Name
+		}
+		text := fmt.Sprintf("*%s* `%s`: %d (%s)", g.AlertLevel, g.Namespace, len(g.Findings), strings.Join(names, ", "))
+		blocks = append(blocks, libSlack.NewContextBlock("", libSlack.NewTextBlockObject(libSlack.MarkdownType, truncate(text, maxTextLength), false, false)))
+	}
+
+	table := "```\n" + truncate(digest.Table(maxDigestRows), maxTextLength-8) + "```"
+	blocks = append(blocks, libSlack.NewSectionBlock(libSlack.NewTextBlockObject(libSlack.MarkdownType, table, false, false), nil, nil))
+
+	return blocks
+}
+
+// newNoticeText creates the text of the notice message.
+func newNoticeText(notice notifier.Notice) string {
+	return fmt.Sprintf("*[%s] %s*\n%s", notice.AlertLevel, notice.Title, notice.Text)
+}
+
+// truncate shortens s to at most n bytes on a line boundary when possible.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
 	}
 
-	return []libSlack.AttachmentField{
-		libSlack.AttachmentField{Title: "Cluster", Value: cluster},
-		libSlack.AttachmentField{Title: "Namespace", Value: namespace},
-		libSlack.AttachmentField{Title: "Ingress", Value: name},
-		libSlack.AttachmentField{Title: "TLS secret name", Value: secret},
-		libSlack.AttachmentField{Title: "Expiration", Value: expiration.Format(time.RFC822)},
-		libSlack.AttachmentField{Title: "Hosts", Value: strings.Join(hosts, "\n")},
+	s = s[:n]
+	if i := strings.LastIndex(s, "\n"); i > 0 {
+		return s[:i+1]
 	}
+	return s
 }
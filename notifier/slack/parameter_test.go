@@ -6,22 +6,23 @@ import (
 	"testing"
 	"time"
 
+	libSlack "github.com/slack-go/slack"
+
 	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
 	"github.com/mercari/certificate-expiry-monitor-controller/source"
 )
 
-func TestNewPostParameters(t *testing.T) {
+func TestNewHeadline(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
 	expectedDays := 5
 
 	type TestArg struct {
 		expiration time.Time
-		ingress    *source.Ingress
-		tls        *source.IngressTLS
 		alertLevel notifier.AlertLevel
 	}
 
 	type TestExpect struct {
-		color      string
+		emoji      string
 		days       int
 		subPreText string
 	}
@@ -34,27 +35,22 @@ func TestNewPostParameters(t *testing.T) {
 	tests := []TestCase{
 		{
 			args: TestArg{
-				// To consider effect of time lapse: `.Add(time.Hour * 12)`
-				expiration: time.Now().AddDate(0, 0, expectedDays).Add(time.Hour * 12),
-				ingress:    makeTestIngress(t),
-				tls:        makeTestIngressTLS(t),
+				expiration: now.AddDate(0, 0, expectedDays).Add(time.Hour * 12),
 				alertLevel: notifier.AlertLevelWarning,
 			},
 			expected: TestExpect{
-				color:      "warning",
+				emoji:      ":warning:",
 				days:       5,
 				subPreText: "[WARNING]",
 			},
 		},
 		{
 			args: TestArg{
-				expiration: time.Now().AddDate(0, 0, -expectedDays),
-				ingress:    makeTestIngress(t),
-				tls:        makeTestIngressTLS(t),
+				expiration: now.AddDate(0, 0, -expectedDays),
 				alertLevel: notifier.AlertLevelCritical,
 			},
 			expected: TestExpect{
-				color:      "danger",
+				emoji:      ":rotating_light:",
 				days:       5,
 				subPreText: "[CRITICAL]",
 			},
@@ -62,24 +58,62 @@ func TestNewPostParameters(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		actual := newPostParameters(test.args.expiration, test.args.ingress, test.args.tls, test.args.alertLevel)
-		actualAttachment := actual.Attachments[0]
+		actual := newHeadline(now, test.args.expiration, test.args.alertLevel)
 
-		if !strings.Contains(actualAttachment.Pretext, test.expected.subPreText) {
-			t.Fatalf("Pretext not includes %s: %s", test.expected.subPreText, actualAttachment.Pretext)
+		if !strings.Contains(actual, test.expected.subPreText) {
+			t.Fatalf("Headline not includes %s: %s", test.expected.subPreText, actual)
 		}
 
-		if actualAttachment.Color != test.expected.color {
-			t.Fatalf("Unexpected Alert color %s, expected %s", actualAttachment.Color, test.expected.color)
+		if !strings.HasPrefix(actual, test.expected.emoji) {
+			t.Fatalf("Unexpected Alert emoji %s, expected %s", actual, test.expected.emoji)
 		}
 
-		if !strings.Contains(actualAttachment.Pretext, strconv.Itoa(test.expected.days)) {
-			t.Fatalf("Pretext not includes expected days %d: %s", test.expected.days, actualAttachment.Pretext)
+		if !strings.Contains(actual, strconv.Itoa(test.expected.days)) {
+			t.Fatalf("Headline not includes expected days %d: %s", test.expected.days, actual)
 		}
 	}
 }
 
-func TestNewAttachmentFields(t *testing.T) {
+func TestNewFollowUpText(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiration := now.AddDate(0, 0, 5).Add(time.Hour * 12)
+
+	actual := newFollowUpText(now, expiration, notifier.AlertLevelWarning, notifier.AlertLevelWarning)
+	if actual != "Still WARNING (5 days remaining)" {
+		t.Fatalf("Unexpected follow-up text: %s", actual)
+	}
+
+	actual = newFollowUpText(now, expiration, notifier.AlertLevelCritical, notifier.AlertLevelWarning)
+	if actual != "Alert level changed from WARNING to CRITICAL (5 days remaining)" {
+		t.Fatalf("Unexpected follow-up text: %s", actual)
+	}
+
+	// Expired certificates are not shown with negative or zero remaining days.
+	actual = newFollowUpText(now, now.Add(-3*time.Hour), notifier.AlertLevelCritical, notifier.AlertLevelCritical)
+	if actual != "Still CRITICAL (already expired at 0 days ago)" {
+		t.Fatalf("Unexpected follow-up text: %s", actual)
+	}
+	actual = newFollowUpText(now, now.AddDate(0, 0, -3), notifier.AlertLevelCritical, notifier.AlertLevelCritical)
+	if actual != "Still CRITICAL (already expired at 3 days ago)" {
+		t.Fatalf("Unexpected follow-up text: %s", actual)
+	}
+}
+
+func TestNewMessageBlocks(t *testing.T) {
+	headline := "headline"
+	actual := newMessageBlocks(headline, time.Now(), makeTestIngress(t), makeTestIngressTLS(t))
+
+	if len(actual) != 2 {
+		t.Fatalf("Unexpected number of blocks: %d", len(actual))
+	}
+
+	section, ok := actual[0].(*libSlack.SectionBlock)
+	if !ok || section.Text.Text != headline {
+		t.Fatalf("Unexpected headline block: %v", actual[0])
+	}
+}
+
+func TestNewFieldBlocks(t *testing.T) {
 	expectedFieldCount := 6
 
 	tests := []struct {
@@ -101,7 +135,7 @@ func TestNewAttachmentFields(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		actual := newAttachmentFields(test.cluster, test.namespace, test.name, test.secret, test.expiration, test.endpoints)
+		actual := newFieldBlocks(test.cluster, test.namespace, test.name, test.secret, test.expiration, test.endpoints)
 
 		if len(actual) != expectedFieldCount {
 			t.Fatalf("Unexpected number of fields: %d", len(actual))
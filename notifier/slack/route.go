@@ -0,0 +1,130 @@
+package slack
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+)
+
+const (
+	// ChannelAnnotation is the annotation of Ingress that overrides the destination channel.
+	ChannelAnnotation = "cert-expiry-monitor/slack-channel"
+
+	// The following values used by ParseRoutes function.
+	routeSeparator   = ";"
+	channelSeparator = "->"
+	matcherSeparator = ","
+	namespaceKey     = "namespace"
+	labelKeyPrefix   = "label."
+)
+
+// Route expresses a rule that selects the destination channel of an alert.
+// Namespace and values of Labels are glob patterns that matched by path.Match.
+// Empty Namespace matches all namespaces.
+type Route struct {
+	Namespace string
+	Labels    map[string]string
+	Channel   string
+}
+
+// ParseRoutes parses routes formatted as `<matchers> -> <channel>` separated by `;`.
+// Matchers are `namespace=<pattern>` or `label.<key>=<pattern>` separated by `,`.
+// For example: `namespace=payments-* -> payments-alerts; label.team=foo -> foo`.
+func ParseRoutes(s string) ([]Route, error) {
+	var routes []Route
+
+	for _, rule := range strings.Split(s, routeSeparator) {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		parts := strings.Split(rule, channelSeparator)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid route %q: must be formatted as <matchers> -> <channel>", rule)
+		}
+
+		route := Route{Channel: normalizeChannel(parts[1])}
+		if route.Channel == "" {
+			return nil, fmt.Errorf("Invalid route %q: channel is missing", rule)
+		}
+
+		for _, matcher := range strings.Split(parts[0], matcherSeparator) {
+			kv := strings.SplitN(strings.TrimSpace(matcher), "=", 2)
+			if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+				return nil, fmt.Errorf("Invalid matcher %q in route %q", matcher, rule)
+			}
+
+			// Validate pattern here to avoid errors when matching.
+			if _, err := path.Match(kv[1], ""); err != nil {
+				return nil, fmt.Errorf("Invalid pattern %q in route %q: %s", kv[1], rule, err.Error())
+			}
+
+			switch {
+			case kv[0] == namespaceKey:
+				route.Namespace = kv[1]
+			case strings.HasPrefix(kv[0], labelKeyPrefix) && len(kv[0]) > len(labelKeyPrefix):
+				if route.Labels == nil {
+					route.Labels = make(map[string]string)
+				}
+				route.Labels[strings.TrimPrefix(kv[0], labelKeyPrefix)] = kv[1]
+			default:
+				return nil, fmt.Errorf("Unexpected matcher key %q in route %q", kv[0], rule)
+			}
+		}
+
+		routes = append(routes, route)
+	}
+
+	return routes, nil
+}
+
+// Match reports whether the Ingress satisfies all matchers of the route.
+func (r Route) Match(ingress *source.Ingress) bool {
+	if r.Namespace != "" {
+		if ok, _ := path.Match(r.Namespace, ingress.Namespace); !ok {
+			return false
+		}
+	}
+
+	for key, pattern := range r.Labels {
+		value, exists := ingress.Labels[key]
+		if !exists {
+			return false
+		}
+		if ok, _ := path.Match(pattern, value); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// channelFor returns the destination channel of the alert about the Ingress.
+// The annotation of the Ingress takes precedence over routes,
+// and the default channel is used when no route matches.
+func (s *Slack) channelFor(ingress *source.Ingress) string {
+	if channel := normalizeChannel(ingress.Annotations[ChannelAnnotation]); channel != "" {
+		return channel
+	}
+
+	for _, r := range s.Routes {
+		if r.Match(ingress) {
+			return r.Channel
+		}
+	}
+
+	return s.ChannelName
+}
+
+func normalizeChannel(channel string) string {
+	return strings.TrimPrefix(strings.TrimSpace(channel), "#")
+}
+
+// Destination returns the channel that the alert about the Ingress is sent to.
+// It is used to log the destination in dry-run mode.
+func (s *Slack) Destination(ingress *source.Ingress) string {
+	return s.channelFor(ingress)
+}
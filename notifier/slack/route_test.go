@@ -0,0 +1,106 @@
+package slack
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+)
+
+func TestParseRoutes(t *testing.T) {
+	tests := []struct {
+		arg      string
+		expected []Route
+		success  bool
+	}{
+		{
+			arg:      "",
+			expected: nil,
+			success:  true,
+		},
+		{
+			arg: "namespace=payments-* -> #payments-alerts; label.team=foo -> foo",
+			expected: []Route{
+				{Namespace: "payments-*", Channel: "payments-alerts"},
+				{Labels: map[string]string{"team": "foo"}, Channel: "foo"},
+			},
+			success: true,
+		},
+		{
+			arg: "namespace=web, label.tier=frontend -> web-frontend;",
+			expected: []Route{
+				{Namespace: "web", Labels: map[string]string{"tier": "frontend"}, Channel: "web-frontend"},
+			},
+			success: true,
+		},
+		{
+			arg:     "namespace=payments-*",
+			success: false,
+		},
+		{
+			arg:     "namespace=payments-* -> ",
+			success: false,
+		},
+		{
+			arg:     "owner=foo -> foo",
+			success: false,
+		},
+		{
+			arg:     "label.=foo -> foo",
+			success: false,
+		},
+		{
+			arg:     "namespace=[ -> foo",
+			success: false,
+		},
+	}
+
+	for _, test := range tests {
+		actual, err := ParseRoutes(test.arg)
+		if (err == nil) != test.success {
+			t.Fatalf("Unexpected result when parse %q: %v", test.arg, err)
+		}
+		if test.success && !reflect.DeepEqual(actual, test.expected) {
+			t.Fatalf("Unexpected routes %v, expected %v", actual, test.expected)
+		}
+	}
+}
+
+func TestChannelFor(t *testing.T) {
+	s := makeTestSlack(t, dummyToken, stubClientChannelName)
+	s.Routes = []Route{
+		{Namespace: "payments-*", Channel: "payments-alerts"},
+		{Labels: map[string]string{"team": "foo"}, Channel: "foo"},
+	}
+
+	tests := []struct {
+		ingress  *source.Ingress
+		expected string
+	}{
+		{
+			ingress:  &source.Ingress{Namespace: "payments-api"},
+			expected: "payments-alerts",
+		},
+		{
+			ingress:  &source.Ingress{Namespace: "web", Labels: map[string]string{"team": "foo"}},
+			expected: "foo",
+		},
+		{
+			ingress:  &source.Ingress{Namespace: "web", Labels: map[string]string{"team": "bar"}},
+			expected: stubClientChannelName,
+		},
+		{
+			ingress: &source.Ingress{
+				Namespace:   "payments-api",
+				Annotations: map[string]string{ChannelAnnotation: "#override"},
+			},
+			expected: "override",
+		},
+	}
+
+	for _, test := range tests {
+		if actual := s.channelFor(test.ingress); actual != test.expected {
+			t.Fatalf("Unexpected channel %s, expected %s", actual, test.expected)
+		}
+	}
+}
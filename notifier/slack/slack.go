@@ -4,7 +4,8 @@ import (
 	"errors"
 	"time"
 
-	libSlack "github.com/nlopes/slack"
+	libSlack "github.com/slack-go/slack"
+	"go.uber.org/multierr"
 	"go.uber.org/ratelimit"
 
 	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
@@ -18,25 +19,60 @@ const (
 
 	// notifierName used by pattern match when parse interpret options.
 	notifierName = "slack"
+
+	// templateKey is the key of the alert template in the template ConfigMap.
+	templateKey = "slack.tmpl"
+
+	// username is the display name of messages.
+	username = "Certificate Expiry Monitor"
+
+	// threadTTL is the time after the last alert that a thread is forgotten.
+	// Resolve is never called for certificates of deleted Ingresses, so that their threads are expired by age.
+	threadTTL = 7 * 24 * time.Hour
 )
 
 // API interface defines slack's API behavior.
-// API interface defined to wrap the library: github.com/nlopes/slack
+// API interface defined to wrap the library: github.com/slack-go/slack
 type API interface {
-	PostMessage(string, string, libSlack.PostMessageParameters) (string, string, error)
+	PostMessage(string, ...libSlack.MsgOption) (string, string, error)
+	UpdateMessage(string, string, ...libSlack.MsgOption) (string, string, string, error)
 }
 
-// Slack struct implements notifier.Notifier interface.
+// Slack struct implements notifier.Notifier, notifier.Resolver, notifier.DigestNotifier and notifier.NoticeNotifier interface.
 // Slack struct sends alert over RESTful API.
+// The first alert of a certificate is posted as a parent message,
+// and subsequent alerts are posted as replies in its thread.
 type Slack struct {
 	APIClient   API
 	ChannelName string
 	RateLimiter ratelimit.Limiter
+
+	// Routes select the destination channel by Ingress metadata.
+	// ChannelName is used when no route matches.
+	Routes []Route
+
+	// Template overrides the default headline of the alert when it is set.
+	Template *notifier.Template
+
+	// threads holds parent messages per certificate.
+	// Messages are kept in memory, so the controller posts new parent messages after restart.
+	// Threads without alerts for threadTTL are forgotten.
+	threads map[string]*thread
+}
+
+// thread expresses the parent message posted for a certificate.
+type thread struct {
+	Destination string
+	Channel     string
+	Timestamp   string
+	AlertLevel  notifier.AlertLevel
+	Expiration  time.Time
+	LastAlert   time.Time
 }
 
 // NewNotifier function returns new instance of Slack.
-// The destination channel is fixed when initialize.
-func NewNotifier(token string, channel string) (notifier.Notifier, error) {
+// The channel is used as the destination when no route matches.
+func NewNotifier(token string, channel string) (*Slack, error) {
 	if token == "" {
 		return nil, errors.New("token is missing")
 	}
@@ -49,6 +85,7 @@ func NewNotifier(token string, channel string) (notifier.Notifier, error) {
 		APIClient:   libSlack.New(token),
 		ChannelName: channel,
 		RateLimiter: ratelimit.New(sendPerSecond),
+		threads:     make(map[string]*thread),
 	}, nil
 }
 
@@ -57,15 +94,151 @@ func String() string {
 	return notifierName
 }
 
+// TemplateKey returns the key of the alert template in the template ConfigMap.
+func TemplateKey() string {
+	return templateKey
+}
+
 // Alert defined by notifier.Notifier interface.
 // This implementation post message that includes infromation about ingress and TLS and those deadline.
+// When the certificate already has a parent message, Alert updates it if the level or expiration changed,
+// and posts a follow-up in its thread.
 func (s *Slack) Alert(expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS, opt notifier.Option) error {
-	params := newPostParameters(expiration, ingress, tls, opt.AlertLevel)
-	return s.postWithRateLimiter(s.ChannelName, "", params)
+	headline, err := renderHeadline(s.Template, expiration, ingress, tls, opt)
+	if err != nil {
+		return err
+	}
+	blocks := newMessageBlocks(headline, expiration, ingress, tls)
+
+	if s.threads == nil {
+		s.threads = make(map[string]*thread)
+	}
+	s.expireThreads(opt.CheckTime())
+
+	destination := s.channelFor(ingress)
+	key := threadKey(ingress, tls)
+
+	// When the destination has been changed, start a new thread in the destination.
+	th, ok := s.threads[key]
+	if !ok || th.Destination != destination {
+		channel, ts, err := s.postWithRateLimiter(destination, messageOptions(headline, blocks)...)
+		if err != nil {
+			return err
+		}
+
+		// Slack returns channel ID that required to update the message.
+		if channel == "" {
+			channel = destination
+		}
+
+		s.threads[key] = &thread{Destination: destination, Channel: channel, Timestamp: ts, AlertLevel: opt.AlertLevel, Expiration: expiration, LastAlert: opt.CheckTime()}
+		return nil
+	}
+
+	if th.AlertLevel != opt.AlertLevel || !th.Expiration.Equal(expiration) {
+		if err := s.updateWithRateLimiter(th.Channel, th.Timestamp, messageOptions(headline, blocks)...); err != nil {
+			return err
+		}
+	}
+
+	followUp := newFollowUpText(opt.CheckTime(), expiration, opt.AlertLevel, th.AlertLevel)
+	if _, _, err := s.postWithRateLimiter(th.Channel, libSlack.MsgOptionText(followUp, false), libSlack.MsgOptionTS(th.Timestamp)); err != nil {
+		return err
+	}
+
+	th.AlertLevel = opt.AlertLevel
+	th.Expiration = expiration
+	th.LastAlert = opt.CheckTime()
+	return nil
+}
+
+// expireThreads forgets threads whose last alert is older than threadTTL at now,
+// e.g. of Ingresses or Secrets that have been deleted.
+func (s *Slack) expireThreads(now time.Time) {
+	for key, th := range s.threads {
+		if now.Sub(th.LastAlert) > threadTTL {
+			delete(s.threads, key)
+		}
+	}
 }
 
-func (s *Slack) postWithRateLimiter(channel string, message string, params libSlack.PostMessageParameters) error {
+// Resolve defined by notifier.Resolver interface.
+// When the certificate has a parent message, Resolve updates it as renewed
+// and forgets it so that the next alert starts a new thread.
+func (s *Slack) Resolve(expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS) error {
+	key := threadKey(ingress, tls)
+	th, ok := s.threads[key]
+	if !ok {
+		return nil
+	}
+
+	headline := newResolvedHeadline(expiration)
+	blocks := newMessageBlocks(headline, expiration, ingress, tls)
+	if err := s.updateWithRateLimiter(th.Channel, th.Timestamp, messageOptions(headline, blocks)...); err != nil {
+		return err
+	}
+
+	if _, _, err := s.postWithRateLimiter(th.Channel, libSlack.MsgOptionText(headline, false), libSlack.MsgOptionTS(th.Timestamp)); err != nil {
+		return err
+	}
+
+	delete(s.threads, key)
+	return nil
+}
+
+// Digest defined by notifier.DigestNotifier interface.
+// This implementation posts one digest message per destination channel.
+func (s *Slack) Digest(digest notifier.Digest) error {
+	var channels []string
+	findings := make(map[string][]notifier.Finding)
+	for _, f := range digest.Findings {
+		channel := s.channelFor(f.Ingress)
+		if _, ok := findings[channel]; !ok {
+			channels = append(channels, channel)
+		}
+		findings[channel] = append(findings[channel], f)
+	}
+
+	var errs error
+	for _, channel := range channels {
+		d := notifier.Digest{GeneratedAt: digest.GeneratedAt, Findings: findings[channel]}
+		headline := newDigestHeadline(d)
+		_, _, err := s.postWithRateLimiter(channel, messageOptions(headline, newDigestBlocks(headline, d))...)
+		errs = multierr.Append(errs, err)
+	}
+
+	return errs
+}
+
+// Notice defined by notifier.NoticeNotifier interface.
+// This implementation posts the notice to the default channel.
+func (s *Slack) Notice(notice notifier.Notice) error {
+	_, _, err := s.postWithRateLimiter(s.ChannelName, libSlack.MsgOptionText(newNoticeText(notice), false))
+	return err
+}
+
+func (s *Slack) postWithRateLimiter(channel string, options ...libSlack.MsgOption) (string, string, error) {
+	s.RateLimiter.Take()
+	options = append(options, libSlack.MsgOptionUsername(username))
+	return s.APIClient.PostMessage(channel, options...)
+}
+
+func (s *Slack) updateWithRateLimiter(channel string, timestamp string, options ...libSlack.MsgOption) error {
 	s.RateLimiter.Take()
-	_, _, err := s.APIClient.PostMessage(channel, message, params)
+	_, _, _, err := s.APIClient.UpdateMessage(channel, timestamp, options...)
 	return err
 }
+
+// messageOptions returns options of the parent message.
+// The text is used as a fallback of the blocks in notifications.
+func messageOptions(text string, blocks []libSlack.Block) []libSlack.MsgOption {
+	return []libSlack.MsgOption{
+		libSlack.MsgOptionText(text, false),
+		libSlack.MsgOptionBlocks(blocks...),
+	}
+}
+
+// threadKey identifies the certificate of the IngressTLS.
+func threadKey(ingress *source.Ingress, tls *source.IngressTLS) string {
+	return notifier.Finding{Ingress: ingress, TLS: tls}.Key()
+}
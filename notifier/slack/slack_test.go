@@ -2,10 +2,13 @@ package slack
 
 import (
 	"errors"
+	"net/url"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
-	libSlack "github.com/nlopes/slack"
+	libSlack "github.com/slack-go/slack"
 	"go.uber.org/ratelimit"
 
 	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
@@ -18,14 +21,34 @@ const (
 )
 
 type fakeClient struct {
-	Token string
+	Token   string
+	Posts   []url.Values
+	Updates []url.Values
 }
 
-func (client *fakeClient) PostMessage(channel string, message string, params libSlack.PostMessageParameters) (string, string, error) {
+func (client *fakeClient) PostMessage(channel string, options ...libSlack.MsgOption) (string, string, error) {
 	if channel != stubClientChannelName {
 		return "", "", errors.New("Unexpected channel name")
 	}
-	return "", "", nil
+	_, values, err := libSlack.UnsafeApplyMsgOptions(client.Token, channel, "", options...)
+	if err != nil {
+		return "", "", err
+	}
+	client.Posts = append(client.Posts, values)
+	return channel, strconv.Itoa(len(client.Posts)), nil
+}
+
+func (client *fakeClient) UpdateMessage(channel string, timestamp string, options ...libSlack.MsgOption) (string, string, string, error) {
+	if channel != stubClientChannelName {
+		return "", "", "", errors.New("Unexpected channel name")
+	}
+	_, values, err := libSlack.UnsafeApplyMsgOptions(client.Token, channel, "", options...)
+	if err != nil {
+		return "", "", "", err
+	}
+	values.Set("ts", timestamp)
+	client.Updates = append(client.Updates, values)
+	return channel, timestamp, "", nil
 }
 
 func TestNewNotifier(t *testing.T) {
@@ -137,17 +160,139 @@ func TestAlert(t *testing.T) {
 	}
 }
 
+func TestAlertWithTemplate(t *testing.T) {
+	s := makeTestSlack(t, dummyToken, stubClientChannelName)
+	tmpl, err := notifier.NewTemplate("test", "{{ .Level }} {{ .Namespace }}/{{ .Ingress }} <!subteam^team>")
+	if err != nil {
+		t.Fatalf("Unexpected failed to parse template: %s", err.Error())
+	}
+	s.Template = tmpl
+
+	err = s.Alert(time.Now(), makeTestIngress(t), makeTestIngressTLS(t), notifier.Option{AlertLevel: notifier.AlertLevelWarning})
+	if err != nil {
+		t.Fatalf("Unexpected result: %s", err.Error())
+	}
+
+	expected := "WARNING DummyNamespace/DummyName <!subteam^team>"
+	if actual := s.APIClient.(*fakeClient).Posts[0].Get("text"); actual != expected {
+		t.Fatalf("Unexpected headline %q, expected %q", actual, expected)
+	}
+}
+
+func TestAlertThreading(t *testing.T) {
+	s := makeTestSlack(t, dummyToken, stubClientChannelName)
+	s.RateLimiter = ratelimit.NewUnlimited()
+	client := s.APIClient.(*fakeClient)
+
+	expiration := time.Now().AddDate(0, 0, 5)
+	ingress := makeTestIngress(t)
+	tls := makeTestIngressTLS(t)
+
+	steps := []struct {
+		alert           bool
+		opt             notifier.Option
+		expectedPosts   int
+		expectedUpdates int
+		expectedThread  bool
+	}{
+		// The first alert posts a parent message.
+		{alert: true, opt: notifier.Option{AlertLevel: notifier.AlertLevelWarning}, expectedPosts: 1, expectedUpdates: 0, expectedThread: true},
+		// The same level posts a follow-up only.
+		{alert: true, opt: notifier.Option{AlertLevel: notifier.AlertLevelWarning}, expectedPosts: 2, expectedUpdates: 0, expectedThread: true},
+		// The level change updates the parent message and posts a follow-up.
+		{alert: true, opt: notifier.Option{AlertLevel: notifier.AlertLevelCritical}, expectedPosts: 3, expectedUpdates: 1, expectedThread: true},
+		// The renewal updates the parent message and posts a follow-up.
+		{alert: false, expectedPosts: 4, expectedUpdates: 2, expectedThread: false},
+		// The resolved certificate has no parent message.
+		{alert: false, expectedPosts: 4, expectedUpdates: 2, expectedThread: false},
+	}
+
+	for i, step := range steps {
+		var err error
+		if step.alert {
+			err = s.Alert(expiration, ingress, tls, step.opt)
+		} else {
+			err = s.Resolve(expiration.AddDate(0, 3, 0), ingress, tls)
+		}
+		if err != nil {
+			t.Fatalf("Unexpected result in step %d: %s", i, err.Error())
+		}
+
+		if len(client.Posts) != step.expectedPosts {
+			t.Fatalf("Unexpected number of posts in step %d: %d", i, len(client.Posts))
+		}
+		if len(client.Updates) != step.expectedUpdates {
+			t.Fatalf("Unexpected number of updates in step %d: %d", i, len(client.Updates))
+		}
+		if _, ok := s.threads[threadKey(ingress, tls)]; ok != step.expectedThread {
+			t.Fatalf("Unexpected thread existence in step %d: %t", i, ok)
+		}
+	}
+
+	// Follow-ups and updates refer to the parent message.
+	for _, values := range client.Posts[1:] {
+		if values.Get("thread_ts") != "1" {
+			t.Fatalf("Unexpected thread_ts of follow-up: %s", values.Get("thread_ts"))
+		}
+	}
+	for _, values := range client.Updates {
+		if values.Get("ts") != "1" {
+			t.Fatalf("Unexpected ts of update: %s", values.Get("ts"))
+		}
+	}
+
+	// After resolved, the next alert posts a new parent message.
+	if err := s.Alert(expiration, ingress, tls, notifier.Option{AlertLevel: notifier.AlertLevelWarning}); err != nil {
+		t.Fatalf("Unexpected result: %s", err.Error())
+	}
+	if client.Posts[len(client.Posts)-1].Get("thread_ts") != "" {
+		t.Fatal("Unexpected follow-up after resolved")
+	}
+}
+
+func TestDigest(t *testing.T) {
+	s := makeTestSlack(t, dummyToken, stubClientChannelName)
+	s.RateLimiter = ratelimit.NewUnlimited()
+	client := s.APIClient.(*fakeClient)
+
+	now := time.Now()
+	digest := notifier.Digest{
+		GeneratedAt: now,
+		Findings: []notifier.Finding{
+			{Expiration: now.AddDate(0, 0, 3), Ingress: makeTestIngress(t), TLS: makeTestIngressTLS(t), Option: notifier.Option{AlertLevel: notifier.AlertLevelWarning}},
+			{Expiration: now.AddDate(0, 0, -1), Ingress: makeTestIngress(t), TLS: makeTestIngressTLS(t), Option: notifier.Option{AlertLevel: notifier.AlertLevelCritical}},
+		},
+	}
+
+	if err := s.Digest(digest); err != nil {
+		t.Fatalf("Unexpected result: %s", err.Error())
+	}
+
+	if len(client.Posts) != 1 {
+		t.Fatalf("Unexpected number of posts: %d", len(client.Posts))
+	}
+	if text := client.Posts[0].Get("text"); !strings.Contains(text, "1 CRITICAL, 1 WARNING") {
+		t.Fatalf("Unexpected digest text: %s", text)
+	}
+
+	// Findings routed to the other channel are posted separately.
+	s.Routes = []Route{{Namespace: "DummyNamespace", Channel: "Dummy" + stubClientChannelName}}
+	if err := s.Digest(digest); err == nil {
+		t.Fatal("Unexpected result: Digest should be fail")
+	}
+}
+
 func TestPostWithRateLimiter(t *testing.T) {
 	s := makeTestSlack(t, dummyToken, stubClientChannelName)
 
-	err := s.postWithRateLimiter(stubClientChannelName, "", libSlack.PostMessageParameters{})
+	_, _, err := s.postWithRateLimiter(stubClientChannelName)
 	if err != nil {
 		t.Fatal("Raise error when testing postWithRateLimiter")
 	}
 
 	before := time.Now()
 
-	err = s.postWithRateLimiter(stubClientChannelName, "", libSlack.PostMessageParameters{})
+	_, _, err = s.postWithRateLimiter(stubClientChannelName)
 	if err != nil {
 		t.Fatal("Raise error when testing postWithRateLimiter")
 	}
@@ -168,6 +313,39 @@ func makeTestSlack(t *testing.T, token string, channel string) *Slack {
 	}
 }
 
+func TestAlertExpireThreads(t *testing.T) {
+	s := makeTestSlack(t, dummyToken, stubClientChannelName)
+	s.RateLimiter = ratelimit.NewUnlimited()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiration := now.AddDate(0, 0, 5)
+	deleted := makeTestIngress(t)
+	deleted.Name = "DeletedName"
+	tls := makeTestIngressTLS(t)
+
+	if err := s.Alert(expiration, deleted, tls, notifier.Option{AlertLevel: notifier.AlertLevelWarning, CheckedAt: now}); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	// The thread of the deleted Ingress is kept within threadTTL, and forgotten after it.
+	steps := []struct {
+		elapsed        time.Duration
+		expectedThread bool
+	}{
+		{elapsed: threadTTL, expectedThread: true},
+		{elapsed: threadTTL + time.Hour, expectedThread: false},
+	}
+	for i, step := range steps {
+		opt := notifier.Option{AlertLevel: notifier.AlertLevelWarning, CheckedAt: now.Add(step.elapsed)}
+		if err := s.Alert(expiration, makeTestIngress(t), tls, opt); err != nil {
+			t.Fatalf("Unexpected error in step %d: %s", i, err.Error())
+		}
+		if _, ok := s.threads[threadKey(deleted, tls)]; ok != step.expectedThread {
+			t.Fatalf("Unexpected thread existence in step %d: %t", i, ok)
+		}
+	}
+}
+
 func makeTestIngress(t *testing.T) *source.Ingress {
 	t.Helper()
 	return &source.Ingress{
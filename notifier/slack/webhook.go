@@ -0,0 +1,168 @@
+package slack
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	libSlack "github.com/slack-go/slack"
+	"go.uber.org/multierr"
+	"go.uber.org/ratelimit"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+)
+
+const (
+	// webhookTimeout is the timeout of a request to the incoming webhook.
+	webhookTimeout = 10 * time.Second
+
+	// maxRetryAfter caps the delay that requested by Retry-After header of rate limited responses.
+	maxRetryAfter = 30 * time.Second
+)
+
+// Webhook struct implements notifier.Notifier, notifier.DigestNotifier and notifier.NoticeNotifier interface.
+// Webhook struct sends alert to incoming webhooks, that bound to their own channel.
+// Incoming webhooks cannot update messages, so each alert is posted as a new message.
+type Webhook struct {
+	URLs        []string
+	HTTPClient  *http.Client
+	RateLimiter ratelimit.Limiter
+
+	// Template overrides the default headline of the alert when it is set.
+	Template *notifier.Template
+}
+
+// NewWebhookNotifier function returns new instance of Webhook that posts to all of urls.
+func NewWebhookNotifier(urls []string) (*Webhook, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("webhook url is missing")
+	}
+
+	for _, u := range urls {
+		if !strings.HasPrefix(u, "https://") && !strings.HasPrefix(u, "http://") {
+			return nil, fmt.Errorf("webhook url must be http or https: %s", u)
+		}
+	}
+
+	return &Webhook{
+		URLs:        urls,
+		HTTPClient:  &http.Client{Timeout: webhookTimeout},
+		RateLimiter: ratelimit.New(sendPerSecond),
+	}, nil
+}
+
+// Alert defined by notifier.Notifier interface.
+// This implementation posts the same message as Slack to all webhooks.
+// When some webhooks fail, Alert still posts to the others and returns the combined error.
+func (w *Webhook) Alert(expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS, opt notifier.Option) error {
+	headline, err := renderHeadline(w.Template, expiration, ingress, tls, opt)
+	if err != nil {
+		return err
+	}
+
+	msg := &libSlack.WebhookMessage{
+		Username: username,
+		Text:     headline,
+		Blocks:   &libSlack.Blocks{BlockSet: newMessageBlocks(headline, expiration, ingress, tls)},
+	}
+
+	var errs error
+	for _, u := range w.URLs {
+		errs = multierr.Append(errs, w.postWithRateLimiter(u, msg))
+	}
+
+	return errs
+}
+
+// Digest defined by notifier.DigestNotifier interface.
+// This implementation posts the digest message to all webhooks.
+func (w *Webhook) Digest(digest notifier.Digest) error {
+	headline := newDigestHeadline(digest)
+	msg := &libSlack.WebhookMessage{
+		Username: username,
+		Text:     headline,
+		Blocks:   &libSlack.Blocks{BlockSet: newDigestBlocks(headline, digest)},
+	}
+
+	var errs error
+	for _, u := range w.URLs {
+		errs = multierr.Append(errs, w.postWithRateLimiter(u, msg))
+	}
+
+	return errs
+}
+
+// Notice defined by notifier.NoticeNotifier interface.
+// This implementation posts the notice to all webhooks.
+func (w *Webhook) Notice(notice notifier.Notice) error {
+	msg := &libSlack.WebhookMessage{
+		Username: username,
+		Text:     newNoticeText(notice),
+	}
+
+	var errs error
+	for _, u := range w.URLs {
+		errs = multierr.Append(errs, w.postWithRateLimiter(u, msg))
+	}
+
+	return errs
+}
+
+func (w *Webhook) postWithRateLimiter(webhookURL string, msg *libSlack.WebhookMessage) error {
+	w.RateLimiter.Take()
+
+	err := w.post(webhookURL, msg)
+
+	// Retry once when rate limited by the webhook.
+	var rateLimited *libSlack.RateLimitedError
+	if errors.As(err, &rateLimited) && rateLimited.RetryAfter <= maxRetryAfter {
+		time.Sleep(rateLimited.RetryAfter)
+		err = w.post(webhookURL, msg)
+	}
+
+	return err
+}
+
+// post sends msg to the webhook.
+// Slack responds with plain text describing the reason when the request is rejected,
+// e.g. `invalid_payload` or `channel_is_archived`, so post includes it in the error.
+func (w *Webhook) post(webhookURL string, msg *libSlack.WebhookMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.HTTPClient.Post(webhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		// The error includes the url that contains the secret of the webhook.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("Failed to post webhook: %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+		if err != nil {
+			retryAfter = sendPerSecond
+		}
+		return &libSlack.RateLimitedError{RetryAfter: time.Duration(retryAfter) * time.Second}
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("Unexpected response from webhook: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
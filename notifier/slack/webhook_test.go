@@ -0,0 +1,142 @@
+package slack
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	libSlack "github.com/slack-go/slack"
+	"go.uber.org/ratelimit"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+)
+
+func TestNewWebhookNotifier(t *testing.T) {
+	tests := []struct {
+		urls    []string
+		success bool
+	}{
+		{
+			urls:    []string{"https://hooks.slack.com/services/T000/B000/XXXX"},
+			success: true,
+		},
+		{
+			urls:    []string{"https://hooks.slack.com/services/T000/B000/XXXX", "https://hooks.slack.com/services/T000/B001/YYYY"},
+			success: true,
+		},
+		{
+			urls:    []string{},
+			success: false,
+		},
+		{
+			urls:    []string{"hooks.slack.com/services/T000/B000/XXXX"},
+			success: false,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := NewWebhookNotifier(test.urls)
+
+		if (err == nil) != test.success {
+			if test.success {
+				t.Fatalf("Unexpected failed to initialize notifier: %s", err.Error())
+			} else {
+				t.Fatalf("Unexpected successed to initialize notifier")
+			}
+		}
+	}
+}
+
+func TestWebhookAlert(t *testing.T) {
+	var received []libSlack.WebhookMessage
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		var msg libSlack.WebhookMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("Unexpected payload: %s", err.Error())
+		}
+		received = append(received, msg)
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/archived", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+		w.Write([]byte("channel_is_archived"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		urls            []string
+		expectedPosts   int
+		expectedMessage string
+	}{
+		{
+			urls:          []string{server.URL + "/ok", server.URL + "/ok"},
+			expectedPosts: 2,
+		},
+		{
+			// The failing webhook does not prevent posting to the others.
+			urls:            []string{server.URL + "/archived", server.URL + "/ok"},
+			expectedPosts:   1,
+			expectedMessage: "channel_is_archived",
+		},
+	}
+
+	for _, test := range tests {
+		received = nil
+		wh := makeTestWebhook(t, test.urls)
+
+		err := wh.Alert(time.Now(), makeTestIngress(t), makeTestIngressTLS(t), notifier.Option{AlertLevel: notifier.AlertLevelWarning})
+		if test.expectedMessage == "" && err != nil {
+			t.Fatalf("Unexpected result: %s", err.Error())
+		}
+		if test.expectedMessage != "" && (err == nil || !strings.Contains(err.Error(), test.expectedMessage)) {
+			t.Fatalf("Unexpected error %v, expected %s", err, test.expectedMessage)
+		}
+
+		if len(received) != test.expectedPosts {
+			t.Fatalf("Unexpected number of posts: %d", len(received))
+		}
+		for _, msg := range received {
+			if !strings.Contains(msg.Text, "[WARNING]") || msg.Blocks == nil || len(msg.Blocks.BlockSet) != 2 {
+				t.Fatalf("Unexpected message: %v", msg)
+			}
+		}
+	}
+}
+
+func TestWebhookRateLimited(t *testing.T) {
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		if count == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	wh := makeTestWebhook(t, []string{server.URL})
+	err := wh.Alert(time.Now(), makeTestIngress(t), makeTestIngressTLS(t), notifier.Option{AlertLevel: notifier.AlertLevelCritical})
+	if err != nil {
+		t.Fatalf("Unexpected result: %s", err.Error())
+	}
+	if count != 2 {
+		t.Fatalf("Unexpected number of requests: %d", count)
+	}
+}
+
+func makeTestWebhook(t *testing.T, urls []string) *Webhook {
+	t.Helper()
+	return &Webhook{
+		URLs:        urls,
+		HTTPClient:  &http.Client{Timeout: time.Second},
+		RateLimiter: ratelimit.NewUnlimited(),
+	}
+}
@@ -0,0 +1,147 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+)
+
+// AlertContext is the data passed to alert templates.
+// Templates refer to its fields, e.g. `{{ .Namespace }}/{{ .Ingress }}`.
+type AlertContext struct {
+	Level         string
+	Expiration    time.Time
+	DaysRemaining int64 // Rounded down, so that it is negative when the certificate has already expired.
+
+	ClusterName string
+	Namespace   string
+	Ingress     string
+	SecretName  string
+	Hosts       []string
+	Labels      map[string]string
+	Annotations map[string]string
+
+	Certificate *CertificateContext
+}
+
+// CertificateContext expresses the certificate details available to templates.
+type CertificateContext struct {
+	Subject      string
+	Issuer       string
+	SerialNumber string
+	DNSNames     []string
+	NotBefore    time.Time
+	NotAfter     time.Time
+}
+
+// NewAlertContext builds AlertContext from the arguments of Notifier.Alert.
+// DaysRemaining is calculated at now, the time of the check, so that templates agree with the alert level.
+func NewAlertContext(now time.Time, expiration time.Time, ingress *source.Ingress, tls *source.IngressTLS, opt Option) AlertContext {
+	hosts := make([]string, len(tls.Endpoints))
+	for i, e := range tls.Endpoints {
+		hosts[i] = e.String()
+	}
+
+	ctx := AlertContext{
+		Level:         opt.AlertLevel.String(),
+		Expiration:    expiration,
+		DaysRemaining: int64(math.Floor(expiration.Sub(now).Hours() / 24)),
+		ClusterName:   ingress.ClusterName,
+		Namespace:     ingress.Namespace,
+		Ingress:       ingress.Name,
+		SecretName:    tls.SecretName,
+		Hosts:         hosts,
+		Labels:        ingress.Labels,
+		Annotations:   ingress.Annotations,
+	}
+
+	if c := opt.Certificate; c != nil {
+		ctx.Certificate = &CertificateContext{
+			Subject:      c.Subject.String(),
+			Issuer:       c.Issuer.String(),
+			SerialNumber: c.SerialNumber.String(),
+			DNSNames:     c.DNSNames,
+			NotBefore:    c.NotBefore,
+			NotAfter:     c.NotAfter,
+		}
+	}
+
+	return ctx
+}
+
+// templateFuncs are the helper functions available in alert templates.
+var templateFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	"abs": func(n int64) int64 {
+		if n < 0 {
+			return -n
+		}
+		return n
+	},
+}
+
+// Template renders alert messages from AlertContext using text/template.
+type Template struct {
+	tmpl *template.Template
+}
+
+// NewTemplate parses text as an alert template.
+func NewTemplate(name string, text string) (*Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Template{tmpl: tmpl}, nil
+}
+
+// LoadTemplate reads and parses the alert template in path.
+func LoadTemplate(path string) (*Template, error) {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTemplate(path, string(text))
+}
+
+// LoadTemplateFromConfigMap reads and parses the alert template stored in key of the ConfigMap.
+// If the ConfigMap does not contain key, LoadTemplateFromConfigMap returns nil without error.
+func LoadTemplateFromConfigMap(clientSet kubernetes.Interface, namespace string, name string, key string) (*Template, error) {
+	cm, err := clientSet.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	text, ok := cm.Data[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return NewTemplate(fmt.Sprintf("%s/%s/%s", namespace, name, key), text)
+}
+
+// Execute renders the template with ctx.
+func (t *Template) Execute(ctx AlertContext) (string, error) {
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, ctx); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
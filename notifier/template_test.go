@@ -0,0 +1,191 @@
+package notifier
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/source"
+)
+
+func TestNewAlertContext(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	expiration := now.AddDate(0, 0, 5).Add(time.Hour * 12)
+	cert := &x509.Certificate{
+		Subject:      pkix.Name{CommonName: "host01.example.com"},
+		Issuer:       pkix.Name{CommonName: "Dummy CA"},
+		SerialNumber: big.NewInt(1234),
+		NotAfter:     expiration,
+	}
+
+	ctx := NewAlertContext(now, expiration, makeTestIngress(t), makeTestIngressTLS(t), Option{AlertLevel: AlertLevelWarning, Certificate: cert})
+
+	if ctx.Level != "WARNING" {
+		t.Fatalf("Unexpected Level: %s", ctx.Level)
+	}
+	if ctx.DaysRemaining != 5 {
+		t.Fatalf("Unexpected DaysRemaining: %d", ctx.DaysRemaining)
+	}
+	if len(ctx.Hosts) != 2 || ctx.Hosts[0] != "host01.example.com:443" {
+		t.Fatalf("Unexpected Hosts: %v", ctx.Hosts)
+	}
+	if ctx.Annotations["runbook"] != "https://runbook.example.com" {
+		t.Fatalf("Unexpected Annotations: %v", ctx.Annotations)
+	}
+	if ctx.Certificate == nil || ctx.Certificate.SerialNumber != "1234" || ctx.Certificate.Issuer != "CN=Dummy CA" {
+		t.Fatalf("Unexpected Certificate: %v", ctx.Certificate)
+	}
+
+	// DaysRemaining is calculated at the time of the check, not at the time of the notification.
+	boundary := NewAlertContext(now, now.Add(time.Hour), makeTestIngress(t), makeTestIngressTLS(t), Option{AlertLevel: AlertLevelWarning})
+	if boundary.DaysRemaining != 0 {
+		t.Fatalf("Unexpected DaysRemaining: %d", boundary.DaysRemaining)
+	}
+
+	// A certificate expired a few hours ago has negative DaysRemaining.
+	recentlyExpired := NewAlertContext(now, now.Add(-3*time.Hour), makeTestIngress(t), makeTestIngressTLS(t), Option{AlertLevel: AlertLevelCritical})
+	if recentlyExpired.DaysRemaining != -1 {
+		t.Fatalf("Unexpected DaysRemaining: %d", recentlyExpired.DaysRemaining)
+	}
+
+	expired := NewAlertContext(now, now.AddDate(0, 0, -5), makeTestIngress(t), makeTestIngressTLS(t), Option{AlertLevel: AlertLevelCritical})
+	if expired.DaysRemaining != -5 {
+		t.Fatalf("Unexpected DaysRemaining: %d", expired.DaysRemaining)
+	}
+	if expired.Certificate != nil {
+		t.Fatalf("Unexpected Certificate: %v", expired.Certificate)
+	}
+}
+
+func TestOptionCheckTime(t *testing.T) {
+	checkedAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := (Option{CheckedAt: checkedAt}).CheckTime(); !got.Equal(checkedAt) {
+		t.Fatalf("Unexpected check time: %s", got)
+	}
+	if got := (Option{}).CheckTime(); got.IsZero() {
+		t.Fatal("Unexpected zero check time")
+	}
+}
+
+func TestTemplateExecute(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+		success  bool
+	}{
+		{
+			text:     "[{{ .Level }}] {{ .Namespace }}/{{ .Ingress }} expires in {{ .DaysRemaining }} days",
+			expected: "[WARNING] DummyNamespace/DummyName expires in 5 days",
+			success:  true,
+		},
+		{
+			text:     `{{ index .Labels "team" | upper }} {{ index .Annotations "runbook" }} {{ join .Hosts "," }}`,
+			expected: "PAYMENTS https://runbook.example.com host01.example.com:443,host02.example.com:443",
+			success:  true,
+		},
+		{
+			text:     `{{ index .Labels "missing" }}`,
+			expected: "",
+			success:  true,
+		},
+		{
+			text:    "{{ .Level ",
+			success: false,
+		},
+	}
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := NewAlertContext(now, now.AddDate(0, 0, 5).Add(time.Hour*12), makeTestIngress(t), makeTestIngressTLS(t), Option{AlertLevel: AlertLevelWarning})
+
+	for _, test := range tests {
+		tmpl, err := NewTemplate("test", test.text)
+		if (err == nil) != test.success {
+			t.Fatalf("Unexpected result when parse template %q: %v", test.text, err)
+		}
+		if err != nil {
+			continue
+		}
+
+		actual, err := tmpl.Execute(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected failed to execute template: %s", err.Error())
+		}
+		if actual != test.expected {
+			t.Fatalf("Unexpected rendered text %q, expected %q", actual, test.expected)
+		}
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "slack.tmpl")
+	if err := ioutil.WriteFile(path, []byte("{{ .Level }}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadTemplate(path); err != nil {
+		t.Fatalf("Unexpected failed to load template: %s", err.Error())
+	}
+
+	if _, err := LoadTemplate(filepath.Join(dir, "missing.tmpl")); err == nil {
+		t.Fatal("Unexpected success to load missing template")
+	}
+}
+
+func TestLoadTemplateFromConfigMap(t *testing.T) {
+	clientSet := fake.NewSimpleClientset(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "templates", Namespace: "kube-system"},
+		Data:       map[string]string{"slack.tmpl": "{{ .Level }}"},
+	})
+
+	tmpl, err := LoadTemplateFromConfigMap(clientSet, "kube-system", "templates", "slack.tmpl")
+	if err != nil || tmpl == nil {
+		t.Fatalf("Unexpected failed to load template: %v", err)
+	}
+
+	tmpl, err = LoadTemplateFromConfigMap(clientSet, "kube-system", "templates", "log.tmpl")
+	if err != nil || tmpl != nil {
+		t.Fatalf("Unexpected result when key is missing: %v, %v", tmpl, err)
+	}
+
+	if _, err := LoadTemplateFromConfigMap(clientSet, "kube-system", "missing", "slack.tmpl"); err == nil {
+		t.Fatal("Unexpected success to load template from missing ConfigMap")
+	}
+}
+
+func makeTestIngress(t *testing.T) *source.Ingress {
+	t.Helper()
+	return &source.Ingress{
+		ClusterName: "DummyClusterName",
+		Namespace:   "DummyNamespace",
+		Name:        "DummyName",
+		Labels:      map[string]string{"team": "payments"},
+		Annotations: map[string]string{"runbook": "https://runbook.example.com"},
+		TLS:         []*source.IngressTLS{},
+	}
+}
+
+func makeTestIngressTLS(t *testing.T) *source.IngressTLS {
+	t.Helper()
+	return &source.IngressTLS{
+		Endpoints: []*source.TLSEndpoint{
+			source.NewTLSEndpoint("host01.example.com", "443"),
+			source.NewTLSEndpoint("host02.example.com", "443"),
+		},
+		SecretName: "DummySecretName",
+	}
+}
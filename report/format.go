@@ -0,0 +1,119 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats of reports.
+const (
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+)
+
+// formatColumns are columns of reports in table, CSV and Markdown formats.
+var formatColumns = []string{"LEVEL", "DAYS", "NOT AFTER", "NAMESPACE", "INGRESS", "SECRET", "HOSTS", "ISSUER", "ERROR"}
+
+// ParseStatus returns Status of case insensitive s.
+func ParseStatus(s string) (Status, error) {
+	status := Status(strings.ToUpper(s))
+	switch status {
+	case StatusOK, StatusWarning, StatusCritical, StatusError:
+		return status, nil
+	default:
+		return "", fmt.Errorf("status must be OK, WARNING, CRITICAL or ERROR: %s", s)
+	}
+}
+
+// AtLeast reports whether s is as severe as or more severe than other.
+// Statuses are ordered as OK, ERROR, WARNING and CRITICAL.
+func (s Status) AtLeast(other Status) bool {
+	return s.severity() >= other.severity()
+}
+
+// DaysRemaining returns days until the expiration at now, truncated toward zero.
+// If the certificate was not retrieved, DaysRemaining returns false.
+func (t TLS) DaysRemaining(now time.Time) (int64, bool) {
+	if t.NotAfter == nil {
+		return 0, false
+	}
+	return int64(t.NotAfter.Sub(now).Hours() / 24), true
+}
+
+// Format writes reports to w in format.
+// In table, CSV and Markdown formats, each row expresses the certificate of IngressTLS.
+// In JSON format, reports are written in the same shape as the response of /api/v1/certificates.
+func Format(w io.Writer, format string, reports []*Report, now time.Time) error {
+	switch format {
+	case FormatJSON:
+		if reports == nil {
+			reports = []*Report{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Certificates []*Report `json:"certificates"`
+		}{reports})
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		cw.Write(formatColumns)
+		for _, row := range formatRows(reports, now) {
+			cw.Write(row)
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatMarkdown:
+		fmt.Fprintf(w, "| %s |\n", strings.Join(formatColumns, " | "))
+		fmt.Fprintf(w, "|%s\n", strings.Repeat("---|", len(formatColumns)))
+		for _, row := range formatRows(reports, now) {
+			for i, v := range row {
+				row[i] = strings.Replace(v, "|", `\|`, -1)
+			}
+			fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | "))
+		}
+		return nil
+	case FormatTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(formatColumns, "\t"))
+		for _, row := range formatRows(reports, now) {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("format must be %s, %s, %s or %s: %s", FormatTable, FormatJSON, FormatCSV, FormatMarkdown, format)
+	}
+}
+
+func formatRows(reports []*Report, now time.Time) [][]string {
+	var rows [][]string
+	for _, r := range reports {
+		for _, tls := range r.TLS {
+			days, notAfter := "-", "-"
+			if d, ok := tls.DaysRemaining(now); ok {
+				days = strconv.FormatInt(d, 10)
+				notAfter = tls.NotAfter.UTC().Format(time.RFC3339)
+			}
+
+			rows = append(rows, []string{
+				string(tls.Status),
+				days,
+				notAfter,
+				r.Namespace,
+				r.Ingress,
+				tls.SecretName,
+				strings.Join(tls.Hosts(), ","),
+				tls.Issuer(),
+				tls.LastError,
+			})
+		}
+	}
+	return rows
+}
@@ -0,0 +1,126 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := now.Add(3 * 24 * time.Hour)
+
+	reports := []*Report{
+		{
+			Namespace: "payments",
+			Ingress:   "api",
+			TLS: []TLS{
+				{
+					SecretName: "api-tls",
+					Status:     StatusWarning,
+					NotAfter:   &notAfter,
+					Endpoints:  []Endpoint{{Host: "api.example.com", Chain: []Certificate{{Issuer: "CN=Example CA"}}}},
+				},
+				{
+					SecretName: "legacy-tls",
+					Status:     StatusError,
+					LastError:  "i/o timeout | retry",
+					Endpoints:  []Endpoint{{Host: "legacy.example.com", Error: "i/o timeout | retry"}},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{
+			format: FormatCSV,
+			expected: "LEVEL,DAYS,NOT AFTER,NAMESPACE,INGRESS,SECRET,HOSTS,ISSUER,ERROR\n" +
+				"WARNING,3,2020-01-04T00:00:00Z,payments,api,api-tls,api.example.com,CN=Example CA,\n" +
+				"ERROR,-,-,payments,api,legacy-tls,legacy.example.com,,i/o timeout | retry\n",
+		},
+		{
+			format: FormatMarkdown,
+			expected: "| LEVEL | DAYS | NOT AFTER | NAMESPACE | INGRESS | SECRET | HOSTS | ISSUER | ERROR |\n" +
+				"|---|---|---|---|---|---|---|---|---|\n" +
+				"| WARNING | 3 | 2020-01-04T00:00:00Z | payments | api | api-tls | api.example.com | CN=Example CA |  |\n" +
+				"| ERROR | - | - | payments | api | legacy-tls | legacy.example.com |  | i/o timeout \\| retry |\n",
+		},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := Format(&buf, test.format, reports, now); err != nil {
+			t.Fatalf("Unexpected error in %s: %s", test.format, err.Error())
+		}
+		if buf.String() != test.expected {
+			t.Fatalf("Unexpected output in %s:\n%s", test.format, buf.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Format(&buf, FormatTable, reports, now); err != nil {
+		t.Fatalf("Unexpected error in table: %s", err.Error())
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[1], "WARNING  3 ") {
+		t.Fatalf("Unexpected output in table:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := Format(&buf, FormatJSON, reports, now); err != nil {
+		t.Fatalf("Unexpected error in json: %s", err.Error())
+	}
+	var res struct {
+		Certificates []*Report `json:"certificates"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &res); err != nil || len(res.Certificates) != 1 || len(res.Certificates[0].TLS) != 2 {
+		t.Fatalf("Unexpected output in json:\n%s", buf.String())
+	}
+
+	if err := Format(&buf, "xml", reports, now); err == nil {
+		t.Fatal("Unexpected success with unknown format")
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		arg      string
+		expected Status
+		success  bool
+	}{
+		{arg: "warning", expected: StatusWarning, success: true},
+		{arg: "CRITICAL", expected: StatusCritical, success: true},
+		{arg: "unknown", success: false},
+	}
+
+	for _, test := range tests {
+		actual, err := ParseStatus(test.arg)
+		if (err == nil) != test.success || actual != test.expected {
+			t.Fatalf("Unexpected result of %q: %s, %v", test.arg, actual, err)
+		}
+	}
+}
+
+func TestStatusAtLeast(t *testing.T) {
+	tests := []struct {
+		status   Status
+		other    Status
+		expected bool
+	}{
+		{status: StatusCritical, other: StatusWarning, expected: true},
+		{status: StatusWarning, other: StatusWarning, expected: true},
+		{status: StatusError, other: StatusWarning, expected: false},
+		{status: StatusWarning, other: StatusError, expected: true},
+		{status: StatusOK, other: StatusError, expected: false},
+	}
+
+	for _, test := range tests {
+		if actual := test.status.AtLeast(test.other); actual != test.expected {
+			t.Fatalf("Unexpected result of %s at least %s: %t", test.status, test.other, actual)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package report
+
+import (
+	"sort"
+	"sync"
+)
+
+// Inventory holds the latest reports of all Ingresses.
+// Inventory is safe for concurrent use.
+type Inventory struct {
+	mu      sync.RWMutex
+	reports map[string]*Report
+}
+
+// NewInventory returns new empty instance of Inventory.
+func NewInventory() *Inventory {
+	return &Inventory{
+		reports: make(map[string]*Report),
+	}
+}
+
+// Update replaces all reports with reports of the latest check.
+// When a report has no successful probe, Update keeps the last successful probe time of the previous report.
+func (i *Inventory) Update(reports []*Report) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	updated := make(map[string]*Report, len(reports))
+	for _, r := range reports {
+		if previous, ok := i.reports[r.Key()]; ok && r.LastSuccessfulProbeTime == nil {
+			r.LastSuccessfulProbeTime = previous.LastSuccessfulProbeTime
+		}
+		updated[r.Key()] = r
+	}
+
+	i.reports = updated
+}
+
+// List returns all reports sorted by namespace and name of Ingress.
+// Returned reports must not be modified.
+func (i *Inventory) List() []*Report {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	reports := make([]*Report, 0, len(i.reports))
+	for _, r := range i.reports {
+		reports = append(reports, r)
+	}
+
+	sort.Slice(reports, func(a, b int) bool {
+		return reports[a].Key() < reports[b].Key()
+	})
+
+	return reports
+}
+
+// Get returns the report of the Ingress.
+// Returned report must not be modified.
+func (i *Inventory) Get(namespace, ingress string) (*Report, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	r, ok := i.reports[namespace+"/"+ingress]
+	return r, ok
+}
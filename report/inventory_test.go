@@ -0,0 +1,36 @@
+package report
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInventory(t *testing.T) {
+	inventory := NewInventory()
+	now := time.Now()
+
+	inventory.Update([]*Report{
+		{Namespace: "b", Ingress: "ingress", Status: StatusOK, LastSuccessfulProbeTime: &now},
+		{Namespace: "a", Ingress: "ingress", Status: StatusWarning},
+	})
+
+	reports := inventory.List()
+	if len(reports) != 2 || reports[0].Key() != "a/ingress" || reports[1].Key() != "b/ingress" {
+		t.Fatalf("Unexpected reports: %v", reports)
+	}
+
+	// The last successful probe time is kept when the probe failed.
+	inventory.Update([]*Report{
+		{Namespace: "b", Ingress: "ingress", Status: StatusError},
+	})
+
+	r, ok := inventory.Get("b", "ingress")
+	if !ok || r.Status != StatusError || r.LastSuccessfulProbeTime == nil || !r.LastSuccessfulProbeTime.Equal(now) {
+		t.Fatalf("Unexpected report: %v", r)
+	}
+
+	// Reports of deleted Ingresses are removed.
+	if _, ok := inventory.Get("a", "ingress"); ok {
+		t.Fatal("Unexpected report of deleted Ingress")
+	}
+}
@@ -0,0 +1,137 @@
+package report
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Status expresses the result of the latest check of certificates.
+type Status string
+
+// Statuses of certificates.
+const (
+	StatusOK       Status = "OK"
+	StatusWarning  Status = "WARNING"
+	StatusCritical Status = "CRITICAL"
+	StatusError    Status = "ERROR"
+)
+
+// severity returns the order of statuses used to select the worst status.
+func (s Status) severity() int {
+	switch s {
+	case StatusCritical:
+		return 3
+	case StatusWarning:
+		return 2
+	case StatusError:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Report expresses the latest check results of certificates in the Ingress.
+// Status and NotAfter are the worst status and the earliest expiration of TLS.
+type Report struct {
+	ClusterName             string     `json:"clusterName,omitempty"`
+	Namespace               string     `json:"namespace"`
+	Ingress                 string     `json:"ingress"`
+	UID                     types.UID  `json:"-"`
+	Status                  Status     `json:"status"`
+	NotAfter                *time.Time `json:"notAfter,omitempty"`
+	TLS                     []TLS      `json:"tls"`
+	LastCheckTime           time.Time  `json:"lastCheckTime"`
+	LastError               string     `json:"lastError,omitempty"`
+	LastSuccessfulProbeTime *time.Time `json:"lastSuccessfulProbeTime,omitempty"`
+}
+
+// TLS expresses the check result of the certificate of IngressTLS.
+type TLS struct {
+	SecretName              string     `json:"secretName,omitempty"`
+	Status                  Status     `json:"status"`
+	NotAfter                *time.Time `json:"notAfter,omitempty"`
+	Endpoints               []Endpoint `json:"endpoints"`
+	LastError               string     `json:"lastError,omitempty"`
+	LastSuccessfulProbeTime *time.Time `json:"lastSuccessfulProbeTime,omitempty"`
+}
+
+// Endpoint expresses the probe result of the TLS endpoint.
+// Chain is the certificate chain served by the endpoint, beginning with the end-user certificate.
+type Endpoint struct {
+	Host  string        `json:"host"`
+	Port  string        `json:"port"`
+	Chain []Certificate `json:"chain,omitempty"`
+	Error string        `json:"error,omitempty"`
+}
+
+// Certificate expresses details of the certificate.
+type Certificate struct {
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	SerialNumber string    `json:"serialNumber"`
+	DNSNames     []string  `json:"dnsNames,omitempty"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+}
+
+// NewChain returns details of the certificate chain.
+// SerialNumber is formatted as hexadecimal like `openssl x509 -serial`.
+func NewChain(certificates []*x509.Certificate) []Certificate {
+	chain := make([]Certificate, len(certificates))
+	for i, c := range certificates {
+		chain[i] = Certificate{
+			Subject:      c.Subject.String(),
+			Issuer:       c.Issuer.String(),
+			SerialNumber: fmt.Sprintf("%X", c.SerialNumber),
+			DNSNames:     c.DNSNames,
+			NotBefore:    c.NotBefore,
+			NotAfter:     c.NotAfter,
+		}
+	}
+	return chain
+}
+
+// Key returns the key of the report that is unique in the cluster.
+func (r *Report) Key() string {
+	return r.Namespace + "/" + r.Ingress
+}
+
+// Add appends tls to the report, and updates the summary of the report.
+func (r *Report) Add(tls TLS) {
+	r.TLS = append(r.TLS, tls)
+
+	if tls.Status.severity() > r.Status.severity() || r.Status == "" {
+		r.Status = tls.Status
+	}
+	if tls.NotAfter != nil && (r.NotAfter == nil || tls.NotAfter.Before(*r.NotAfter)) {
+		r.NotAfter = tls.NotAfter
+	}
+	if tls.LastError != "" && r.LastError == "" {
+		r.LastError = tls.LastError
+	}
+	if tls.LastSuccessfulProbeTime != nil && (r.LastSuccessfulProbeTime == nil || tls.LastSuccessfulProbeTime.After(*r.LastSuccessfulProbeTime)) {
+		r.LastSuccessfulProbeTime = tls.LastSuccessfulProbeTime
+	}
+}
+
+// Issuer returns the issuer of the end-user certificate served by the first reachable endpoint.
+func (t TLS) Issuer() string {
+	for _, e := range t.Endpoints {
+		if len(e.Chain) > 0 {
+			return e.Chain[0].Issuer
+		}
+	}
+	return ""
+}
+
+// Hosts returns hosts of endpoints.
+func (t TLS) Hosts() []string {
+	hosts := make([]string, len(t.Endpoints))
+	for i, e := range t.Endpoints {
+		hosts[i] = e.Host
+	}
+	return hosts
+}
@@ -0,0 +1,75 @@
+package report
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestNewChain(t *testing.T) {
+	notAfter := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	certificates := []*x509.Certificate{
+		{
+			Subject:      pkix.Name{CommonName: "example.com"},
+			Issuer:       pkix.Name{CommonName: "Example CA"},
+			SerialNumber: big.NewInt(0x0a1b2c),
+			DNSNames:     []string{"example.com"},
+			NotAfter:     notAfter,
+		},
+	}
+
+	chain := NewChain(certificates)
+	if len(chain) != 1 {
+		t.Fatalf("Unexpected length of chain: %d", len(chain))
+	}
+	if chain[0].Subject != "CN=example.com" || chain[0].Issuer != "CN=Example CA" || chain[0].SerialNumber != "A1B2C" || !chain[0].NotAfter.Equal(notAfter) {
+		t.Fatalf("Unexpected certificate: %v", chain[0])
+	}
+}
+
+func TestReportAdd(t *testing.T) {
+	now := time.Now()
+	early, late := now.Add(time.Hour), now.Add(48*time.Hour)
+
+	r := &Report{Namespace: "namespace", Ingress: "ingress"}
+	r.Add(TLS{Status: StatusOK, NotAfter: &late, LastSuccessfulProbeTime: &now})
+	if r.Status != StatusOK || !r.NotAfter.Equal(late) || r.LastSuccessfulProbeTime == nil {
+		t.Fatalf("Unexpected report: %v", r)
+	}
+
+	r.Add(TLS{Status: StatusError, LastError: "timeout"})
+	if r.Status != StatusError || r.LastError != "timeout" {
+		t.Fatalf("Unexpected report: %v", r)
+	}
+
+	r.Add(TLS{Status: StatusWarning, NotAfter: &early})
+	if r.Status != StatusWarning || !r.NotAfter.Equal(early) {
+		t.Fatalf("Unexpected report: %v", r)
+	}
+
+	r.Add(TLS{Status: StatusOK, NotAfter: &late})
+	if r.Status != StatusWarning || !r.NotAfter.Equal(early) || len(r.TLS) != 4 {
+		t.Fatalf("Unexpected report: %v", r)
+	}
+}
+
+func TestTLSIssuer(t *testing.T) {
+	tls := TLS{
+		Endpoints: []Endpoint{
+			{Host: "a.example.com", Error: "timeout"},
+			{Host: "b.example.com", Chain: []Certificate{{Issuer: "CN=Example CA"}}},
+		},
+	}
+
+	if issuer := tls.Issuer(); issuer != "CN=Example CA" {
+		t.Fatalf("Unexpected issuer: %s", issuer)
+	}
+	if hosts := tls.Hosts(); len(hosts) != 2 || hosts[0] != "a.example.com" || hosts[1] != "b.example.com" {
+		t.Fatalf("Unexpected hosts: %v", hosts)
+	}
+	if issuer := (TLS{}).Issuer(); issuer != "" {
+		t.Fatalf("Unexpected issuer without endpoints: %s", issuer)
+	}
+}
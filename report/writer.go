@@ -0,0 +1,108 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// Kind and resource of CertificateReport custom resource.
+// The CustomResourceDefinition is defined in manifests/certificatereport-crd.yaml.
+const (
+	Kind       = "CertificateReport"
+	APIVersion = "cert-expiry-monitor.mercari.com/v1alpha1"
+)
+
+// GroupVersionResource is the resource of CertificateReport.
+var GroupVersionResource = schema.GroupVersionResource{
+	Group:    "cert-expiry-monitor.mercari.com",
+	Version:  "v1alpha1",
+	Resource: "certificatereports",
+}
+
+// managedByLabel is the label of CertificateReport that identifies the controller.
+const managedByLabel = "app.kubernetes.io/managed-by"
+
+// Writer writes reports as CertificateReport custom resources through the dynamic client.
+// Each CertificateReport has the same namespace and name as the Ingress, and is owned by the Ingress
+// so that it is deleted by garbage collection when the Ingress is deleted.
+type Writer struct {
+	Client dynamic.Interface
+}
+
+// NewWriter returns new instance of Writer.
+func NewWriter(client dynamic.Interface) *Writer {
+	return &Writer{
+		Client: client,
+	}
+}
+
+// Write creates or updates the CertificateReport of the report.
+// When the report has no successful probe, Write keeps the last successful probe time of the existing CertificateReport.
+func (w *Writer) Write(r *Report) error {
+	client := w.Client.Resource(GroupVersionResource).Namespace(r.Namespace)
+
+	obj, err := newObject(r)
+	if err != nil {
+		return err
+	}
+
+	existing, err := client.Get(context.TODO(), r.Ingress, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = client.Create(context.TODO(), obj, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if r.LastSuccessfulProbeTime == nil {
+		if t, ok, _ := unstructured.NestedString(existing.Object, "status", "lastSuccessfulProbeTime"); ok {
+			if err := unstructured.SetNestedField(obj.Object, t, "status", "lastSuccessfulProbeTime"); err != nil {
+				return err
+			}
+		}
+	}
+
+	obj.SetResourceVersion(existing.GetResourceVersion())
+	_, err = client.Update(context.TODO(), obj, metav1.UpdateOptions{})
+	return err
+}
+
+// newObject returns CertificateReport of the report.
+func newObject(r *Report) (*unstructured.Unstructured, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var status map[string]interface{}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, err
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"status": status}}
+	obj.SetAPIVersion(APIVersion)
+	obj.SetKind(Kind)
+	obj.SetNamespace(r.Namespace)
+	obj.SetName(r.Ingress)
+	obj.SetLabels(map[string]string{managedByLabel: "certificate-expiry-monitor-controller"})
+
+	if r.UID != "" {
+		obj.SetOwnerReferences([]metav1.OwnerReference{
+			{
+				APIVersion: "networking.k8s.io/v1",
+				Kind:       "Ingress",
+				Name:       r.Ingress,
+				UID:        r.UID,
+			},
+		})
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,66 @@
+package report
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestWriterWrite(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		GroupVersionResource: Kind + "List",
+	})
+	writer := NewWriter(client)
+
+	probeTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := probeTime.Add(24 * time.Hour)
+
+	r := &Report{Namespace: "namespace", Ingress: "ingress", UID: "uid"}
+	r.Add(TLS{SecretName: "secret", Status: StatusWarning, NotAfter: &notAfter, LastSuccessfulProbeTime: &probeTime})
+	if err := writer.Write(r); err != nil {
+		t.Fatalf("Unexpected failed to create CertificateReport: %s", err.Error())
+	}
+
+	obj := getTestObject(t, client)
+	if status, _, _ := unstructured.NestedString(obj.Object, "status", "status"); status != "WARNING" {
+		t.Fatalf("Unexpected status: %v", obj.Object["status"])
+	}
+	if refs := obj.GetOwnerReferences(); len(refs) != 1 || refs[0].Kind != "Ingress" || refs[0].UID != "uid" {
+		t.Fatalf("Unexpected owner references: %v", refs)
+	}
+
+	// The probe failed, and the last successful probe time is kept.
+	r = &Report{Namespace: "namespace", Ingress: "ingress", UID: "uid"}
+	r.Add(TLS{SecretName: "secret", Status: StatusError, LastError: "timeout"})
+	if err := writer.Write(r); err != nil {
+		t.Fatalf("Unexpected failed to update CertificateReport: %s", err.Error())
+	}
+
+	obj = getTestObject(t, client)
+	if status, _, _ := unstructured.NestedString(obj.Object, "status", "status"); status != "ERROR" {
+		t.Fatalf("Unexpected status: %v", obj.Object["status"])
+	}
+	if lastError, _, _ := unstructured.NestedString(obj.Object, "status", "lastError"); lastError != "timeout" {
+		t.Fatalf("Unexpected last error: %v", obj.Object["status"])
+	}
+	if probe, _, _ := unstructured.NestedString(obj.Object, "status", "lastSuccessfulProbeTime"); probe != "2020-01-01T00:00:00Z" {
+		t.Fatalf("Unexpected last successful probe time: %v", obj.Object["status"])
+	}
+}
+
+func getTestObject(t *testing.T, client *dynamicfake.FakeDynamicClient) *unstructured.Unstructured {
+	t.Helper()
+
+	obj, err := client.Resource(GroupVersionResource).Namespace("namespace").Get(context.TODO(), "ingress", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected failed to get CertificateReport: %s", err.Error())
+	}
+
+	return obj
+}
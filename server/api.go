@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/report"
+)
+
+// certificatesPath is the path of API that lists reports of the latest check.
+const certificatesPath = "/api/v1/certificates"
+
+// certificatesResponse is the response of GET /api/v1/certificates.
+type certificatesResponse struct {
+	Certificates []*report.Report `json:"certificates"`
+}
+
+// errorResponse is the response of API on error.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// certificates handles GET /api/v1/certificates and GET /api/v1/certificates/{namespace}/{ingress}.
+// The list is filtered by `namespace` and `level` query parameters, that accept comma separated values.
+func (s *Server) certificates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		s.writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, certificatesPath), "/")
+	if path != "" {
+		parts := strings.Split(path, "/")
+		if len(parts) != 2 {
+			s.writeJSON(w, http.StatusNotFound, errorResponse{Error: "not found"})
+			return
+		}
+
+		rep, ok := s.Controller.Inventory.Get(parts[0], parts[1])
+		if !ok {
+			s.writeJSON(w, http.StatusNotFound, errorResponse{Error: "certificates of Ingress " + parts[0] + "/" + parts[1] + " not found"})
+			return
+		}
+
+		s.writeJSON(w, http.StatusOK, rep)
+		return
+	}
+
+	namespaces := splitQuery(r.URL.Query().Get("namespace"))
+	levels := splitQuery(strings.ToUpper(r.URL.Query().Get("level")))
+	for _, l := range levels {
+		switch report.Status(l) {
+		case report.StatusOK, report.StatusWarning, report.StatusCritical, report.StatusError:
+		default:
+			s.writeJSON(w, http.StatusBadRequest, errorResponse{Error: "level must be OK, WARNING, CRITICAL or ERROR: " + l})
+			return
+		}
+	}
+
+	res := certificatesResponse{Certificates: []*report.Report{}}
+	for _, rep := range s.Controller.Inventory.List() {
+		if !contains(namespaces, rep.Namespace) || !contains(levels, string(rep.Status)) {
+			continue
+		}
+		res.Certificates = append(res.Certificates, rep)
+	}
+
+	s.writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.Logger.Warn("Failed to write response", zap.Error(err))
+	}
+}
+
+// splitQuery splits comma separated query value, and drops empty values.
+func splitQuery(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// contains reports whether values contain v. Empty values contain any v.
+func contains(values []string, v string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/report"
+)
+
+func TestCertificates(t *testing.T) {
+	s := makeTestServer(t, nil)
+
+	notAfter := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.Controller.Inventory.Update([]*report.Report{
+		{Namespace: "payments", Ingress: "api", Status: report.StatusCritical, NotAfter: &notAfter},
+		{Namespace: "payments", Ingress: "web", Status: report.StatusOK},
+		{Namespace: "default", Ingress: "web", Status: report.StatusError, LastError: "timeout"},
+	})
+
+	tests := []struct {
+		method         string
+		target         string
+		expectedStatus int
+		expectedKeys   []string
+		single         bool
+	}{
+		{
+			method:         http.MethodGet,
+			target:         "/api/v1/certificates",
+			expectedStatus: http.StatusOK,
+			expectedKeys:   []string{"default/web", "payments/api", "payments/web"},
+		},
+		{
+			method:         http.MethodGet,
+			target:         "/api/v1/certificates?namespace=payments",
+			expectedStatus: http.StatusOK,
+			expectedKeys:   []string{"payments/api", "payments/web"},
+		},
+		{
+			method:         http.MethodGet,
+			target:         "/api/v1/certificates?level=critical,error",
+			expectedStatus: http.StatusOK,
+			expectedKeys:   []string{"default/web", "payments/api"},
+		},
+		{
+			method:         http.MethodGet,
+			target:         "/api/v1/certificates?namespace=payments&level=WARNING",
+			expectedStatus: http.StatusOK,
+			expectedKeys:   []string{},
+		},
+		{
+			method:         http.MethodGet,
+			target:         "/api/v1/certificates?level=unknown",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			method:         http.MethodGet,
+			target:         "/api/v1/certificates/payments/api",
+			expectedStatus: http.StatusOK,
+			expectedKeys:   []string{"payments/api"},
+			single:         true,
+		},
+		{
+			method:         http.MethodGet,
+			target:         "/api/v1/certificates/payments/missing",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			method:         http.MethodGet,
+			target:         "/api/v1/certificates/payments",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			method:         http.MethodPost,
+			target:         "/api/v1/certificates",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(test.method, test.target, nil))
+
+		if rec.Code != test.expectedStatus {
+			t.Fatalf("Unexpected status of %s %s: %d", test.method, test.target, rec.Code)
+		}
+		if rec.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("Unexpected content type of %s %s: %s", test.method, test.target, rec.Header().Get("Content-Type"))
+		}
+		if test.expectedKeys == nil {
+			continue
+		}
+
+		var reports []*report.Report
+		if test.single {
+			var r report.Report
+			if err := json.Unmarshal(rec.Body.Bytes(), &r); err != nil {
+				t.Fatalf("Unexpected response of %s: %s", test.target, rec.Body.String())
+			}
+			reports = append(reports, &r)
+		} else {
+			var res certificatesResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatalf("Unexpected response of %s: %s", test.target, rec.Body.String())
+			}
+			reports = res.Certificates
+		}
+
+		if len(reports) != len(test.expectedKeys) {
+			t.Fatalf("Unexpected number of certificates of %s: %s", test.target, rec.Body.String())
+		}
+		for i, r := range reports {
+			if r.Key() != test.expectedKeys[i] {
+				t.Fatalf("Unexpected certificates of %s: %s", test.target, rec.Body.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/report"
+)
+
+//go:embed dashboard.html
+var dashboardHTML string
+
+// dashboardTemplate renders the dashboard. It has no external assets, so that it works in air-gapped clusters.
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(dashboardHTML))
+
+// dashboard is the data of dashboardTemplate.
+type dashboard struct {
+	GeneratedAt string
+	Counts      map[string]int
+	Rows        []dashboardRow
+
+	Namespaces []string
+	Issuers    []string
+	Namespace  string
+	Issuer     string
+	Sort       string
+
+	// SortURL is the URL that toggles the order of days remaining with the current filters.
+	SortURL string
+}
+
+// dashboardRow expresses the certificate of IngressTLS.
+// DaysRemaining is nil when the certificate was not retrieved.
+type dashboardRow struct {
+	Namespace     string
+	Ingress       string
+	SecretName    string
+	Hosts         string
+	Issuer        string
+	Status        report.Status
+	DaysRemaining *int64
+	NotAfter      string
+	LastError     string
+	LastProbe     string
+}
+
+// Orders of rows by days remaining.
+const (
+	sortDaysAsc  = "days"
+	sortDaysDesc = "-days"
+)
+
+// dashboard handles GET / and renders certificates of the latest check as HTML.
+// The rows are filtered by `namespace` and `issuer` and sorted by `sort` query parameters.
+func (s *Server) dashboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	d := dashboard{
+		GeneratedAt: s.now().UTC().Format(time.RFC3339),
+		Counts:      make(map[string]int),
+		Namespace:   query.Get("namespace"),
+		Issuer:      query.Get("issuer"),
+		Sort:        sortDaysAsc,
+	}
+	if query.Get("sort") == sortDaysDesc {
+		d.Sort = sortDaysDesc
+	}
+
+	namespaces, issuers := make(map[string]bool), make(map[string]bool)
+	for _, rep := range s.Controller.Inventory.List() {
+		for _, tls := range rep.TLS {
+			row := newDashboardRow(s.now(), rep, tls)
+
+			namespaces[row.Namespace] = true
+			if row.Issuer != "" {
+				issuers[row.Issuer] = true
+			}
+
+			if (d.Namespace != "" && d.Namespace != row.Namespace) || (d.Issuer != "" && d.Issuer != row.Issuer) {
+				continue
+			}
+
+			d.Counts[string(row.Status)]++
+			d.Rows = append(d.Rows, row)
+		}
+	}
+
+	d.Namespaces, d.Issuers = sortedKeys(namespaces), sortedKeys(issuers)
+	sortDashboardRows(d.Rows, d.Sort == sortDaysDesc)
+
+	toggled := url.Values{}
+	for k, v := range query {
+		toggled[k] = v
+	}
+	if d.Sort == sortDaysDesc {
+		toggled.Set("sort", sortDaysAsc)
+	} else {
+		toggled.Set("sort", sortDaysDesc)
+	}
+	d.SortURL = "/?" + toggled.Encode()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.Execute(w, d); err != nil {
+		s.Logger.Warn("Failed to render dashboard", zap.Error(err))
+	}
+}
+
+func newDashboardRow(now time.Time, rep *report.Report, tls report.TLS) dashboardRow {
+	row := dashboardRow{
+		Namespace:  rep.Namespace,
+		Ingress:    rep.Ingress,
+		SecretName: tls.SecretName,
+		Hosts:      strings.Join(tls.Hosts(), ", "),
+		Issuer:     tls.Issuer(),
+		Status:     tls.Status,
+		LastError:  tls.LastError,
+	}
+
+	if days, ok := tls.DaysRemaining(now); ok {
+		row.DaysRemaining = &days
+		row.NotAfter = tls.NotAfter.UTC().Format(time.RFC3339)
+	}
+	if tls.LastSuccessfulProbeTime != nil {
+		row.LastProbe = tls.LastSuccessfulProbeTime.UTC().Format(time.RFC3339)
+	}
+
+	return row
+}
+
+// sortDashboardRows sorts rows by days remaining.
+// Rows without certificates are placed first regardless of the order, since they need triage most.
+func sortDashboardRows(rows []dashboardRow, desc bool) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i].DaysRemaining, rows[j].DaysRemaining
+		switch {
+		case a == nil || b == nil:
+			return a == nil && b != nil
+		case desc:
+			return *a > *b
+		default:
+			return *a < *b
+		}
+	})
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
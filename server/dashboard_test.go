@@ -0,0 +1,133 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/report"
+)
+
+func TestDashboard(t *testing.T) {
+	s := makeTestServer(t, nil)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	soon, late := now.Add(3*24*time.Hour), now.Add(90*24*time.Hour)
+	s.Controller.Inventory.Update([]*report.Report{
+		{
+			Namespace: "payments",
+			Ingress:   "api",
+			TLS: []report.TLS{
+				{
+					SecretName: "api-tls",
+					Status:     report.StatusWarning,
+					NotAfter:   &soon,
+					Endpoints:  []report.Endpoint{{Host: "api.example.com", Chain: []report.Certificate{{Issuer: "CN=Let's Encrypt"}}}},
+				},
+			},
+		},
+		{
+			Namespace: "payments",
+			Ingress:   "web",
+			TLS: []report.TLS{
+				{
+					SecretName: "web-tls",
+					Status:     report.StatusOK,
+					NotAfter:   &late,
+					Endpoints:  []report.Endpoint{{Host: "web.example.com", Chain: []report.Certificate{{Issuer: "CN=Internal CA"}}}},
+				},
+			},
+		},
+		{
+			Namespace: "default",
+			Ingress:   "broken",
+			TLS: []report.TLS{
+				{
+					SecretName: "broken-tls",
+					Status:     report.StatusError,
+					LastError:  "<timeout>",
+					Endpoints:  []report.Endpoint{{Host: "broken.example.com", Error: "<timeout>"}},
+				},
+			},
+		},
+	})
+
+	tests := []struct {
+		target           string
+		expectedStatus   int
+		expectedOrder    []string
+		unexpectedValues []string
+	}{
+		{
+			target:         "/",
+			expectedStatus: http.StatusOK,
+			// Rows without certificates come first.
+			expectedOrder: []string{"broken-tls", "api-tls", "web-tls"},
+		},
+		{
+			target:         "/?sort=-days",
+			expectedStatus: http.StatusOK,
+			expectedOrder:  []string{"broken-tls", "web-tls", "api-tls"},
+		},
+		{
+			target:           "/?namespace=payments",
+			expectedStatus:   http.StatusOK,
+			expectedOrder:    []string{"api-tls", "web-tls"},
+			unexpectedValues: []string{"broken-tls"},
+		},
+		{
+			target:           "/?issuer=CN%3DInternal+CA",
+			expectedStatus:   http.StatusOK,
+			expectedOrder:    []string{"web-tls"},
+			unexpectedValues: []string{"api-tls", "broken-tls"},
+		},
+		{
+			target:         "/missing",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.target, nil))
+
+		if rec.Code != test.expectedStatus {
+			t.Fatalf("Unexpected status of %s: %d", test.target, rec.Code)
+		}
+
+		body := rec.Body.String()
+		last := -1
+		for _, v := range test.expectedOrder {
+			i := strings.Index(body, ">"+v+"<")
+			if i < 0 || i < last {
+				t.Fatalf("Unexpected order of %s: %v", test.target, test.expectedOrder)
+			}
+			last = i
+		}
+		for _, v := range test.unexpectedValues {
+			if strings.Contains(body, ">"+v+"<") {
+				t.Fatalf("Unexpected row %s in %s", v, test.target)
+			}
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	body := rec.Body.String()
+
+	// Days remaining, errors escaped as HTML and no external assets.
+	for _, v := range []string{"<td>3</td>", "<td>90</td>", "&lt;timeout&gt;", `class="WARNING"`} {
+		if !strings.Contains(body, v) {
+			t.Fatalf("Not found expected value %q in dashboard", v)
+		}
+	}
+	for _, v := range []string{"<script", "http://", "https://"} {
+		if strings.Contains(body, v) {
+			t.Fatalf("Unexpected external asset %q in dashboard", v)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/controller"
+)
+
+// shutdownTimeout is the maximum time to wait for active requests on shutdown.
+const shutdownTimeout = 5 * time.Second
+
+// Server serves HTTP endpoints of the controller.
+// /healthz and /readyz are used as liveness and readiness probes of Kubernetes.
+// /api/v1/certificates serves reports of the latest check as read-only JSON API, and / serves them as HTML dashboard.
+// When PprofEnabled is true, Server also serves profiles of net/http/pprof under /debug/pprof/.
+type Server struct {
+	Addr         string
+	Logger       *zap.Logger
+	Controller   *controller.Controller
+	PprofEnabled bool
+
+	// now returns the current time. Used in testing.
+	now func() time.Time
+}
+
+// NewServer returns new instance of Server.
+func NewServer(addr string, logger *zap.Logger, c *controller.Controller) *Server {
+	return &Server{
+		Addr:       addr,
+		Logger:     logger,
+		Controller: c,
+		now:        time.Now,
+	}
+}
+
+// Handler returns the handler of all endpoints.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.dashboard)
+	mux.HandleFunc("/healthz", s.healthz)
+	mux.HandleFunc("/readyz", s.readyz)
+	mux.HandleFunc(certificatesPath, s.certificates)
+	mux.HandleFunc(certificatesPath+"/", s.certificates)
+
+	if s.PprofEnabled {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	return mux
+}
+
+// Run starts listening on Addr.
+// If stopCh receives message, Run shuts down the server and returns nil.
+func (s *Server) Run(stopCh chan struct{}) error {
+	srv := &http.Server{
+		Addr:    s.Addr,
+		Handler: s.Handler(),
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.Logger.Info("Starting HTTP server...", zap.String("addr", s.Addr))
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-stopCh:
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return srv.Shutdown(ctx)
+	}
+}
+
+// healthz responds whether execution loop of the controller is alive.
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	s.respond(w, "healthz", s.Controller.Healthy(time.Now()))
+}
+
+// readyz responds whether the controller is ready to verify certificates.
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	s.respond(w, "readyz", s.Controller.Ready())
+}
+
+func (s *Server) respond(w http.ResponseWriter, check string, err error) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	if err != nil {
+		s.Logger.Warn("Failed health check", zap.String("check", check), zap.Error(err))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(err.Error() + "\n"))
+		return
+	}
+
+	w.Write([]byte("ok\n"))
+}
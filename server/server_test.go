@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/controller"
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier"
+	"github.com/mercari/certificate-expiry-monitor-controller/notifier/log"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		path           string
+		notifiers      []notifier.Notifier
+		pprofEnabled   bool
+		expectedStatus int
+	}{
+		{
+			path:           "/healthz",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			path:           "/readyz",
+			notifiers:      []notifier.Notifier{log.NewNotifier(zap.NewNop())},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			// no notifiers
+			path:           "/readyz",
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			path:           "/debug/pprof/",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			path:           "/debug/pprof/",
+			pprofEnabled:   true,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		s := makeTestServer(t, test.notifiers)
+		s.PprofEnabled = test.pprofEnabled
+
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+		if rec.Code != test.expectedStatus {
+			t.Fatalf("Unexpected status of %s: %d", test.path, rec.Code)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	s := makeTestServer(t, nil)
+	s.Addr = "127.0.0.1:0"
+
+	stopCh := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Run(stopCh) }()
+
+	close(stopCh)
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("Unexpected error on shutdown: %s", err.Error())
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Unexpected server was not stopped")
+	}
+}
+
+func makeTestServer(t *testing.T, notifiers []notifier.Notifier) *Server {
+	t.Helper()
+
+	c, err := controller.NewController(zap.NewNop(), fake.NewSimpleClientset(), time.Hour, 24*time.Hour, notifiers, nil)
+	if err != nil {
+		t.Fatalf("Unexpected falied to initialize controller: %s", err.Error())
+	}
+
+	return NewServer(":8080", zap.NewNop(), c)
+}
@@ -0,0 +1,154 @@
+package silence
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/hostport"
+)
+
+// SnoozeAnnotation is the annotation of Ingress or TLS Secret that suppresses notifications
+// until the time formatted as RFC 3339, e.g. `2026-11-01T00:00:00Z`.
+const SnoozeAnnotation = "cert-expiry-monitor/snooze-until"
+
+// Silence expresses a rule that suppresses notifications of matching certificates until the time.
+// Host and Namespace are glob patterns that matched by path.Match.
+// Host is normalized in the same way as hosts of Ingresses, e.g. internationalized hostnames are converted to punycode.
+// Serial is the hexadecimal serial number of the certificate, e.g. the output of `openssl x509 -serial`.
+// Empty fields match all certificates, but at least one of them must be set.
+type Silence struct {
+	Host      string    `json:"host,omitempty"`
+	Namespace string    `json:"namespace,omitempty"`
+	Serial    string    `json:"serial,omitempty"`
+	Until     time.Time `json:"until"`
+	Comment   string    `json:"comment,omitempty"`
+}
+
+// config expresses the file of silences.
+type config struct {
+	Silences []Silence `json:"silences"`
+}
+
+// LoadSilences reads and validates silences in path formatted as YAML.
+func LoadSilences(path string) ([]Silence, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSilences(data)
+}
+
+// ParseSilences parses and validates silences formatted as YAML.
+func ParseSilences(data []byte) ([]Silence, error) {
+	var c config
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, err
+	}
+
+	for i := range c.Silences {
+		c.Silences[i].Host = normalizeHostPattern(c.Silences[i].Host)
+		s := c.Silences[i]
+		if s.Host == "" && s.Namespace == "" && s.Serial == "" {
+			return nil, fmt.Errorf("silence %d must have host, namespace or serial", i)
+		}
+		if s.Until.IsZero() {
+			return nil, fmt.Errorf("silence %d must have until", i)
+		}
+		for _, pattern := range []string{s.Host, s.Namespace} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("silence %d has invalid pattern %q: %s", i, pattern, err.Error())
+			}
+		}
+	}
+
+	return c.Silences, nil
+}
+
+// Active reports whether the silence is effective at the time.
+func (s Silence) Active(t time.Time) bool {
+	return t.Before(s.Until)
+}
+
+// Match reports whether the certificate served to hosts in namespace matches the silence.
+// When Host is set, any of hosts must match it.
+func (s Silence) Match(namespace string, hosts []string, cert *x509.Certificate) bool {
+	if s.Namespace != "" {
+		if ok, _ := path.Match(s.Namespace, namespace); !ok {
+			return false
+		}
+	}
+
+	if s.Serial != "" {
+		if cert == nil || normalizeSerial(s.Serial) != normalizeSerial(fmt.Sprintf("%x", cert.SerialNumber)) {
+			return false
+		}
+	}
+
+	if s.Host != "" {
+		matched := false
+		for _, h := range hosts {
+			if ok, _ := path.Match(s.Host, h); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SnoozedUntil returns the time in SnoozeAnnotation of annotations.
+// If annotations do not have SnoozeAnnotation, SnoozedUntil returns the zero time.
+func SnoozedUntil(annotations map[string]string) (time.Time, error) {
+	value, ok := annotations[SnoozeAnnotation]
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	until, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, errors.New("Invalid value of annotation " + SnoozeAnnotation + ": " + value)
+	}
+
+	return until, nil
+}
+
+// normalizeHostPattern normalizes labels of the host pattern without glob characters by hostport.NormalizeHost.
+// Labels that cannot be normalized are kept as they are, in the same way as hosts of Ingresses.
+func normalizeHostPattern(pattern string) string {
+	if !strings.ContainsAny(pattern, `*?[\`) {
+		if host, err := hostport.NormalizeHost(pattern); err == nil {
+			return host
+		}
+		return pattern
+	}
+
+	labels := strings.Split(pattern, ".")
+	for i, label := range labels {
+		if strings.ContainsAny(label, `*?[\`) || label == "" {
+			continue
+		}
+		if normalized, err := hostport.NormalizeHost(label); err == nil {
+			labels[i] = normalized
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+// normalizeSerial removes separators and leading zeros of the hexadecimal serial number.
+func normalizeSerial(serial string) string {
+	serial = strings.ToLower(strings.Replace(serial, ":", "", -1))
+	serial = strings.TrimPrefix(serial, "0x")
+	return strings.TrimLeft(serial, "0")
+}
@@ -0,0 +1,184 @@
+package silence
+
+import (
+	"crypto/x509"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseSilences(t *testing.T) {
+	tests := []struct {
+		arg     string
+		success bool
+	}{
+		{
+			arg:     "silences: [{host: '*.example.com', namespace: payments, until: '2020-01-01T00:00:00Z', comment: renewal in progress}]",
+			success: true,
+		},
+		{
+			arg:     "silences: []",
+			success: true,
+		},
+		{
+			// no matcher
+			arg:     "silences: [{until: '2020-01-01T00:00:00Z'}]",
+			success: false,
+		},
+		{
+			// until is missing
+			arg:     "silences: [{serial: 0a1b}]",
+			success: false,
+		},
+		{
+			// invalid pattern
+			arg:     "silences: [{host: '[', until: '2020-01-01T00:00:00Z'}]",
+			success: false,
+		},
+		{
+			// invalid until
+			arg:     "silences: [{host: example.com, until: tomorrow}]",
+			success: false,
+		},
+		{
+			// unknown field
+			arg:     "silences: [{hosts: [example.com], until: '2020-01-01T00:00:00Z'}]",
+			success: false,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ParseSilences([]byte(test.arg))
+		if (err == nil) != test.success {
+			t.Fatalf("Unexpected result when parse %q: %v", test.arg, err)
+		}
+	}
+}
+
+func TestLoadSilences(t *testing.T) {
+	dir, err := ioutil.TempDir("", "silence")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "silences.yaml")
+	if err := ioutil.WriteFile(path, []byte("silences:\n  - namespace: sandbox-*\n    until: 2020-01-01T00:00:00Z\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	silences, err := LoadSilences(path)
+	if err != nil {
+		t.Fatalf("Unexpected failed to load silences: %s", err.Error())
+	}
+	if len(silences) != 1 || silences[0].Namespace != "sandbox-*" || !silences[0].Until.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected silences: %v", silences)
+	}
+
+	if _, err := LoadSilences(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Fatal("Unexpected success to load missing silences")
+	}
+}
+
+func TestSilenceMatch(t *testing.T) {
+	cert := &x509.Certificate{SerialNumber: big.NewInt(0x0a1b2c)}
+	hosts := []string{"api.example.com", "www.example.com"}
+
+	tests := []struct {
+		silence  Silence
+		expected bool
+	}{
+		{silence: Silence{Namespace: "payments"}, expected: true},
+		{silence: Silence{Namespace: "payments-*"}, expected: false},
+		{silence: Silence{Host: "www.example.com"}, expected: true},
+		{silence: Silence{Host: "*.example.com", Namespace: "payments"}, expected: true},
+		{silence: Silence{Host: "*.example.org"}, expected: false},
+		{silence: Silence{Serial: "0A:1B:2C"}, expected: true},
+		{silence: Silence{Serial: "0a1b2c"}, expected: true},
+		{silence: Silence{Serial: "0a1b2d"}, expected: false},
+		{silence: Silence{Serial: "0a1b2c", Namespace: "default"}, expected: false},
+	}
+
+	for _, test := range tests {
+		if actual := test.silence.Match("payments", hosts, cert); actual != test.expected {
+			t.Fatalf("Unexpected result of %v: %t", test.silence, actual)
+		}
+	}
+
+	if (Silence{Serial: "0a1b2c"}).Match("payments", hosts, nil) {
+		t.Fatal("Unexpected match without certificate")
+	}
+}
+
+func TestParseSilencesNormalizeHost(t *testing.T) {
+	silences, err := ParseSilences([]byte("silences: [{host: '*.Bücher.example', until: '2020-01-01T00:00:00Z'}, {host: 'Bücher.example', until: '2020-01-01T00:00:00Z'}, {host: 'api-*.Example.com', until: '2020-01-01T00:00:00Z'}]"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	// Hosts of Ingresses are normalized by hostport.NormalizeHost.
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{host: "www.xn--bcher-kva.example", expected: "*.xn--bcher-kva.example"},
+		{host: "xn--bcher-kva.example", expected: "xn--bcher-kva.example"},
+		{host: "api-1.example.com", expected: "api-*.example.com"},
+	}
+	for i, test := range tests {
+		if silences[i].Host != test.expected {
+			t.Fatalf("Unexpected normalized host: %s, expected %s", silences[i].Host, test.expected)
+		}
+		if !silences[i].Match("payments", []string{test.host}, nil) {
+			t.Fatalf("Unexpected unmatched host %s of %s", test.host, silences[i].Host)
+		}
+	}
+}
+
+func TestSilenceActive(t *testing.T) {
+	until := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := Silence{Host: "example.com", Until: until}
+
+	if !s.Active(until.Add(-time.Second)) {
+		t.Fatal("Unexpected inactive silence before until")
+	}
+	if s.Active(until) {
+		t.Fatal("Unexpected active silence at until")
+	}
+}
+
+func TestSnoozedUntil(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		expected    time.Time
+		success     bool
+	}{
+		{
+			annotations: nil,
+			expected:    time.Time{},
+			success:     true,
+		},
+		{
+			annotations: map[string]string{SnoozeAnnotation: "2020-01-01T09:00:00+09:00"},
+			expected:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			success:     true,
+		},
+		{
+			annotations: map[string]string{SnoozeAnnotation: "2020-01-01"},
+			success:     false,
+		},
+	}
+
+	for _, test := range tests {
+		actual, err := SnoozedUntil(test.annotations)
+		if (err == nil) != test.success {
+			t.Fatalf("Unexpected result of %v: %v", test.annotations, err)
+		}
+		if test.success && !actual.Equal(test.expected) {
+			t.Fatalf("Unexpected time of %v: %s", test.annotations, actual)
+		}
+	}
+}
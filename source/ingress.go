@@ -1,5 +1,7 @@
 package source
 
+import "k8s.io/apimachinery/pkg/types"
+
 // Ingress expresses information about existing Ingress.
 // Controller requires some fileds of original Ingress struct.
 // So, this definition masks unnecessary fields of https://godoc.org/k8s.io/api/extensions/v1beta1#Ingress
@@ -7,5 +9,8 @@ type Ingress struct {
 	ClusterName string
 	Namespace   string
 	Name        string
+	UID         types.UID
+	Labels      map[string]string
+	Annotations map[string]string
 	TLS         []*IngressTLS
 }
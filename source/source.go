@@ -3,7 +3,10 @@ package source
 import (
 	"context"
 
+	"github.com/mercari/certificate-expiry-monitor-controller/hostport"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -11,6 +14,9 @@ import (
 // Source uses ClientSet to call API endpoint of Kubernetes.
 type Source struct {
 	ClientSet kubernetes.Interface
+
+	// ClusterName is used as the cluster name of Ingresses that do not have it in metadata.
+	ClusterName string
 }
 
 // NewSource creates Source instance that defined Ingresses function.
@@ -36,6 +42,10 @@ func (s *Source) Ingresses() ([]*Ingress, error) {
 
 			endpoints := make([]*TLSEndpoint, len(tls.Hosts))
 			for k, host := range tls.Hosts {
+				// Hosts that cannot be normalized are kept as is, and fail to get certificates.
+				if normalized, err := hostport.NormalizeHost(host); err == nil {
+					host = normalized
+				}
 				// TODO: Support port numbers other than default
 				endpoints[k] = NewTLSEndpoint(host, "")
 			}
@@ -46,13 +56,39 @@ func (s *Source) Ingresses() ([]*Ingress, error) {
 			}
 		}
 
+		clusterName := item.ObjectMeta.ClusterName
+		if clusterName == "" {
+			clusterName = s.ClusterName
+		}
+
 		ingresses[i] = &Ingress{
-			ClusterName: item.ObjectMeta.ClusterName,
+			ClusterName: clusterName,
 			Namespace:   item.ObjectMeta.Namespace,
 			Name:        item.ObjectMeta.Name,
+			UID:         item.ObjectMeta.UID,
+			Labels:      item.ObjectMeta.Labels,
+			Annotations: item.ObjectMeta.Annotations,
 			TLS:         ingressTLSs,
 		}
 	}
 
 	return ingresses, nil
 }
+
+// SecretAnnotations returns annotations of the Secret in namespace.
+// It requires the permission to get Secrets.
+func (s *Source) SecretAnnotations(namespace, name string) (map[string]string, error) {
+	secret, err := s.ClientSet.CoreV1().Secrets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return secret.ObjectMeta.Annotations, nil
+}
+
+// PatchIngress patches the Ingress in namespace with data of patch type pt.
+// It requires the permission to patch Ingresses.
+func (s *Source) PatchIngress(namespace, name string, pt types.PatchType, data []byte) error {
+	_, err := s.ClientSet.NetworkingV1().Ingresses(namespace).Patch(context.TODO(), name, pt, data, metav1.PatchOptions{})
+	return err
+}
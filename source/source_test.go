@@ -3,6 +3,7 @@ package source
 import (
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
@@ -69,6 +70,9 @@ func TestIngresses(t *testing.T) {
 		if ingress.ClusterName != ingressList.Items[i].ObjectMeta.ClusterName {
 			t.Fatalf("Unmatch expected ClusterName: %s", ingress.ClusterName)
 		}
+		if ingress.Labels["protocol"] != ingressList.Items[i].ObjectMeta.Labels["protocol"] {
+			t.Fatalf("Unmatch expected Labels: %v", ingress.Labels)
+		}
 
 		for j, tls := range ingress.TLS {
 			if len(tls.Endpoints) != len(ingressList.Items[i].Spec.TLS[j].Hosts) {
@@ -80,3 +84,48 @@ func TestIngresses(t *testing.T) {
 		}
 	}
 }
+
+func TestIngressesClusterName(t *testing.T) {
+	clientSet := fake.NewSimpleClientset(
+		&v1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "ingress1", Namespace: "namespace1", ClusterName: "metadata"}},
+		&v1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "ingress2", Namespace: "namespace1"}},
+	)
+	source := NewSource(clientSet)
+	source.ClusterName = "configured"
+
+	ingresses, err := source.Ingresses()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	expected := map[string]string{"ingress1": "metadata", "ingress2": "configured"}
+	for _, ingress := range ingresses {
+		if ingress.ClusterName != expected[ingress.Name] {
+			t.Fatalf("Unexpected ClusterName of %s: %s", ingress.Name, ingress.ClusterName)
+		}
+	}
+}
+
+func TestSecretAnnotations(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "secret1",
+			Namespace:   "namespace1",
+			Annotations: map[string]string{"cert-expiry-monitor/snooze-until": "2020-01-01T00:00:00Z"},
+		},
+	}
+
+	source := NewSource(fake.NewSimpleClientset(secret))
+
+	annotations, err := source.SecretAnnotations("namespace1", "secret1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if annotations["cert-expiry-monitor/snooze-until"] != "2020-01-01T00:00:00Z" {
+		t.Fatalf("Unexpected annotations: %v", annotations)
+	}
+
+	if _, err := source.SecretAnnotations("namespace1", "missing"); err == nil {
+		t.Fatal("Unexpected success to get missing Secret")
+	}
+}
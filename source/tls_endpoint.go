@@ -3,7 +3,9 @@ package source
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"net"
 	"strings"
+	"time"
 )
 
 var (
@@ -14,6 +16,10 @@ var (
 	// DefaultPortNumber exposes default port number to testing
 	// TODO: Support port numbers other than :443
 	DefaultPortNumber = "443"
+
+	// DialTimeout is the maximum time to connect and complete TLS handshake. Zero means no timeout.
+	// Without timeout, an endpoint that never completes handshake blocks the controller.
+	DialTimeout = 10 * time.Second
 )
 
 // TLSEndpoint expressses https endpoint that using TLS.
@@ -35,12 +41,17 @@ func NewTLSEndpoint(host string, port string) *TLSEndpoint {
 	}
 }
 
-// GetCertificates tries to get certificates from endpoint using tls.Dial
+// String returns the endpoint formatted as `host:port`, enclosing IPv6 addresses in brackets.
+func (e *TLSEndpoint) String() string {
+	return net.JoinHostPort(e.Hostname, e.Port)
+}
+
+// GetCertificates tries to get certificates from endpoint using tls.Dial within DialTimeout
 func (e *TLSEndpoint) GetCertificates() ([]*x509.Certificate, error) {
 
 	// We cannot connect to Hostnames with wildcards, so replacing with cert-test.
 	hostName := strings.Replace(e.Hostname, "*", "cert-test", -1)
-	conn, err := tls.Dial("tcp", hostName+":"+e.Port, &defaultTLSConfig)
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: DialTimeout}, "tcp", net.JoinHostPort(hostName, e.Port), &defaultTLSConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -1,10 +1,12 @@
 package source
 
 import (
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 )
 
 func TestGetCertificates(t *testing.T) {
@@ -18,6 +20,21 @@ func TestGetCertificates(t *testing.T) {
 			t.Fatalf("Cannot get certificate when using available endpoint %s", u.Hostname()+":"+u.Port())
 		}
 
+		// When using available endpoint by IPv6 address
+		listener, err := net.Listen("tcp6", "[::1]:0")
+		if err == nil {
+			server6 := httptest.NewUnstartedServer(http.NewServeMux())
+			server6.Listener = listener
+			server6.StartTLS()
+			defer server6.Close()
+
+			u6, _ := url.Parse(server6.URL)
+			certs, err = NewTLSEndpoint(u6.Hostname(), u6.Port()).GetCertificates()
+			if err != nil || len(certs) == 0 {
+				t.Fatalf("Cannot get certificate when using available endpoint %s", u6.Host)
+			}
+		}
+
 		// When using unavailable endpoint
 		unavailableEndpoint := NewTLSEndpoint("dummy.localhost.local", "443")
 		certs, err = unavailableEndpoint.GetCertificates()
@@ -27,6 +44,38 @@ func TestGetCertificates(t *testing.T) {
 	})
 }
 
+func TestGetCertificatesTimeout(t *testing.T) {
+	// The listener accepts connections but never completes TLS handshake.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	defaultTimeout := DialTimeout
+	DialTimeout = 100 * time.Millisecond
+	defer func() { DialTimeout = defaultTimeout }()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	start := time.Now()
+	if _, err := NewTLSEndpoint(host, port).GetCertificates(); err == nil {
+		t.Fatal("Unexpected success to get certificates from endpoint without handshake")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Unexpected elapsed time: %s", elapsed)
+	}
+}
+
 func testWithTLSServer(f func(server *httptest.Server)) {
 	server := httptest.NewTLSServer(http.NewServeMux())
 	defer server.Close()
@@ -60,3 +109,19 @@ func TestNewTLSEndpoint(t *testing.T) {
 		}
 	}
 }
+
+func TestTLSEndpointString(t *testing.T) {
+	tests := []struct {
+		endpoint *TLSEndpoint
+		expected string
+	}{
+		{endpoint: NewTLSEndpoint("example.com", ""), expected: "example.com:443"},
+		{endpoint: NewTLSEndpoint("2001:db8::1", "8443"), expected: "[2001:db8::1]:8443"},
+	}
+
+	for _, test := range tests {
+		if got := test.endpoint.String(); got != test.expected {
+			t.Fatalf("Unexpected string: %s, expected %s", got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package synthetics
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Annotations of Ingress that override assertions of synthetics tests of its endpoints.
+const (
+	CertificateDaysAnnotation  = "cert-expiry-monitor/synthetics-certificate-days"
+	MinTLSVersionAnnotation    = "cert-expiry-monitor/synthetics-min-tls-version"
+	MaxResponseTimeAnnotation  = "cert-expiry-monitor/synthetics-max-response-time"
+	AcceptSelfSignedAnnotation = "cert-expiry-monitor/synthetics-accept-self-signed"
+)
+
+// defaultCertificateDays is used when neither TestManager nor the endpoint sets CertificateDays.
+const defaultCertificateDays = 12
+
+// tlsVersions are supported values of MinTLSVersion.
+var tlsVersions = []string{"1.0", "1.1", "1.2", "1.3"}
+
+// Assertions expresses assertions of synthetics tests.
+// Zero values of the endpoint fall back to the values of TestManager.
+type Assertions struct {
+	// CertificateDays asserts the certificate expires in more than the days.
+	CertificateDays int
+
+	// MinTLSVersion asserts the TLS version is the version or later, e.g. `1.2`. Empty disables the assertion.
+	MinTLSVersion string
+
+	// MaxResponseTime asserts the response time is less than the duration. Zero disables the assertion.
+	MaxResponseTime time.Duration
+
+	// AcceptSelfSigned makes the test accept self-signed certificates. Nil falls back to TestManager.
+	AcceptSelfSigned *bool
+}
+
+// DefaultCertificateDays returns the days of certificate assertion derived from the alert threshold of the controller,
+// so that synthetics tests fail when the controller starts alerting.
+func DefaultCertificateDays(threshold time.Duration) int {
+	return int(math.Ceil(threshold.Hours() / 24))
+}
+
+// ValidateTLSVersion returns error if version is not supported by MinTLSVersion.
+func ValidateTLSVersion(version string) error {
+	if version == "" || Contains(tlsVersions, version) {
+		return nil
+	}
+	return fmt.Errorf("TLS version must be one of %v: %s", tlsVersions, version)
+}
+
+// AssertionsFromAnnotations returns assertions set by annotations of Ingress.
+func AssertionsFromAnnotations(annotations map[string]string) (Assertions, error) {
+	var a Assertions
+
+	if v, ok := annotations[CertificateDaysAnnotation]; ok {
+		days, err := strconv.Atoi(v)
+		if err != nil || days <= 0 {
+			return Assertions{}, fmt.Errorf("%s must be a positive integer: %s", CertificateDaysAnnotation, v)
+		}
+		a.CertificateDays = days
+	}
+
+	if v, ok := annotations[MinTLSVersionAnnotation]; ok {
+		if err := ValidateTLSVersion(v); err != nil {
+			return Assertions{}, fmt.Errorf("invalid %s: %s", MinTLSVersionAnnotation, err.Error())
+		}
+		a.MinTLSVersion = v
+	}
+
+	if v, ok := annotations[MaxResponseTimeAnnotation]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return Assertions{}, fmt.Errorf("%s must be a positive duration: %s", MaxResponseTimeAnnotation, v)
+		}
+		a.MaxResponseTime = d
+	}
+
+	if v, ok := annotations[AcceptSelfSignedAnnotation]; ok {
+		accept, err := strconv.ParseBool(v)
+		if err != nil {
+			return Assertions{}, fmt.Errorf("%s must be true or false: %s", AcceptSelfSignedAnnotation, v)
+		}
+		a.AcceptSelfSigned = &accept
+	}
+
+	return a, nil
+}
+
+// Merge returns assertions whose zero values are replaced by defaults.
+func (a Assertions) Merge(defaults Assertions) Assertions {
+	if a.CertificateDays == 0 {
+		a.CertificateDays = defaults.CertificateDays
+	}
+	if a.CertificateDays == 0 {
+		a.CertificateDays = defaultCertificateDays
+	}
+	if a.MinTLSVersion == "" {
+		a.MinTLSVersion = defaults.MinTLSVersion
+	}
+	if a.MaxResponseTime == 0 {
+		a.MaxResponseTime = defaults.MaxResponseTime
+	}
+	if a.AcceptSelfSigned == nil {
+		a.AcceptSelfSigned = defaults.AcceptSelfSigned
+	}
+	return a
+}
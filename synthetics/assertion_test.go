@@ -0,0 +1,80 @@
+package synthetics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDefaultCertificateDays(t *testing.T) {
+	tests := []struct {
+		threshold time.Duration
+		expected  int
+	}{
+		{threshold: 336 * time.Hour, expected: 14},
+		{threshold: 24 * time.Hour, expected: 1},
+		{threshold: 100 * time.Hour, expected: 5},
+	}
+
+	for _, test := range tests {
+		if got := DefaultCertificateDays(test.threshold); got != test.expected {
+			t.Fatalf("Unexpected days of %s: %d, expected %d", test.threshold, got, test.expected)
+		}
+	}
+}
+
+func TestAssertionsFromAnnotations(t *testing.T) {
+	accept := true
+
+	tests := []struct {
+		annotations map[string]string
+		expected    Assertions
+		expectedErr bool
+	}{
+		{
+			annotations: map[string]string{},
+			expected:    Assertions{},
+		},
+		{
+			annotations: map[string]string{
+				CertificateDaysAnnotation:  "30",
+				MinTLSVersionAnnotation:    "1.2",
+				MaxResponseTimeAnnotation:  "500ms",
+				AcceptSelfSignedAnnotation: "true",
+			},
+			expected: Assertions{CertificateDays: 30, MinTLSVersion: "1.2", MaxResponseTime: 500 * time.Millisecond, AcceptSelfSigned: &accept},
+		},
+		{
+			annotations: map[string]string{CertificateDaysAnnotation: "0"},
+			expectedErr: true,
+		},
+		{
+			annotations: map[string]string{MinTLSVersionAnnotation: "1.4"},
+			expectedErr: true,
+		},
+		{
+			annotations: map[string]string{MaxResponseTimeAnnotation: "500"},
+			expectedErr: true,
+		},
+		{
+			annotations: map[string]string{AcceptSelfSignedAnnotation: "yes"},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := AssertionsFromAnnotations(test.annotations)
+		if (err != nil) != test.expectedErr {
+			t.Fatalf("Unexpected error of %v: %v", test.annotations, err)
+		}
+		if test.expectedErr {
+			continue
+		}
+
+		if got.CertificateDays != test.expected.CertificateDays || got.MinTLSVersion != test.expected.MinTLSVersion || got.MaxResponseTime != test.expected.MaxResponseTime {
+			t.Fatalf("Unexpected assertions: %+v, expected %+v", got, test.expected)
+		}
+		if (got.AcceptSelfSigned == nil) != (test.expected.AcceptSelfSigned == nil) {
+			t.Fatalf("Unexpected AcceptSelfSigned: %v, expected %v", got.AcceptSelfSigned, test.expected.AcceptSelfSigned)
+		}
+	}
+}
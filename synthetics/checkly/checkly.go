@@ -0,0 +1,335 @@
+package checkly
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/hostport"
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics"
+)
+
+const (
+	providerName = "checkly"
+
+	// clusterTagPrefix is the prefix of the tag that identifies the cluster owning the check.
+	clusterTagPrefix = synthetics.ClusterTagPrefix
+)
+
+// frequencies are check frequencies in minutes supported by Checkly.
+var frequencies = []int{1, 2, 5, 10, 15, 30, 60, 120, 180, 360, 720, 1440}
+
+// alertThresholds are days before expiration supported by SSL certificate alerts of Checkly.
+var alertThresholds = []int{3, 7, 14, 30}
+
+// Manager synchronize API checks in Checkly with existing Kubernetes Ingress Endpoints.
+// Manager implements synthetics.SyntheticsProvider interface.
+type Manager struct {
+	Client     Client
+	Logger     *zap.Logger
+	DefaultTag string
+	Tags       []string
+	Locations  []string
+
+	// CheckInterval is the interval of checks in seconds.
+	// It is rounded down to a frequency supported by Checkly.
+	CheckInterval int
+
+	// Assertions are the default assertions of checks.
+	// SyntheticEndpoint.Assertions override them per endpoint.
+	// MinTLSVersion is not supported by Checkly and ignored.
+	Assertions synthetics.Assertions
+
+	// MaxDeleteCount, MaxDeleteRatio and DeleteAfterRuns guard managed checks against mass deletion,
+	// in the same way as the Datadog provider.
+	MaxDeleteCount  int
+	MaxDeleteRatio  float64
+	DeleteAfterRuns int
+
+	// RunInterval is the interval of periodic runs, and runs are counted toward DeleteAfterRuns at most once per RunInterval.
+	RunInterval time.Duration
+
+	// ClusterName identifies the cluster that owns checks, when several clusters share the Checkly account.
+	ClusterName string
+
+	// missing holds the number of consecutive runs that the endpoint of the check is missing by ID.
+	missing synthetics.MissingRuns
+}
+
+// NewManager returns new instance of Manager with APIClient for the account.
+func NewManager(apiKey string, accountID string) (*Manager, error) {
+	client, err := NewClient(apiKey, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Manager{
+		Client: client,
+		Logger: zap.NewNop(),
+	}, nil
+}
+
+// String returns the name of the provider used in SYNTHETICS_PROVIDER.
+func String() string {
+	return providerName
+}
+
+// Reconcile defined by synthetics.SyntheticsProvider interface.
+// This implementation creates and updates checks of endpoints, then deletes orphaned managed checks.
+func (m *Manager) Reconcile(ctx context.Context, endpoints synthetics.SyntheticEndpoints) (synthetics.Result, error) {
+	var result synthetics.Result
+	if m.DefaultTag == "" {
+		return result, fmt.Errorf("No default tag is set for checks, aborting reconciliation")
+	}
+
+	checks, err := m.getManagedChecks(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	byName := make(map[string]Check, len(checks))
+	for _, check := range checks {
+		byName[check.Name] = check
+	}
+
+	var errs error
+	for name, endpoint := range endpoints {
+		// The remaining endpoints are left to the next reconciliation when ctx is done, e.g. on shutdown.
+		if ctx.Err() != nil {
+			break
+		}
+
+		desired := m.newManagedCheck(m.checkName(name), endpoint)
+		logger := m.Logger.With(zap.String("host", endpoint.Hostname), zap.Int("port", endpoint.Port))
+
+		actual, ok := byName[desired.Name]
+		if !ok {
+			logger.Info("Creating new check")
+			if _, err := m.Client.CreateCheck(ctx, desired); err != nil {
+				logger.Warn("Failed to create check", zap.Error(err))
+				result.Failed = append(result.Failed, desired.Name)
+				errs = multierr.Append(errs, fmt.Errorf("Failed to create check of %s: %w", hostport.Join(endpoint.Hostname, endpoint.Port), err))
+				continue
+			}
+			result.Created = append(result.Created, desired.Name)
+			continue
+		}
+
+		diffs := diffCheck(*desired, actual)
+		if len(diffs) == 0 {
+			result.Skipped = append(result.Skipped, desired.Name)
+			continue
+		}
+		logger.Info("Updating drifted check", zap.String("id", actual.ID), zap.Strings("fields", diffs))
+		if _, err := m.Client.UpdateCheck(ctx, actual.ID, desired); err != nil {
+			logger.Warn("Failed to update check", zap.String("id", actual.ID), zap.Error(err))
+			result.Failed = append(result.Failed, desired.Name)
+			errs = multierr.Append(errs, fmt.Errorf("Failed to update check %s of %s: %w", actual.ID, hostport.Join(endpoint.Hostname, endpoint.Port), err))
+			continue
+		}
+		result.Updated = append(result.Updated, desired.Name)
+	}
+
+	// Deletion is skipped when ctx is done during the creation, since it is the destructive part.
+	if err := ctx.Err(); err != nil {
+		return result, multierr.Append(errs, err)
+	}
+
+	deleted, err := m.deleteOrphanedChecks(ctx, checks, endpoints)
+	return result.Merge(deleted), multierr.Append(errs, err)
+}
+
+// getManagedChecks returns checks tagged by DefaultTag and owned by the cluster.
+func (m *Manager) getManagedChecks(ctx context.Context) ([]Check, error) {
+	checks, err := m.Client.GetChecks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var managed []Check
+	for _, check := range checks {
+		if synthetics.Contains(check.Tags, m.DefaultTag) && clusterOf(check) == m.ClusterName {
+			managed = append(managed, check)
+		}
+	}
+	return managed, nil
+}
+
+// deleteOrphanedChecks deletes managed checks whose endpoints are missing for DeleteAfterRuns consecutive runs, counted at most once per RunInterval.
+// If the deletion exceeds MaxDeleteCount or MaxDeleteRatio, no check is deleted and synthetics.MassDeletionError is returned.
+// Failures of checks are aggregated into the returned error, and the others are still deleted.
+func (m *Manager) deleteOrphanedChecks(ctx context.Context, checks []Check, endpoints synthetics.SyntheticEndpoints) (synthetics.Result, error) {
+	var result synthetics.Result
+
+	names := make(map[string]bool, len(endpoints))
+	for name := range endpoints {
+		names[m.checkName(name)] = true
+	}
+
+	var missingIDs []string
+	for _, check := range checks {
+		if !names[check.Name] {
+			missingIDs = append(missingIDs, check.ID)
+		}
+	}
+	missing := m.missing.Count(missingIDs, m.RunInterval)
+
+	var toDelete []Check
+	for _, check := range checks {
+		if names[check.Name] {
+			continue
+		}
+		if missing[check.ID] < m.DeleteAfterRuns {
+			m.Logger.Info("Managed check has no endpoint, waiting before deletion", zap.String("id", check.ID), zap.String("name", check.Name), zap.Int("runs", missing[check.ID]))
+			continue
+		}
+		toDelete = append(toDelete, check)
+	}
+
+	if err := synthetics.CheckMassDeletion(len(toDelete), len(checks), m.MaxDeleteCount, m.MaxDeleteRatio); err != nil {
+		return result, err
+	}
+
+	var errs error
+	for _, check := range toDelete {
+		m.Logger.Info("Deleting managed check without endpoint", zap.String("id", check.ID), zap.String("name", check.Name))
+		if err := m.Client.DeleteCheck(ctx, check.ID); err != nil {
+			m.Logger.Warn("Failed to delete check", zap.String("id", check.ID), zap.String("name", check.Name), zap.Error(err))
+			result.Failed = append(result.Failed, check.Name)
+			errs = multierr.Append(errs, fmt.Errorf("Failed to delete check %s: %w", check.ID, err))
+			continue
+		}
+		m.missing.Forget(check.ID)
+		result.Deleted = append(result.Deleted, check.Name)
+	}
+	return result, errs
+}
+
+// newManagedCheck returns the desired spec of the check of the endpoint.
+// AlertMessage and Mentions of the endpoint are ignored, since alerts of Checkly are sent to alert channels of the account.
+func (m *Manager) newManagedCheck(name string, endpoint synthetics.SyntheticEndpoint) *Check {
+	assertions := endpoint.Assertions.Merge(m.Assertions)
+
+	tags := append([]string{}, m.Tags...)
+	tags = append(tags, endpoint.Tags...)
+	tags = append(tags, m.DefaultTag)
+	if m.ClusterName != "" {
+		tags = append(tags, clusterTagPrefix+m.ClusterName)
+	}
+
+	interval := m.CheckInterval
+	if endpoint.CheckInterval > 0 {
+		interval = endpoint.CheckInterval
+	}
+	locations := m.Locations
+	if len(endpoint.Locations) > 0 {
+		locations = endpoint.Locations
+	}
+
+	return &Check{
+		Name:            name,
+		CheckType:       "API",
+		Activated:       true,
+		Frequency:       frequency(interval),
+		Locations:       locations,
+		Tags:            tags,
+		MaxResponseTime: int(assertions.MaxResponseTime / time.Millisecond),
+		SSLCheckDomain:  endpoint.Hostname,
+		Request: Request{
+			Method:  http.MethodGet,
+			URL:     "https://" + hostport.Join(endpoint.Hostname, endpoint.Port) + "/",
+			SkipSSL: assertions.AcceptSelfSigned != nil && *assertions.AcceptSelfSigned,
+			Assertions: []Assertion{
+				{Source: "STATUS_CODE", Comparison: "LESS_THAN", Target: "500"},
+			},
+		},
+		AlertSettings: AlertSettings{
+			SSLCertificates: SSLCertificates{Enabled: true, AlertThreshold: alertThreshold(assertions.CertificateDays)},
+		},
+	}
+}
+
+// checkName returns the name of the check of the endpoint named by GetNormalizedName.
+func (m *Manager) checkName(name string) string {
+	if m.ClusterName == "" {
+		return name
+	}
+	return m.ClusterName + "/" + name
+}
+
+// clusterOf returns the cluster that owns the check, or empty if the check has no cluster tag.
+func clusterOf(check Check) string {
+	for _, tag := range check.Tags {
+		if strings.HasPrefix(tag, clusterTagPrefix) {
+			return strings.TrimPrefix(tag, clusterTagPrefix)
+		}
+	}
+	return ""
+}
+
+// frequency returns the largest frequency supported by Checkly within interval seconds.
+func frequency(interval int) int {
+	f := frequencies[0]
+	for _, v := range frequencies {
+		if v*60 <= interval {
+			f = v
+		}
+	}
+	return f
+}
+
+// alertThreshold returns the smallest alert threshold supported by Checkly that covers days,
+// so that Checkly alerts no later than the assertion of days.
+func alertThreshold(days int) int {
+	for _, v := range alertThresholds {
+		if v >= days {
+			return v
+		}
+	}
+	return alertThresholds[len(alertThresholds)-1]
+}
+
+// diffCheck returns names of fields of actual that drifted from desired.
+func diffCheck(desired, actual Check) []string {
+	fields := []struct {
+		name            string
+		desired, actual interface{}
+	}{
+		{"frequency", desired.Frequency, actual.Frequency},
+		{"activated", desired.Activated, actual.Activated},
+		{"locations", sortedJoin(desired.Locations), sortedJoin(actual.Locations)},
+		{"tags", sortedJoin(desired.Tags), sortedJoin(actual.Tags)},
+		{"sslCheckDomain", desired.SSLCheckDomain, actual.SSLCheckDomain},
+		{"request.url", desired.Request.URL, actual.Request.URL},
+		{"request.skipSSL", desired.Request.SkipSSL, actual.Request.SkipSSL},
+		{"alertSettings.sslCertificates", desired.AlertSettings.SSLCertificates, actual.AlertSettings.SSLCertificates},
+	}
+
+	// Checkly sets its default to maxResponseTime when it is omitted.
+	if desired.MaxResponseTime > 0 {
+		fields = append(fields, struct {
+			name            string
+			desired, actual interface{}
+		}{"maxResponseTime", desired.MaxResponseTime, actual.MaxResponseTime})
+	}
+
+	var diffs []string
+	for _, f := range fields {
+		if f.desired != f.actual {
+			diffs = append(diffs, f.name)
+		}
+	}
+	return diffs
+}
+
+func sortedJoin(values []string) string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
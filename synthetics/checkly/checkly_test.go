@@ -0,0 +1,344 @@
+package checkly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics"
+)
+
+// fakeCheckly is a local stand-in of the Checkly API that keeps checks in memory.
+type fakeCheckly struct {
+	t *testing.T
+
+	mu      sync.Mutex
+	checks  map[string]Check
+	nextID  int
+	deleted []string
+
+	// failing are names of checks that fail to be created.
+	failing map[string]bool
+}
+
+func (f *fakeCheckly) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer api_key" || r.Header.Get("X-Checkly-Account") != "account_id" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"message":"Unauthorized"}`))
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/v1/checks/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/checks":
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+		ids := make([]string, 0, len(f.checks))
+		for id := range f.checks {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		checks := []Check{}
+		for i := (page - 1) * limit; i < len(ids) && i < page*limit; i++ {
+			checks = append(checks, f.checks[ids[i]])
+		}
+		json.NewEncoder(w).Encode(checks)
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/checks":
+		var check Check
+		if err := json.NewDecoder(r.Body).Decode(&check); err != nil {
+			f.t.Fatalf("Unexpected body: %s", err.Error())
+		}
+		if f.failing[check.Name] {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		f.nextID++
+		check.ID = "new-" + strconv.Itoa(f.nextID)
+		f.checks[check.ID] = check
+		json.NewEncoder(w).Encode(check)
+	case r.Method == http.MethodPut:
+		var check Check
+		if err := json.NewDecoder(r.Body).Decode(&check); err != nil {
+			f.t.Fatalf("Unexpected body: %s", err.Error())
+		}
+		check.ID = id
+		f.checks[id] = check
+		json.NewEncoder(w).Encode(check)
+	case r.Method == http.MethodDelete:
+		delete(f.checks, id)
+		f.deleted = append(f.deleted, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.t.Fatalf("Unexpected request: %s %s", r.Method, r.URL)
+	}
+}
+
+func makeTestManager(t *testing.T, checks ...Check) (*Manager, *fakeCheckly) {
+	t.Helper()
+
+	fake := &fakeCheckly{t: t, checks: make(map[string]Check)}
+	for _, check := range checks {
+		fake.checks[check.ID] = check
+	}
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	m, err := NewManager("api_key", "account_id")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	m.Client.(*APIClient).BaseURL = server.URL
+	m.Logger = zap.NewNop()
+	m.DefaultTag = "managed-by-cert-expiry-mon"
+	m.Locations = []string{"ap-northeast-1"}
+	m.CheckInterval = 900
+
+	return m, fake
+}
+
+func makeTestEndpoints(t *testing.T, endpoints ...string) synthetics.SyntheticEndpoints {
+	t.Helper()
+
+	se := make(synthetics.SyntheticEndpoints)
+	for _, e := range endpoints {
+		s, err := (synthetics.SyntheticEndpoint{}).FromString(e)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+		se.Add(s)
+	}
+	return se
+}
+
+func TestNewManager(t *testing.T) {
+	tests := []struct {
+		apiKey    string
+		accountID string
+		expected  bool
+	}{
+		{apiKey: "api_key", accountID: "account_id", expected: true},
+		{apiKey: "", accountID: "account_id", expected: false},
+		{apiKey: "api_key", accountID: "", expected: false},
+	}
+
+	for _, test := range tests {
+		if _, err := NewManager(test.apiKey, test.accountID); (err == nil) != test.expected {
+			t.Fatalf("Unexpected result of %+v: %v", test, err)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	m, fake := makeTestManager(t)
+
+	// The first reconciliation creates checks of endpoints.
+	endpoints := makeTestEndpoints(t, "a.example.com", "b.example.com:8443")
+	result, err := m.Reconcile(context.Background(), endpoints)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if len(result.Created) != 2 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	if len(fake.checks) != 2 {
+		t.Fatalf("Unexpected checks: %v", fake.checks)
+	}
+
+	var created Check
+	for _, check := range fake.checks {
+		if check.Name == "b.example.com-8443" {
+			created = check
+		}
+	}
+	if created.Request.URL != "https://b.example.com:8443/" || created.SSLCheckDomain != "b.example.com" {
+		t.Fatalf("Unexpected request of check: %+v", created)
+	}
+	if created.Frequency != 15 || created.AlertSettings.SSLCertificates.AlertThreshold != 14 || !created.Activated {
+		t.Fatalf("Unexpected settings of check: %+v", created)
+	}
+
+	// Drifted checks are updated, and checks of other owners are kept.
+	drifted := fake.checks[created.ID]
+	drifted.Frequency = 60
+	fake.checks[created.ID] = drifted
+	fake.checks["manual"] = Check{ID: "manual", Name: "manual"}
+	fake.checks["other"] = Check{ID: "other", Name: "prod/c.example.com-443", Tags: []string{"managed-by-cert-expiry-mon", "cluster:prod"}}
+
+	m.DeleteAfterRuns = 1
+	endpoints = makeTestEndpoints(t, "b.example.com:8443")
+	result, err = m.Reconcile(context.Background(), endpoints)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if len(result.Updated) != 1 || len(result.Deleted) != 1 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+
+	if got := fake.checks[created.ID].Frequency; got != 15 {
+		t.Fatalf("Unexpected frequency of updated check: %d", got)
+	}
+	if len(fake.deleted) != 1 || fake.deleted[0] == created.ID {
+		t.Fatalf("Unexpected deleted checks: %v", fake.deleted)
+	}
+	if _, ok := fake.checks["manual"]; !ok {
+		t.Fatal("Unexpected deletion of unmanaged check")
+	}
+	if _, ok := fake.checks["other"]; !ok {
+		t.Fatal("Unexpected deletion of check of other cluster")
+	}
+}
+
+func TestReconcilePagination(t *testing.T) {
+	var checks []Check
+	for i := 0; i < pageLimit+1; i++ {
+		name := "host" + strconv.Itoa(i) + ".example.com-443"
+		checks = append(checks, Check{ID: strconv.Itoa(1000 + i), Name: name, Tags: []string{"managed-by-cert-expiry-mon"}})
+	}
+	m, fake := makeTestManager(t, checks...)
+	m.DeleteAfterRuns = 1
+	m.MaxDeleteRatio = 0.5
+
+	_, err := m.Reconcile(context.Background(), makeTestEndpoints(t))
+	var massDeletion *synthetics.MassDeletionError
+	if !errors.As(err, &massDeletion) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if massDeletion.Managed != pageLimit+1 {
+		t.Fatalf("Unexpected managed checks: %d", massDeletion.Managed)
+	}
+	if len(fake.deleted) != 0 {
+		t.Fatalf("Unexpected deleted checks: %v", fake.deleted)
+	}
+}
+
+func TestReconcileMassDeletion(t *testing.T) {
+	tags := []string{"managed-by-cert-expiry-mon"}
+	m, fake := makeTestManager(t,
+		Check{ID: "1", Name: "a.example.com-443", Tags: tags},
+		Check{ID: "2", Name: "b.example.com-443", Tags: tags},
+		Check{ID: "3", Name: "c.example.com-443", Tags: tags},
+	)
+	m.MaxDeleteCount = 1
+	m.DeleteAfterRuns = 2
+
+	// Checks are kept until their endpoints are missing for DeleteAfterRuns runs.
+	if _, err := m.Reconcile(context.Background(), makeTestEndpoints(t, "a.example.com")); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if len(fake.deleted) != 0 {
+		t.Fatalf("Unexpected deleted checks: %v", fake.deleted)
+	}
+
+	_, err := m.Reconcile(context.Background(), makeTestEndpoints(t, "a.example.com"))
+	var massDeletion *synthetics.MassDeletionError
+	if !errors.As(err, &massDeletion) || massDeletion.Count != 2 {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(fake.deleted) != 0 {
+		t.Fatalf("Unexpected deleted checks: %v", fake.deleted)
+	}
+}
+
+func TestReconcilePartialFailure(t *testing.T) {
+	m, fake := makeTestManager(t)
+	fake.failing = map[string]bool{"a.example.com-443": true}
+
+	result, err := m.Reconcile(context.Background(), makeTestEndpoints(t, "a.example.com", "b.example.com"))
+	if err == nil || !strings.Contains(err.Error(), "a.example.com:443") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Failed) != 1 || result.Failed[0] != "a.example.com-443" || len(result.Created) != 1 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	if len(fake.checks) != 1 {
+		t.Fatalf("Unexpected checks: %v", fake.checks)
+	}
+}
+
+func TestClientError(t *testing.T) {
+	m, _ := makeTestManager(t)
+	m.Client.(*APIClient).APIKey = "invalid"
+
+	_, err := m.Reconcile(context.Background(), makeTestEndpoints(t, "a.example.com"))
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestNewManagedCheck(t *testing.T) {
+	accept := true
+	m := &Manager{
+		DefaultTag:    "managed-by-cert-expiry-mon",
+		ClusterName:   "prod",
+		CheckInterval: 604800,
+		Assertions:    synthetics.Assertions{CertificateDays: 40},
+	}
+
+	endpoint := synthetics.SyntheticEndpoint{
+		Hostname: "example.com",
+		Port:     443,
+		Settings: synthetics.Settings{
+			Assertions: synthetics.Assertions{MaxResponseTime: time.Second, AcceptSelfSigned: &accept},
+		},
+	}
+	check := m.newManagedCheck(m.checkName(endpoint.GetNormalizedName()), endpoint)
+
+	if check.Name != "prod/example.com-443" || !synthetics.Contains(check.Tags, "cluster:prod") {
+		t.Fatalf("Unexpected owner of check: %+v", check)
+	}
+	if check.Frequency != 1440 {
+		t.Fatalf("Unexpected frequency: %d", check.Frequency)
+	}
+	if check.AlertSettings.SSLCertificates.AlertThreshold != 30 {
+		t.Fatalf("Unexpected alert threshold: %d", check.AlertSettings.SSLCertificates.AlertThreshold)
+	}
+	if check.MaxResponseTime != 1000 || !check.Request.SkipSSL {
+		t.Fatalf("Unexpected assertions: %+v", check)
+	}
+}
+
+func TestNewManagedCheckSettings(t *testing.T) {
+	m := &Manager{
+		DefaultTag:    "managed-by-cert-expiry-mon",
+		Tags:          []string{"env:prod"},
+		Locations:     []string{"ap-northeast-1"},
+		CheckInterval: 900,
+	}
+
+	endpoint := synthetics.SyntheticEndpoint{
+		Hostname: "example.com",
+		Port:     443,
+		Settings: synthetics.Settings{
+			Locations:     []string{"us-east-1", "eu-west-1"},
+			CheckInterval: 300,
+			Tags:          []string{"team:sre"},
+		},
+	}
+	check := m.newManagedCheck(m.checkName(endpoint.GetNormalizedName()), endpoint)
+
+	if check.Frequency != 5 {
+		t.Fatalf("Unexpected frequency: %d", check.Frequency)
+	}
+	if sortedJoin(check.Locations) != "eu-west-1,us-east-1" {
+		t.Fatalf("Unexpected locations: %v", check.Locations)
+	}
+	if sortedJoin(check.Tags) != "env:prod,managed-by-cert-expiry-mon,team:sre" {
+		t.Fatalf("Unexpected tags: %v", check.Tags)
+	}
+}
@@ -0,0 +1,181 @@
+package checkly
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultBaseURL is the base URL of the Checkly public API.
+	DefaultBaseURL = "https://api.checklyhq.com"
+
+	// requestTimeout is the timeout of a request to Checkly.
+	requestTimeout = 30 * time.Second
+
+	// pageLimit is the number of checks fetched per request.
+	pageLimit = 100
+)
+
+// Client is an interface that clients implement to manage checks in Checkly.
+type Client interface {
+	GetChecks(ctx context.Context) ([]Check, error)
+	CreateCheck(ctx context.Context, check *Check) (*Check, error)
+	UpdateCheck(ctx context.Context, id string, check *Check) (*Check, error)
+	DeleteCheck(ctx context.Context, id string) error
+}
+
+// Check is an API check of Checkly.
+// Only fields managed by Manager are defined, and Checkly keeps the others as they are on update.
+type Check struct {
+	ID              string        `json:"id,omitempty"`
+	Name            string        `json:"name"`
+	CheckType       string        `json:"checkType"`
+	Activated       bool          `json:"activated"`
+	Frequency       int           `json:"frequency"`
+	Locations       []string      `json:"locations"`
+	Tags            []string      `json:"tags"`
+	MaxResponseTime int           `json:"maxResponseTime,omitempty"`
+	SSLCheckDomain  string        `json:"sslCheckDomain,omitempty"`
+	Request         Request       `json:"request"`
+	AlertSettings   AlertSettings `json:"alertSettings"`
+}
+
+// Request is the HTTP request of the check.
+type Request struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	SkipSSL    bool        `json:"skipSSL"`
+	Assertions []Assertion `json:"assertions"`
+}
+
+// Assertion is an assertion of the response of the check.
+type Assertion struct {
+	Source     string `json:"source"`
+	Comparison string `json:"comparison"`
+	Target     string `json:"target"`
+}
+
+// AlertSettings configures alerts of the check.
+type AlertSettings struct {
+	SSLCertificates SSLCertificates `json:"sslCertificates"`
+}
+
+// SSLCertificates alerts when the certificate of SSLCheckDomain expires within AlertThreshold days.
+type SSLCertificates struct {
+	Enabled        bool `json:"enabled"`
+	AlertThreshold int  `json:"alertThreshold"`
+}
+
+// APIClient implements Client with the Checkly public API.
+type APIClient struct {
+	BaseURL    string
+	APIKey     string
+	AccountID  string
+	HTTPClient *http.Client
+}
+
+// NewClient returns new instance of APIClient for the account.
+func NewClient(apiKey string, accountID string) (*APIClient, error) {
+	if apiKey == "" {
+		return nil, errors.New("checkly api key is required")
+	}
+	if accountID == "" {
+		return nil, errors.New("checkly account id is required")
+	}
+
+	return &APIClient{
+		BaseURL:    DefaultBaseURL,
+		APIKey:     apiKey,
+		AccountID:  accountID,
+		HTTPClient: &http.Client{Timeout: requestTimeout},
+	}, nil
+}
+
+// GetChecks returns all checks of the account, fetching pages until the last page.
+func (c *APIClient) GetChecks(ctx context.Context) ([]Check, error) {
+	var checks []Check
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("limit", strconv.Itoa(pageLimit))
+		query.Set("page", strconv.Itoa(page))
+
+		var resp []Check
+		if err := c.do(ctx, http.MethodGet, "/v1/checks?"+query.Encode(), nil, &resp); err != nil {
+			return nil, err
+		}
+		checks = append(checks, resp...)
+
+		if len(resp) < pageLimit {
+			return checks, nil
+		}
+	}
+}
+
+// CreateCheck creates the check.
+func (c *APIClient) CreateCheck(ctx context.Context, check *Check) (*Check, error) {
+	var created Check
+	if err := c.do(ctx, http.MethodPost, "/v1/checks", check, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateCheck updates the check of id.
+func (c *APIClient) UpdateCheck(ctx context.Context, id string, check *Check) (*Check, error) {
+	var updated Check
+	if err := c.do(ctx, http.MethodPut, "/v1/checks/"+url.PathEscape(id), check, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteCheck deletes the check of id.
+func (c *APIClient) DeleteCheck(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/v1/checks/"+url.PathEscape(id), nil, nil)
+}
+
+// do sends the request with body encoded as JSON, and decodes the response into out unless it is nil.
+// Checkly responds with JSON describing the reason when the request is rejected, so do includes it in the error.
+func (c *APIClient) do(ctx context.Context, method string, path string, body interface{}, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	req.Header.Set("X-Checkly-Account", c.AccountID)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Failed to request %s %s to checkly: %s", method, path, err.Error())
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Unexpected response of %s %s from checkly: %s: %s", method, path, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
@@ -0,0 +1,54 @@
+package checkly
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// DryRunClient is a Client that logs what would be created, updated and deleted instead of calling Checkly.
+// Checks are still read from Client, so that the diff against Ingresses is calculated as usual.
+type DryRunClient struct {
+	Client Client
+	Logger *zap.Logger
+}
+
+// NewDryRunClient returns new instance of DryRunClient that wraps client.
+func NewDryRunClient(logger *zap.Logger, client Client) *DryRunClient {
+	return &DryRunClient{
+		Client: client,
+		Logger: logger,
+	}
+}
+
+// GetChecks returns checks of Client.
+func (d *DryRunClient) GetChecks(ctx context.Context) ([]Check, error) {
+	return d.Client.GetChecks(ctx)
+}
+
+// CreateCheck logs the check and returns it without creating it.
+func (d *DryRunClient) CreateCheck(ctx context.Context, check *Check) (*Check, error) {
+	d.Logger.Info("DRY RUN: Create check",
+		zap.String("name", check.Name),
+		zap.String("url", check.Request.URL),
+		zap.Strings("tags", check.Tags),
+		zap.Strings("locations", check.Locations),
+		zap.Int("frequency", check.Frequency),
+	)
+	return check, nil
+}
+
+// UpdateCheck logs the check and returns it without updating it.
+func (d *DryRunClient) UpdateCheck(ctx context.Context, id string, check *Check) (*Check, error) {
+	d.Logger.Info("DRY RUN: Update check",
+		zap.String("id", id),
+		zap.String("name", check.Name),
+	)
+	return check, nil
+}
+
+// DeleteCheck logs id without deleting the check.
+func (d *DryRunClient) DeleteCheck(ctx context.Context, id string) error {
+	d.Logger.Info("DRY RUN: Delete check", zap.String("id", id))
+	return nil
+}
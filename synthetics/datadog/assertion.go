@@ -0,0 +1,28 @@
+package datadog
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics"
+)
+
+// syntheticsAssertions returns assertions of Datadog synthetics test.
+func syntheticsAssertions(a synthetics.Assertions) []datadogV1.SyntheticsAssertion {
+	certificate := datadogV1.NewSyntheticsAssertionTarget(datadogV1.SYNTHETICSASSERTIONOPERATOR_IS_IN_MORE_DAYS_THAN, a.CertificateDays, datadogV1.SYNTHETICSASSERTIONTYPE_CERTIFICATE)
+
+	assertions := []datadogV1.SyntheticsAssertion{datadogV1.SyntheticsAssertionTargetAsSyntheticsAssertion(certificate)}
+
+	if a.MinTLSVersion != "" {
+		tlsVersion := datadogV1.NewSyntheticsAssertionTarget(datadogV1.SYNTHETICSASSERTIONOPERATOR_MORE_THAN_OR_EQUAL, a.MinTLSVersion, datadogV1.SYNTHETICSASSERTIONTYPE_TLS_VERSION)
+		assertions = append(assertions, datadogV1.SyntheticsAssertionTargetAsSyntheticsAssertion(tlsVersion))
+	}
+
+	if a.MaxResponseTime > 0 {
+		responseTime := datadogV1.NewSyntheticsAssertionTarget(datadogV1.SYNTHETICSASSERTIONOPERATOR_LESS_THAN, int(a.MaxResponseTime/time.Millisecond), datadogV1.SYNTHETICSASSERTIONTYPE_RESPONSE_TIME)
+		assertions = append(assertions, datadogV1.SyntheticsAssertionTargetAsSyntheticsAssertion(responseTime))
+	}
+
+	return assertions
+}
@@ -0,0 +1,53 @@
+package datadog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics"
+)
+
+func TestNewManagedSyntheticsTestAssertions(t *testing.T) {
+	accept, reject := true, false
+	tm := &TestManager{
+		DefaultTag: "managed-by-cert-expiry-mon",
+		Assertions: synthetics.Assertions{CertificateDays: 14, MinTLSVersion: "1.2", AcceptSelfSigned: &reject},
+	}
+
+	tests := []struct {
+		endpoint                 synthetics.SyntheticEndpoint
+		expectedAssertions       string
+		expectedAcceptSelfSigned bool
+	}{
+		{
+			endpoint:                 synthetics.SyntheticEndpoint{Hostname: "example.com", Port: 443},
+			expectedAssertions:       "certificate isInMoreThan 14,tlsVersion moreThanOrEqual 1.2",
+			expectedAcceptSelfSigned: false,
+		},
+		{
+			endpoint: synthetics.SyntheticEndpoint{
+				Hostname: "example.com",
+				Port:     443,
+				Settings: synthetics.Settings{
+					Assertions: synthetics.Assertions{CertificateDays: 30, MaxResponseTime: time.Second, AcceptSelfSigned: &accept},
+				},
+			},
+			expectedAssertions:       "certificate isInMoreThan 30,responseTime lessThan 1000,tlsVersion moreThanOrEqual 1.2",
+			expectedAcceptSelfSigned: true,
+		},
+	}
+
+	for _, test := range tests {
+		got := tm.newManagedSyntheticsTest(test.endpoint.GetNormalizedName(), test.endpoint)
+
+		config := got.GetConfig()
+		if assertions := formatAssertions(config.Assertions); assertions != test.expectedAssertions {
+			t.Fatalf("Unexpected assertions: %s, expected %s", assertions, test.expectedAssertions)
+		}
+
+		options := got.GetOptions()
+		if options.GetAcceptSelfSigned() != test.expectedAcceptSelfSigned {
+			t.Fatalf("Unexpected acceptSelfSigned: %v, expected %v", options.GetAcceptSelfSigned(), test.expectedAcceptSelfSigned)
+		}
+	}
+}
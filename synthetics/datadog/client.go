@@ -0,0 +1,247 @@
+package datadog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	datadogapi "github.com/DataDog/datadog-api-client-go/v2/api/datadog"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics"
+)
+
+const (
+	// DefaultSite is the Datadog site used when DD_SITE is not set.
+	DefaultSite = "datadoghq.com"
+
+	// privateLocationPrefix is the prefix of IDs of private locations.
+	privateLocationPrefix = "pl:"
+
+	// defaultPageSize is the number of synthetics tests fetched per request.
+	defaultPageSize = 100
+
+	// maxPages is the maximum number of pages fetched by GetSyntheticsTests,
+	// so that listing stops even if the API keeps returning full pages.
+	maxPages = 1000
+
+	// The following values configure retries of requests failed by rate limits or server errors.
+	maxRetries   = 3
+	retryTimeout = 5 * time.Minute
+	httpTimeout  = time.Minute
+
+	// Rate limit headers returned by Datadog.
+	rateLimitRemainingHeader = "X-RateLimit-Remaining"
+	rateLimitResetHeader     = "X-RateLimit-Reset"
+)
+
+// APIClient implements Client with the official Datadog API client.
+type APIClient struct {
+	api *datadogV1.SyntheticsApi
+
+	// The following values are set to the context of each request for authentication and the server.
+	apiKeys         map[string]datadogapi.APIKey
+	serverIndex     int
+	serverVariables map[string]string
+
+	// PageSize is the number of synthetics tests fetched per request by GetSyntheticsTests.
+	PageSize int64
+}
+
+// NewClient returns new instance of APIClient for the Datadog site, e.g. `datadoghq.eu`.
+// Empty site means DefaultSite.
+func NewClient(apiKey string, appKey string, site string) (*APIClient, error) {
+	if site == "" {
+		site = DefaultSite
+	}
+	if err := ValidateSite(site); err != nil {
+		return nil, err
+	}
+	return newClient(apiKey, appKey, 0, map[string]string{"site": site}), nil
+}
+
+// newClient returns new instance of APIClient that sends requests to the server of serverIndex in datadogapi.Configuration.
+func newClient(apiKey string, appKey string, serverIndex int, serverVariables map[string]string) *APIClient {
+	config := datadogapi.NewConfiguration()
+	config.HTTPClient = &http.Client{
+		Timeout:   httpTimeout,
+		Transport: &rateLimitTransport{base: http.DefaultTransport},
+	}
+	// Requests rejected by rate limits are retried after X-RateLimit-Reset,
+	// and the other retriable requests are retried with exponential backoff.
+	config.RetryConfiguration.EnableRetry = true
+	config.RetryConfiguration.MaxRetries = maxRetries
+	config.RetryConfiguration.BackOffMultiplier = 2
+	config.RetryConfiguration.BackOffBase = 2
+	config.RetryConfiguration.HTTPRetryTimeout = retryTimeout
+
+	return &APIClient{
+		api: datadogV1.NewSyntheticsApi(datadogapi.NewAPIClient(config)),
+		apiKeys: map[string]datadogapi.APIKey{
+			"apiKeyAuth": {Key: apiKey},
+			"appKeyAuth": {Key: appKey},
+		},
+		serverIndex:     serverIndex,
+		serverVariables: serverVariables,
+		PageSize:        defaultPageSize,
+	}
+}
+
+// requestContext returns ctx with the API keys and the server of the client,
+// so that cancellation of ctx stops requests including their retries.
+func (c *APIClient) requestContext(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, datadogapi.ContextAPIKeys, c.apiKeys)
+	ctx = context.WithValue(ctx, datadogapi.ContextServerIndex, c.serverIndex)
+	return context.WithValue(ctx, datadogapi.ContextServerVariables, c.serverVariables)
+}
+
+// ValidateSite returns error if site is not a Datadog site supported by the API client.
+func ValidateSite(site string) error {
+	sites := datadogapi.NewConfiguration().Servers[0].Variables["site"].EnumValues
+	if !synthetics.Contains(sites, site) {
+		return fmt.Errorf("Datadog site must be one of %v: %s", sites, site)
+	}
+	return nil
+}
+
+// ValidatePrivateLocations returns error if any of locations is not an ID of private location, e.g. `pl:my-location-1234`.
+func ValidatePrivateLocations(locations []string) error {
+	for _, location := range locations {
+		if !strings.HasPrefix(location, privateLocationPrefix) || location == privateLocationPrefix {
+			return fmt.Errorf("private location must be prefixed by %s: %s", privateLocationPrefix, location)
+		}
+	}
+	return nil
+}
+
+// CreateSyntheticsTest creates the synthetics API test.
+func (c *APIClient) CreateSyntheticsTest(ctx context.Context, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
+	test, _, err := c.api.CreateSyntheticsAPITest(c.requestContext(ctx), *syntheticsTest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create synthetics test %s: %s", syntheticsTest.GetName(), err.Error())
+	}
+	return &test, nil
+}
+
+// GetSyntheticsTests returns all synthetics API tests, fetching pages of PageSize tests until the last page.
+// Browser tests are not returned since they are never managed.
+// GetSyntheticsTests fails if a full page contains no new tests, e.g. the API ignores the page number, or maxPages is exceeded.
+func (c *APIClient) GetSyntheticsTests(ctx context.Context) ([]datadogV1.SyntheticsAPITest, error) {
+	var tests []datadogV1.SyntheticsAPITest
+	seen := make(map[string]struct{})
+	for page := int64(0); page < maxPages; page++ {
+		params := datadogV1.NewListTestsOptionalParameters().WithPageSize(c.PageSize).WithPageNumber(page)
+		resp, _, err := c.api.ListTests(c.requestContext(ctx), *params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list synthetics tests: %s", err.Error())
+		}
+
+		added := 0
+		for _, details := range resp.Tests {
+			if _, ok := seen[details.GetPublicId()]; ok {
+				continue
+			}
+			seen[details.GetPublicId()] = struct{}{}
+			added++
+
+			if details.GetType() != datadogV1.SYNTHETICSTESTDETAILSTYPE_API {
+				continue
+			}
+			tests = append(tests, apiTestFromDetails(details))
+		}
+
+		if int64(len(resp.Tests)) < c.PageSize {
+			return tests, nil
+		}
+		if added == 0 {
+			return nil, fmt.Errorf("failed to list synthetics tests: page %d contains no new tests", page)
+		}
+	}
+
+	return nil, fmt.Errorf("failed to list synthetics tests: more than %d pages", maxPages)
+}
+
+// UpdateSyntheticsTest updates the synthetics API test of publicId.
+func (c *APIClient) UpdateSyntheticsTest(ctx context.Context, publicId string, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
+	test, _, err := c.api.UpdateAPITest(c.requestContext(ctx), publicId, *syntheticsTest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update synthetics test %s: %s", publicId, err.Error())
+	}
+	return &test, nil
+}
+
+// DeleteSyntheticsTests deletes synthetics tests of publicIds.
+func (c *APIClient) DeleteSyntheticsTests(ctx context.Context, publicIds []string) error {
+	payload := datadogV1.SyntheticsDeleteTestsPayload{PublicIds: publicIds}
+	if _, _, err := c.api.DeleteTests(c.requestContext(ctx), payload); err != nil {
+		return fmt.Errorf("failed to delete synthetics tests %v: %s", publicIds, err.Error())
+	}
+	return nil
+}
+
+// apiTestFromDetails converts the API test listed by ListTests to SyntheticsAPITest.
+func apiTestFromDetails(details datadogV1.SyntheticsTestDetails) datadogV1.SyntheticsAPITest {
+	config := details.GetConfig()
+	test := datadogV1.SyntheticsAPITest{
+		Config: datadogV1.SyntheticsAPITestConfig{
+			Assertions:      config.Assertions,
+			ConfigVariables: config.ConfigVariables,
+			Request:         config.Request,
+		},
+		Locations: details.Locations,
+		Message:   details.GetMessage(),
+		MonitorId: details.MonitorId,
+		Name:      details.GetName(),
+		Options:   details.GetOptions(),
+		PublicId:  details.PublicId,
+		Status:    details.Status,
+		Subtype:   details.Subtype,
+		Tags:      details.Tags,
+		Type:      datadogV1.SYNTHETICSAPITESTTYPE_API,
+	}
+	return test
+}
+
+// rateLimitTransport delays requests while the rate limit of Datadog is exhausted.
+// A response with X-RateLimit-Remaining of zero holds the following requests until X-RateLimit-Reset,
+// so that requests are not rejected by the rate limit in the first place.
+type rateLimitTransport struct {
+	base http.RoundTripper
+
+	mu       sync.Mutex
+	resumeAt time.Time
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	wait := time.Until(t.resumeAt)
+	t.mu.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.Header.Get(rateLimitRemainingHeader) == "0" {
+		if reset, err := strconv.Atoi(resp.Header.Get(rateLimitResetHeader)); err == nil && reset > 0 {
+			t.mu.Lock()
+			t.resumeAt = time.Now().Add(time.Duration(reset) * time.Second)
+			t.mu.Unlock()
+		}
+	}
+	return resp, nil
+}
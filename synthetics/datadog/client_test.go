@@ -0,0 +1,229 @@
+package datadog
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics"
+)
+
+func makeTestClient(t *testing.T, handler http.HandlerFunc) *APIClient {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return newClient("api_key", "app_key", 1, map[string]string{
+		"protocol": "http",
+		"name":     strings.TrimPrefix(server.URL, "http://"),
+	})
+}
+
+func makeTestDetails(t *testing.T, testType string, names ...string) []byte {
+	t.Helper()
+
+	tests := make([]map[string]interface{}, len(names))
+	for i, name := range names {
+		tests[i] = map[string]interface{}{
+			"name":      name,
+			"public_id": name,
+			"type":      testType,
+			"tags":      []string{"managed-by-cert-expiry-mon"},
+		}
+	}
+	body, err := json.Marshal(map[string]interface{}{"tests": tests})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	return body
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		site     string
+		expected bool
+	}{
+		{site: "", expected: true},
+		{site: "datadoghq.eu", expected: true},
+		{site: "us5.datadoghq.com", expected: true},
+		{site: "example.com", expected: false},
+	}
+
+	for _, test := range tests {
+		_, err := NewClient("api_key", "app_key", test.site)
+		if (err == nil) != test.expected {
+			t.Fatalf("Unexpected result of site %q: %v", test.site, err)
+		}
+	}
+}
+
+func TestClientGetSyntheticsTests(t *testing.T) {
+	var pages []string
+	client := makeTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("DD-API-KEY") != "api_key" || r.Header.Get("DD-APPLICATION-KEY") != "app_key" {
+			t.Fatalf("Unexpected keys: %v", r.Header)
+		}
+		if r.URL.Path != "/api/v1/synthetics/tests" {
+			t.Fatalf("Unexpected path: %s", r.URL.Path)
+		}
+
+		page := r.URL.Query().Get("page_number")
+		pages = append(pages, page)
+		switch page {
+		case "0":
+			w.Write(makeTestDetails(t, "api", "a", "b"))
+		case "1":
+			w.Write(makeTestDetails(t, "browser", "c", "d"))
+		case "2":
+			w.Write(makeTestDetails(t, "api", "e"))
+		default:
+			t.Fatalf("Unexpected page: %s", page)
+		}
+	})
+	client.PageSize = 2
+
+	tests, err := client.GetSyntheticsTests(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	if strings.Join(pages, ",") != "0,1,2" {
+		t.Fatalf("Unexpected pages: %v", pages)
+	}
+
+	var names []string
+	for _, test := range tests {
+		if test.GetType() != datadogV1.SYNTHETICSAPITESTTYPE_API || !synthetics.Contains(test.Tags, "managed-by-cert-expiry-mon") {
+			t.Fatalf("Unexpected test: %v", test)
+		}
+		names = append(names, test.GetName())
+	}
+	if strings.Join(names, ",") != "a,b,e" {
+		t.Fatalf("Unexpected tests: %v", names)
+	}
+}
+
+func TestClientGetSyntheticsTestsRepeatedPage(t *testing.T) {
+	requests := 0
+	client := makeTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(makeTestDetails(t, "api", "a", "b"))
+	})
+	client.PageSize = 2
+
+	if _, err := client.GetSyntheticsTests(context.Background()); err == nil {
+		t.Fatalf("Unexpected success of listing repeated pages")
+	}
+	if requests != 2 {
+		t.Fatalf("Unexpected requests: %d", requests)
+	}
+}
+
+func TestClientRetryRateLimit(t *testing.T) {
+	var requests []time.Time
+	client := makeTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, time.Now())
+		if len(requests) == 1 {
+			w.Header().Set("X-RateLimit-Reset", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		var payload datadogV1.SyntheticsDeleteTestsPayload
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Fatalf("Unexpected body: %s", body)
+		}
+		if strings.Join(payload.PublicIds, ",") != "aaa-aaa-aaa" {
+			t.Fatalf("Unexpected public IDs: %v", payload.PublicIds)
+		}
+		w.Write([]byte(`{}`))
+	})
+
+	if err := client.DeleteSyntheticsTests(context.Background(), []string{"aaa-aaa-aaa"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("Unexpected count of requests: %d", len(requests))
+	}
+	if wait := requests[1].Sub(requests[0]); wait < time.Second {
+		t.Fatalf("Unexpected retry before X-RateLimit-Reset: %s", wait)
+	}
+}
+
+func TestClientWaitRateLimitReset(t *testing.T) {
+	var requests []time.Time
+	client := makeTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, time.Now())
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(2-len(requests)))
+		w.Header().Set("X-RateLimit-Reset", "1")
+
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+
+	test := datadogV1.NewSyntheticsAPITestWithDefaults()
+	test.SetName("example.com-443")
+	test.SetLocations([]string{"aws:ap-northeast-1"})
+	for i := 0; i < 3; i++ {
+		if _, err := client.UpdateSyntheticsTest(context.Background(), "aaa-aaa-aaa", test); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+	}
+
+	if len(requests) != 3 {
+		t.Fatalf("Unexpected count of requests: %d", len(requests))
+	}
+	if wait := requests[1].Sub(requests[0]); wait >= time.Second {
+		t.Fatalf("Unexpected wait with remaining requests: %s", wait)
+	}
+	if wait := requests[2].Sub(requests[1]); wait < time.Second {
+		t.Fatalf("Unexpected request before X-RateLimit-Reset: %s", wait)
+	}
+}
+
+func TestClientError(t *testing.T) {
+	client := makeTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors":["Forbidden"]}`))
+	})
+
+	test := datadogV1.NewSyntheticsAPITestWithDefaults()
+	test.SetName("example.com-443")
+	if _, err := client.CreateSyntheticsTest(context.Background(), test); err == nil || !strings.Contains(err.Error(), "example.com-443") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestClientCancel(t *testing.T) {
+	client := makeTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(rateLimitRemainingHeader, "0")
+		w.Header().Set(rateLimitResetHeader, "60")
+		w.Write([]byte(`{}`))
+	})
+
+	if err := client.DeleteSyntheticsTests(context.Background(), []string{"aaa-aaa-aaa"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	// The request waiting for X-RateLimit-Reset is cancelled by the context.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := client.DeleteSyntheticsTests(ctx, []string{"aaa-aaa-aaa"}); err == nil {
+		t.Fatal("Unexpected success of cancelled request")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Unexpected elapsed time: %s", elapsed)
+	}
+}
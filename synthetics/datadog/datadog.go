@@ -1,236 +1,368 @@
-package synthetics
+package datadog
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"github.com/zorkian/go-datadog-api"
-	"go.uber.org/zap"
-	"log"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/hostport"
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics"
 )
 
-// TestManager synchronize synthetics tests in Datadog with existing Kubernetes Ingress Endpoints
+const providerName = "datadog"
+
+// TestManager synchronize synthetics tests in Datadog with existing Kubernetes Ingress Endpoints.
+// TestManager implements synthetics.SyntheticsProvider interface.
 type TestManager struct {
-	Client              Client
-	Logger              *zap.Logger
-	AlertMessage        string
-	CheckInterval       int
-	DefaultTag          string
-	DefaultLocations    []string
-	Tags                []string
-	AdditionalEndpoints []string
-	Enabled             bool
+	Client           Client
+	Logger           *zap.Logger
+	AlertMessage     string
+	CheckInterval    int
+	DefaultTag       string
+	DefaultLocations []string
+	PrivateLocations []string
+	Tags             []string
+
+	// Assertions are the default assertions of synthetics tests.
+	// SyntheticEndpoint.Assertions override them per endpoint.
+	Assertions synthetics.Assertions
+
+	// MaxDeleteCount and MaxDeleteRatio limit managed tests deleted at once, see synthetics.MassDeletionError.
+	// Zero disables each limit.
+	MaxDeleteCount int
+	MaxDeleteRatio float64
+
+	// DeleteAfterRuns is the number of consecutive runs that an endpoint must be missing before its test is deleted.
+	// Zero or one deletes the test at the first run.
+	DeleteAfterRuns int
+
+	// RunInterval is the interval of periodic runs. Runs are counted toward DeleteAfterRuns at most once per RunInterval,
+	// so that runs triggered by changes of Ingresses do not shorten the grace period. Zero counts every run.
+	RunInterval time.Duration
+
+	// ClusterName identifies the cluster that owns synthetics tests, when several clusters share the Datadog account.
+	// It is added to names and tags of tests, and tests of other clusters are never updated nor deleted.
+	ClusterName string
+
+	// missing holds the number of consecutive runs that the endpoint of the test is missing by public ID.
+	missing synthetics.MissingRuns
 }
 
 // Client is an interface that clients implement to manage synthetic tests in Datadog.
 type Client interface {
-	CreateSyntheticsTest(syntheticsTest *datadog.SyntheticsTest) (*datadog.SyntheticsTest, error)
-	GetSyntheticsTests() ([]datadog.SyntheticsTest, error)
-	DeleteSyntheticsTests(publicIds []string) error
-}
-
-type SyntheticEndpoint struct {
-	Hostname string
-	Port     int
-}
-
-type SyntheticEndpoints map[string]SyntheticEndpoint
-
-func (s SyntheticEndpoint) GetNormalizedName() string {
-	return fmt.Sprintf("%s-%d", s.Hostname, s.Port)
-}
-
-func (s SyntheticEndpoint) FromHostPortStr(hostname string, port string) (SyntheticEndpoint, error) {
-	endpoint := fmt.Sprintf("%s:%s", hostname, port)
-	return s.FromString(endpoint)
+	CreateSyntheticsTest(ctx context.Context, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error)
+	GetSyntheticsTests(ctx context.Context) ([]datadogV1.SyntheticsAPITest, error)
+	UpdateSyntheticsTest(ctx context.Context, publicId string, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error)
+	DeleteSyntheticsTests(ctx context.Context, publicIds []string) error
 }
 
-func (s SyntheticEndpoint) FromString(input string) (SyntheticEndpoint, error) {
-	host := input
-	portStr := "443"
-
-	if strings.Contains(input, ":") {
-		split := strings.Split(input, ":")
-
-		if len(split) != 2 {
-			return s, errors.New("Invalid additional endpoint " + input)
-		}
-
-		host = split[0]
-		portStr = split[1]
-
-		if len(host) == 0 {
-			return s, errors.New("missing hostname")
-		}
-
-		if len(portStr) == 0 {
-			return s, errors.New("missing port")
-		}
-
-		if portStr == "0" {
-			return s, errors.New("invalid port")
-		}
-	}
-
-	port, err := strconv.Atoi(portStr)
-	if err != nil {
-		return s, errors.New("The port number is not a valid numeral: " + portStr)
-	}
-
-	s.Hostname = host
-	s.Port = port
-
-	return s, nil
-}
-
-func (se SyntheticEndpoints) Add(s SyntheticEndpoint) {
-	se[s.GetNormalizedName()] = s
-}
-
-// NewTestManager creates a new datadog.Client
-func NewTestManager(apiKey string, appKey string) (*TestManager, error) {
+// NewTestManager creates a new TestManager with APIClient for the Datadog site
+func NewTestManager(apiKey string, appKey string, site string) (*TestManager, error) {
 	if apiKey == "" {
 		return &TestManager{}, errors.New("datadog api key is required")
 	}
 	if appKey == "" {
 		return &TestManager{}, errors.New("datadog application key is required")
 	}
+	client, err := NewClient(apiKey, appKey, site)
+	if err != nil {
+		return &TestManager{}, err
+	}
 	return &TestManager{
-		Client: datadog.NewClient(apiKey, appKey),
+		Client: client,
+		Logger: zap.NewNop(),
 	}, nil
 }
 
-// Contains return whether a slice contains a specific value
-func Contains(slice []string, val string) bool {
-	for _, n := range slice {
-		if val == n {
-			return true
-		}
-	}
-	return false
+// String returns the name of the provider used in SYNTHETICS_PROVIDER.
+func String() string {
+	return providerName
 }
 
-// getManagedSyntheticsTests returns all synthetics tests matching the default tag
-func (tm *TestManager) getManagedSyntheticsTests() ([]datadog.SyntheticsTest, error) {
+// clusterTagPrefix is the prefix of the tag that identifies the cluster owning the test.
+const clusterTagPrefix = synthetics.ClusterTagPrefix
+
+// getManagedSyntheticsTests returns synthetics tests matching the default tag and owned by the cluster.
+// When ClusterName is set, it also returns tests created before ClusterName was set, i.e. tests without cluster tag,
+// so that the cluster can adopt tests of its endpoints.
+func (tm *TestManager) getManagedSyntheticsTests(ctx context.Context) (owned []datadogV1.SyntheticsAPITest, adoptable []datadogV1.SyntheticsAPITest, err error) {
 	// Return error if default tag is not set
 	if tm.DefaultTag == "" {
 		err := fmt.Errorf("No default tag is set for synthetics tests, aborting creation process")
-		return nil, err
+		return nil, nil, err
 	}
-	var managedTests []datadog.SyntheticsTest
 	// Get all existing synthetic tests
-	tests, err := tm.Client.GetSyntheticsTests()
+	tests, err := tm.Client.GetSyntheticsTests(ctx)
 	if err != nil {
-		log.Printf("Failed to get synthetics tests from Datadog: %s\n", err.Error())
-		return nil, err
+		return nil, nil, fmt.Errorf("Failed to get synthetics tests from Datadog: %w", err)
 	}
 	for _, test := range tests {
 		// Only deal with tests having auto-generated tag
-		if Contains(test.Tags, tm.DefaultTag) {
-			if _, exists := test.GetNameOk(); exists {
-				managedTests = append(managedTests, test)
-			}
+		if !synthetics.Contains(test.Tags, tm.DefaultTag) {
+			continue
+		}
+		if test.GetName() == "" {
+			continue
+		}
+
+		switch cluster := clusterOf(test); {
+		case cluster == tm.ClusterName:
+			owned = append(owned, test)
+		case cluster == "":
+			adoptable = append(adoptable, test)
 		}
 	}
 
-	return managedTests, nil
+	return owned, adoptable, nil
 }
 
-// createManagedSyntheticsTest configures and create a new synthetics test in Datadog
-func (tm *TestManager) createManagedSyntheticsTest(name string, endpoint SyntheticEndpoint) (*datadog.SyntheticsTest, error) {
-	newOptions := &datadog.SyntheticsOptions{}
-	newOptions.SetAcceptSelfSigned(false)
-	newOptions.SetTickEvery(tm.CheckInterval)
+// clusterOf returns the cluster that owns the test, or empty if the test has no cluster tag.
+func clusterOf(test datadogV1.SyntheticsAPITest) string {
+	for _, tag := range test.Tags {
+		if strings.HasPrefix(tag, clusterTagPrefix) {
+			return strings.TrimPrefix(tag, clusterTagPrefix)
+		}
+	}
+	return ""
+}
 
-	expiryAssertion := &datadog.SyntheticsAssertion{}
-	expiryAssertion.SetType("certificate")
-	expiryAssertion.SetOperator("isInMoreThan")
-	expiryAssertion.Target = 12
+// testName returns the name of the synthetics test of the endpoint named by GetNormalizedName.
+// The name is prefixed by ClusterName, so that names do not collide across clusters.
+func (tm *TestManager) testName(name string) string {
+	if tm.ClusterName == "" {
+		return name
+	}
+	return tm.ClusterName + "/" + name
+}
+
+// newManagedSyntheticsTest returns the desired spec of the synthetics test of the endpoint
+func (tm *TestManager) newManagedSyntheticsTest(name string, endpoint synthetics.SyntheticEndpoint) *datadogV1.SyntheticsAPITest {
+	assertions := endpoint.Assertions.Merge(tm.Assertions)
 
-	newRequest := &datadog.SyntheticsRequest{}
-	newRequest.SetHost(endpoint.Hostname)
-	newRequest.SetPort(endpoint.Port)
+	options := datadogV1.SyntheticsTestOptions{}
+	options.SetAcceptSelfSigned(assertions.AcceptSelfSigned != nil && *assertions.AcceptSelfSigned)
+	interval := tm.CheckInterval
+	if endpoint.CheckInterval > 0 {
+		interval = endpoint.CheckInterval
+	}
+	options.SetTickEvery(int64(interval))
+
+	request := datadogV1.SyntheticsTestRequest{}
+	request.SetHost(endpoint.Hostname)
+	request.SetPort(strconv.Itoa(endpoint.Port))
 
-	newConfig := &datadog.SyntheticsConfig{}
-	newConfig.Assertions = []datadog.SyntheticsAssertion{*expiryAssertion}
-	newConfig.SetRequest(*newRequest)
+	config := datadogV1.SyntheticsAPITestConfig{}
+	config.Assertions = syntheticsAssertions(assertions)
+	config.SetRequest(request)
 
-	tags := tm.Tags
+	tags := append([]string{}, tm.Tags...)
+	tags = append(tags, endpoint.Tags...)
 	tags = append(tags, tm.DefaultTag)
+	if tm.ClusterName != "" {
+		tags = append(tags, clusterTagPrefix+tm.ClusterName)
+	}
 
-	newTest := &datadog.SyntheticsTest{Locations: tm.DefaultLocations, Tags: tags}
-	newTest.SetName(name)
-	newTest.SetType("api")
-	newTest.SetSubtype("ssl")
-	newTest.SetConfig(*newConfig)
+	locations := endpoint.Locations
+	if len(locations) == 0 {
+		locations = append([]string{}, tm.DefaultLocations...)
+		locations = append(locations, tm.PrivateLocations...)
+	}
+
+	newTest := datadogV1.NewSyntheticsAPITest(config, locations, endpoint.Message(tm.AlertMessage), name, options, datadogV1.SYNTHETICSAPITESTTYPE_API)
+	newTest.SetSubtype(datadogV1.SYNTHETICSTESTDETAILSSUBTYPE_SSL)
+	newTest.Tags = tags
 
-	newTest.SetMessage(tm.AlertMessage)
-	newTest.SetOptions(*newOptions)
+	return newTest
+}
 
-	test, err := tm.Client.CreateSyntheticsTest(newTest)
+// createManagedSyntheticsTest configures and create a new synthetics test in Datadog
+func (tm *TestManager) createManagedSyntheticsTest(ctx context.Context, name string, endpoint synthetics.SyntheticEndpoint) (*datadogV1.SyntheticsAPITest, error) {
+	test, err := tm.Client.CreateSyntheticsTest(ctx, tm.newManagedSyntheticsTest(name, endpoint))
 	if err != nil {
 		return nil, err
 	}
 	return test, nil
 }
 
-// CreateManagedSyntheticsTests creates synthetics test according to the endpointList provided
-func (tm *TestManager) CreateManagedSyntheticsTests(endpoints SyntheticEndpoints) error {
+// updateManagedSyntheticsTest updates the synthetics test in Datadog if it drifted from the desired spec.
+// It returns true if the test was updated.
+func (tm *TestManager) updateManagedSyntheticsTest(ctx context.Context, name string, test datadogV1.SyntheticsAPITest, endpoint synthetics.SyntheticEndpoint) (bool, error) {
+	desired := tm.newManagedSyntheticsTest(name, endpoint)
+	diffs := diffSyntheticsTest(*desired, test)
+	if len(diffs) == 0 {
+		return false, nil
+	}
+
+	for _, diff := range diffs {
+		tm.Logger.Info("Synthetics test drifted",
+			zap.String("id", test.GetPublicId()),
+			zap.String("host", endpoint.Hostname),
+			zap.Int("port", endpoint.Port),
+			zap.String("field", diff.Field),
+			zap.String("actual", diff.Actual),
+			zap.String("desired", diff.Desired),
+		)
+	}
+	if _, err := tm.Client.UpdateSyntheticsTest(ctx, test.GetPublicId(), desired); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Reconcile defined by synthetics.SyntheticsProvider interface.
+// This implementation creates and updates tests of endpoints, then deletes orphaned managed tests.
+func (tm *TestManager) Reconcile(ctx context.Context, endpoints synthetics.SyntheticEndpoints) (synthetics.Result, error) {
+	result, errs := tm.CreateManagedSyntheticsTests(ctx, endpoints)
+	// Deletion is skipped when ctx is done during the creation, since it is the destructive part.
+	// The error of ctx is already aggregated by CreateManagedSyntheticsTests.
+	if ctx.Err() != nil {
+		return result, errs
+	}
+
+	deleted, err := tm.DeleteManagedSyntheticsTests(ctx, endpoints)
+	return result.Merge(deleted), multierr.Append(errs, err)
+}
+
+// CreateManagedSyntheticsTests creates synthetics test according to the endpointList provided,
+// and updates existing tests that drifted from the current configuration.
+// Failures of endpoints are aggregated into the returned error, and the others are still processed.
+// When ctx is done, the remaining endpoints are left to the next reconciliation and the error of ctx is returned.
+func (tm *TestManager) CreateManagedSyntheticsTests(ctx context.Context, endpoints synthetics.SyntheticEndpoints) (synthetics.Result, error) {
+	var result synthetics.Result
+
 	// Get all existing synthetic tests
-	tests, err := tm.getManagedSyntheticsTests()
+	tests, adoptable, err := tm.getManagedSyntheticsTests(ctx)
 	if err != nil {
-		log.Printf("Failed to get synthetics tests: %s\n", err.Error())
-		return err
+		return result, err
 	}
+
+	var errs error
 	for name, endpoint := range endpoints {
-		var matched bool
+		if ctx.Err() != nil {
+			break
+		}
+
+		var matched *datadogV1.SyntheticsAPITest
+		logger := tm.Logger.With(zap.String("host", endpoint.Hostname), zap.Int("port", endpoint.Port))
 
 		// Normalize endpoint names from SYNTHETIC_ADDITIONAL_ENDPOINTS as they might have a defined port
-		for _, test := range tests {
-			if name == test.GetName() {
-				matched = true
+		for i := range tests {
+			if tm.testName(name) == tests[i].GetName() {
+				matched = &tests[i]
 			}
 		}
-		if matched {
-			log.Printf("Test is already existing for %s:%d and Ingress exists", endpoint.Hostname, endpoint.Port)
-		} else {
-			log.Printf("Creating new test for Ingress endpoint %s:%d", endpoint.Hostname, endpoint.Port)
-			_, err := tm.createManagedSyntheticsTest(name, endpoint)
-			if err != nil {
-				log.Printf("Couldn't create the synthetic test for Ingress endpoint %s:%d: %s\n", endpoint.Hostname, endpoint.Port, err.Error())
+		// Tests created before ClusterName was set are named without the cluster, and updated with the name and tag of the cluster.
+		if matched == nil && tm.ClusterName != "" {
+			for i := range adoptable {
+				if name == adoptable[i].GetName() {
+					logger.Info("Adopting synthetics test without cluster tag", zap.String("id", adoptable[i].GetPublicId()))
+					matched = &adoptable[i]
+				}
 			}
 		}
+
+		if matched == nil {
+			logger.Info("Creating synthetics test")
+			if _, err := tm.createManagedSyntheticsTest(ctx, tm.testName(name), endpoint); err != nil {
+				logger.Warn("Failed to create synthetics test", zap.Error(err))
+				result.Failed = append(result.Failed, tm.testName(name))
+				errs = multierr.Append(errs, fmt.Errorf("Failed to create synthetics test of %s: %w", hostport.Join(endpoint.Hostname, endpoint.Port), err))
+				continue
+			}
+			result.Created = append(result.Created, tm.testName(name))
+			continue
+		}
+
+		logger.Debug("Synthetics test already exists", zap.String("id", matched.GetPublicId()))
+		updated, err := tm.updateManagedSyntheticsTest(ctx, tm.testName(name), *matched, endpoint)
+		switch {
+		case err != nil:
+			logger.Warn("Failed to update synthetics test", zap.String("id", matched.GetPublicId()), zap.Error(err))
+			result.Failed = append(result.Failed, tm.testName(name))
+			errs = multierr.Append(errs, fmt.Errorf("Failed to update synthetics test %s of %s: %w", matched.GetPublicId(), hostport.Join(endpoint.Hostname, endpoint.Port), err))
+		case updated:
+			logger.Info("Updated drifted synthetics test", zap.String("id", matched.GetPublicId()))
+			result.Updated = append(result.Updated, tm.testName(name))
+		default:
+			result.Skipped = append(result.Skipped, tm.testName(name))
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		errs = multierr.Append(errs, err)
 	}
-	return nil
+	return result, errs
 }
 
-// DeleteManagedSyntheticsTests removes managed synthetics test not matching the endpointList provided
-func (tm *TestManager) DeleteManagedSyntheticsTests(endpoints SyntheticEndpoints) error {
+// DeleteManagedSyntheticsTests removes managed synthetics test not matching the endpointList provided.
+// Tests are deleted after their endpoints are missing for DeleteAfterRuns consecutive runs, counted at most once per RunInterval.
+// If the deletion exceeds MaxDeleteCount or MaxDeleteRatio, no test is deleted and synthetics.MassDeletionError is returned.
+func (tm *TestManager) DeleteManagedSyntheticsTests(ctx context.Context, endpoints synthetics.SyntheticEndpoints) (synthetics.Result, error) {
+	var result synthetics.Result
+
 	// Get all existing synthetic tests
-	tests, err := tm.getManagedSyntheticsTests()
+	tests, _, err := tm.getManagedSyntheticsTests(ctx)
 	if err != nil {
-		log.Printf("Failed to get synthetics tests: %s\n", err.Error())
-		return err
+		return result, err
+	}
+
+	names := make(map[string]bool, len(endpoints))
+	for name := range endpoints {
+		names[tm.testName(name)] = true
 	}
-	// Slice containing all tests publicIds to delete
-	var toDelete []string
+
+	var missingIds []string
 	for _, test := range tests {
-		if _, ok := endpoints[test.GetName()]; !ok {
-			log.Printf("warn: Managed test %s, with hostname %s, doesn't have any matching ingress, adding to delete list", test.GetPublicId(), test.GetName())
-			toDelete = append(toDelete, test.GetPublicId())
+		if !names[test.GetName()] {
+			missingIds = append(missingIds, test.GetPublicId())
 		}
 	}
-	// Delete only when there are candidates to deletion
-	if toDelete != nil && len(toDelete) >= 1 {
-		log.Printf("Deleting %d managed tests", len(toDelete))
-		err := tm.Client.DeleteSyntheticsTests(toDelete)
-		if err != nil {
-			log.Printf("Failed to delete managed tests: %s\n", err.Error())
-			return err
+	missing := tm.missing.Count(missingIds, tm.RunInterval)
+
+	// Slices containing publicIds and names of all tests to delete
+	var toDelete, toDeleteNames []string
+	for _, test := range tests {
+		if names[test.GetName()] {
+			continue
+		}
+
+		logger := tm.Logger.With(zap.String("id", test.GetPublicId()), zap.String("name", test.GetName()))
+		if missing[test.GetPublicId()] < tm.DeleteAfterRuns {
+			logger.Info("Managed synthetics test has no matching Ingress, waiting before deletion",
+				zap.Int("runs", missing[test.GetPublicId()]),
+				zap.Int("deleteAfterRuns", tm.DeleteAfterRuns),
+			)
+			continue
 		}
-	} else {
-		log.Printf("No test candidate for deletion")
+		logger.Info("Managed synthetics test has no matching Ingress, adding to delete list")
+		toDelete = append(toDelete, test.GetPublicId())
+		toDeleteNames = append(toDeleteNames, test.GetName())
+	}
+
+	if err := synthetics.CheckMassDeletion(len(toDelete), len(tests), tm.MaxDeleteCount, tm.MaxDeleteRatio); err != nil {
+		return result, err
+	}
+
+	// Delete only when there are candidates to deletion
+	if len(toDelete) == 0 {
+		tm.Logger.Debug("No synthetics test candidate for deletion")
+		return result, nil
+	}
+
+	tm.Logger.Info("Deleting managed synthetics tests", zap.Int("count", len(toDelete)))
+	if err := tm.Client.DeleteSyntheticsTests(ctx, toDelete); err != nil {
+		result.Failed = toDeleteNames
+		return result, fmt.Errorf("Failed to delete %d managed synthetics tests: %w", len(toDelete), err)
+	}
+	for _, id := range toDelete {
+		tm.missing.Forget(id)
 	}
-	return nil
+	result.Deleted = toDeleteNames
+	return result, nil
 }
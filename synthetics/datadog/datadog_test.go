@@ -1,35 +1,42 @@
-package synthetics
+package datadog
 
 import (
-	"bytes"
-	"log"
-	"os"
-	"reflect"
+	"context"
+	"errors"
 	"strings"
 	"testing"
 
-	"github.com/zorkian/go-datadog-api"
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics"
 )
 
 type fakeClient struct {
 	t *testing.T
 
-	validateCreateSyntheticsTestFunc  func(t *testing.T, syntheticsTest *datadog.SyntheticsTest) (*datadog.SyntheticsTest, error)
-	validateGetSyntheticsTestsFunc    func(t *testing.T) []datadog.SyntheticsTest
+	validateCreateSyntheticsTestFunc  func(t *testing.T, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error)
+	validateGetSyntheticsTestsFunc    func(t *testing.T) []datadogV1.SyntheticsAPITest
+	validateUpdateSyntheticsTestFunc  func(t *testing.T, publicId string, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error)
 	validateDeleteSyntheticsTestsFunc func(t *testing.T, publicIds []string) error
 }
 
-func (f *fakeClient) CreateSyntheticsTest(syntheticsTest *datadog.SyntheticsTest) (*datadog.SyntheticsTest, error) {
-	test, _ := f.validateCreateSyntheticsTestFunc(f.t, syntheticsTest)
-	return test, nil
+func (f *fakeClient) CreateSyntheticsTest(ctx context.Context, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
+	return f.validateCreateSyntheticsTestFunc(f.t, syntheticsTest)
 }
 
-func (f *fakeClient) GetSyntheticsTests() ([]datadog.SyntheticsTest, error) {
+func (f *fakeClient) GetSyntheticsTests(ctx context.Context) ([]datadogV1.SyntheticsAPITest, error) {
 	tests := f.validateGetSyntheticsTestsFunc(f.t)
 	return tests, nil
 }
 
-func (f *fakeClient) DeleteSyntheticsTests(publicIds []string) error {
+func (f *fakeClient) UpdateSyntheticsTest(ctx context.Context, publicId string, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
+	return f.validateUpdateSyntheticsTestFunc(f.t, publicId, syntheticsTest)
+}
+
+func (f *fakeClient) DeleteSyntheticsTests(ctx context.Context, publicIds []string) error {
 	error := f.validateDeleteSyntheticsTestsFunc(f.t, publicIds)
 	return error
 }
@@ -37,35 +44,35 @@ func (f *fakeClient) DeleteSyntheticsTests(publicIds []string) error {
 func TestCreateSyntheticsTest(t *testing.T) {
 	client := &fakeClient{
 		t: t,
-		validateCreateSyntheticsTestFunc: func(t *testing.T, syntheticsTest *datadog.SyntheticsTest) (*datadog.SyntheticsTest, error) {
+		validateCreateSyntheticsTestFunc: func(t *testing.T, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
 			if len(syntheticsTest.GetConfig().Assertions) != 1 {
 				t.Fatalf("got %v, want %v", len(syntheticsTest.GetConfig().Assertions), 1)
 			}
 			if got, want := *syntheticsTest.GetOptions().AcceptSelfSigned, false; got != want {
 				t.Fatalf("got %v, want %v", got, want)
 			}
-			if got, want := *syntheticsTest.GetOptions().TickEvery, 60; got != want {
+			if got, want := *syntheticsTest.GetOptions().TickEvery, int64(60); got != want {
 				t.Fatalf("got %v, want %v", got, want)
 			}
-			if got, want := syntheticsTest.GetConfig().Assertions[0].GetType(), "certificate"; got != want {
+			if got, want := syntheticsTest.GetConfig().Assertions[0].SyntheticsAssertionTarget.Type, datadogV1.SYNTHETICSASSERTIONTYPE_CERTIFICATE; got != want {
 				t.Fatalf("got %v, want %v", got, want)
 			}
-			if got, want := syntheticsTest.GetConfig().Assertions[0].GetOperator(), "isInMoreThan"; got != want {
+			if got, want := syntheticsTest.GetConfig().Assertions[0].SyntheticsAssertionTarget.Operator, datadogV1.SYNTHETICSASSERTIONOPERATOR_IS_IN_MORE_DAYS_THAN; got != want {
 				t.Fatalf("got %v, want %v", got, want)
 			}
-			if got, want := syntheticsTest.GetConfig().Assertions[0].Target, 12; got != want {
+			if got, want := syntheticsTest.GetConfig().Assertions[0].SyntheticsAssertionTarget.Target, interface{}(12); got != want {
 				t.Fatalf("got %v, want %v", got, want)
 			}
-			if got, want := syntheticsTest.GetConfig().Request.GetPort(), 443; got != want {
+			if got, want := syntheticsTest.GetConfig().Request.GetPort(), "443"; got != want {
 				t.Fatalf("got %v, want %v", got, want)
 			}
 			if got, want := syntheticsTest.GetConfig().Request.GetHost(), "example.com"; got != want {
 				t.Fatalf("got %v, want %v", got, want)
 			}
-			if got, want := syntheticsTest.GetType(), "api"; got != want {
+			if got, want := syntheticsTest.GetType(), datadogV1.SYNTHETICSAPITESTTYPE_API; got != want {
 				t.Fatalf("got %v, want %v", got, want)
 			}
-			if got, want := syntheticsTest.GetSubtype(), "ssl"; got != want {
+			if got, want := syntheticsTest.GetSubtype(), datadogV1.SYNTHETICSTESTDETAILSSUBTYPE_SSL; got != want {
 				t.Fatalf("got %v, want %v", got, want)
 			}
 			if len(syntheticsTest.Locations) != 1 {
@@ -86,7 +93,7 @@ func TestCreateSyntheticsTest(t *testing.T) {
 
 	apiKey := "api_key"
 	appKey := "app_key"
-	tm, err := NewTestManager(apiKey, appKey)
+	tm, err := NewTestManager(apiKey, appKey, "")
 	if err != nil {
 		t.Fatalf("want nil, got %s", err)
 	}
@@ -96,33 +103,33 @@ func TestCreateSyntheticsTest(t *testing.T) {
 	tm.DefaultTag = "managed-by-cert-exp-mon"
 	tm.DefaultLocations = []string{"aws:ap-northeast-1"}
 	name := "example.com-443"
-	endpoint := SyntheticEndpoint{
+	endpoint := synthetics.SyntheticEndpoint{
 		Hostname: "example.com",
 		Port:     443,
 	}
-	tm.createManagedSyntheticsTest(name, endpoint)
+	tm.createManagedSyntheticsTest(context.Background(), name, endpoint)
 }
 
 func TestGetSyntheticsTests(t *testing.T) {
 	client := &fakeClient{
 		t: t,
-		validateGetSyntheticsTestsFunc: func(t *testing.T) []datadog.SyntheticsTest {
-			test := new(datadog.SyntheticsTest)
-			test2 := new(datadog.SyntheticsTest)
-			tests := &[]datadog.SyntheticsTest{*test, *test2}
+		validateGetSyntheticsTestsFunc: func(t *testing.T) []datadogV1.SyntheticsAPITest {
+			test := new(datadogV1.SyntheticsAPITest)
+			test2 := new(datadogV1.SyntheticsAPITest)
+			tests := &[]datadogV1.SyntheticsAPITest{*test, *test2}
 			return *tests
 		},
 	}
 
 	apiKey := "api_key"
 	appKey := "app_key"
-	tm, err := NewTestManager(apiKey, appKey)
+	tm, err := NewTestManager(apiKey, appKey, "")
 	if err != nil {
 		t.Fatalf("want nil, got %s", err)
 	}
 	tm.Client = client
-	if got, _ := tm.Client.GetSyntheticsTests(); got == nil {
-		t.Fatal("want []datadog.SyntheticsTest, got nil")
+	if got, _ := tm.Client.GetSyntheticsTests(context.Background()); got == nil {
+		t.Fatal("want []datadogV1.SyntheticsAPITest, got nil")
 	}
 
 }
@@ -130,7 +137,7 @@ func TestGetSyntheticsTests(t *testing.T) {
 func TestNewTestManager(t *testing.T) {
 	apiKey := "api_key"
 	appKey := "app_key"
-	if got, _ := NewTestManager(apiKey, appKey); got == nil {
+	if got, _ := NewTestManager(apiKey, appKey, ""); got == nil {
 		t.Fatal("want *NewTestManager, got nil")
 	}
 }
@@ -138,77 +145,276 @@ func TestNewTestManager(t *testing.T) {
 func TestCreateManagedSyntheticsTests(t *testing.T) {
 	client := &fakeClient{
 		t: t,
-		validateGetSyntheticsTestsFunc: func(t *testing.T) []datadog.SyntheticsTest {
-			test := new(datadog.SyntheticsTest)
+		validateGetSyntheticsTestsFunc: func(t *testing.T) []datadogV1.SyntheticsAPITest {
+			test := new(datadogV1.SyntheticsAPITest)
 			test.SetName("example.com-443")
 			test.Tags = append(test.Tags, "managed-by-cert-expiry-mon")
-			test2 := new(datadog.SyntheticsTest)
+			test2 := new(datadogV1.SyntheticsAPITest)
 			test2.SetName("example2.com-443")
-			tests := &[]datadog.SyntheticsTest{*test, *test2}
+			tests := &[]datadogV1.SyntheticsAPITest{*test, *test2}
 			return *tests
 		},
-		validateCreateSyntheticsTestFunc: func(t *testing.T, syntheticsTest *datadog.SyntheticsTest) (*datadog.SyntheticsTest, error) {
+		validateCreateSyntheticsTestFunc: func(t *testing.T, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
+			return syntheticsTest, nil
+		},
+		validateUpdateSyntheticsTestFunc: func(t *testing.T, publicId string, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
 			return syntheticsTest, nil
 		},
 	}
 
 	apiKey := "api_key"
 	appKey := "app_key"
-	tm, err := NewTestManager(apiKey, appKey)
+	tm, err := NewTestManager(apiKey, appKey, "")
 	if err != nil {
 		t.Fatalf("want nil, got %s", err)
 	}
 	tm.Client = client
 	tm.DefaultTag = "managed-by-cert-expiry-mon"
 
-	if got, _ := tm.Client.GetSyntheticsTests(); got == nil {
-		t.Fatal("want []datadog.SyntheticsTest, got nil")
+	if got, _ := tm.Client.GetSyntheticsTests(context.Background()); got == nil {
+		t.Fatal("want []datadogV1.SyntheticsAPITest, got nil")
 	}
 
-	if got := captureOutput(func() {
-		endpoints := SyntheticEndpoints{
-			"example.com-443": SyntheticEndpoint{
-				Hostname: "example.com",
-				Port:     443,
+	endpoints := synthetics.SyntheticEndpoints{
+		"example.com-443": synthetics.SyntheticEndpoint{
+			Hostname: "example.com",
+			Port:     443,
+		},
+	}
+	result, err := tm.CreateManagedSyntheticsTests(context.Background(), endpoints)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if len(result.Created) != 0 || len(result.Updated)+len(result.Skipped) != 1 {
+		t.Fatalf("want the existing test of example.com-443, got %+v", result)
+	}
+
+	endpoints = synthetics.SyntheticEndpoints{
+		"nonexistinguri.com-443": synthetics.SyntheticEndpoint{
+			Hostname: "nonexistinguri.com",
+			Port:     443,
+		},
+	}
+	result, err = tm.CreateManagedSyntheticsTests(context.Background(), endpoints)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if len(result.Created) != 1 || result.Created[0] != "nonexistinguri.com-443" {
+		t.Fatalf("want a new test of nonexistinguri.com-443, got %+v", result)
+	}
+}
+
+func TestCreateManagedSyntheticsTestsPartialFailure(t *testing.T) {
+	client := &fakeClient{
+		t: t,
+		validateGetSyntheticsTestsFunc: func(t *testing.T) []datadogV1.SyntheticsAPITest {
+			return nil
+		},
+		validateCreateSyntheticsTestFunc: func(t *testing.T, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
+			if syntheticsTest.GetName() == "failing.example.com-443" {
+				return nil, errors.New("400 Bad Request")
+			}
+			return syntheticsTest, nil
+		},
+	}
+
+	core, recorded := observer.New(zapcore.InfoLevel)
+	tm := &TestManager{Client: client, Logger: zap.New(core), DefaultTag: "managed-by-cert-expiry-mon"}
+
+	endpoints := synthetics.SyntheticEndpoints{}
+	endpoints.Add(synthetics.SyntheticEndpoint{Hostname: "failing.example.com", Port: 443})
+	endpoints.Add(synthetics.SyntheticEndpoint{Hostname: "example.com", Port: 443})
+
+	result, err := tm.CreateManagedSyntheticsTests(context.Background(), endpoints)
+	if err == nil || !strings.Contains(err.Error(), "failing.example.com:443") {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.Failed) != 1 || result.Failed[0] != "failing.example.com-443" {
+		t.Fatalf("Unexpected failed tests: %v", result.Failed)
+	}
+	if len(result.Created) != 1 || result.Created[0] != "example.com-443" {
+		t.Fatalf("Unexpected created tests: %v", result.Created)
+	}
+
+	entries := recorded.FilterMessage("Failed to create synthetics test").All()
+	if len(entries) != 1 || entries[0].ContextMap()["host"] != "failing.example.com" {
+		t.Fatalf("Unexpected logs: %v", entries)
+	}
+}
+
+func TestReconcileCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var created int
+	client := &fakeClient{
+		t: t,
+		validateGetSyntheticsTestsFunc: func(t *testing.T) []datadogV1.SyntheticsAPITest {
+			return nil
+		},
+		validateCreateSyntheticsTestFunc: func(t *testing.T, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
+			// The controller stops during the first creation.
+			created++
+			cancel()
+			return syntheticsTest, nil
+		},
+		validateDeleteSyntheticsTestsFunc: func(t *testing.T, publicIds []string) error {
+			t.Fatalf("Unexpected deletion after cancellation: %v", publicIds)
+			return nil
+		},
+	}
+	tm := &TestManager{Client: client, Logger: zap.NewNop(), DefaultTag: "managed-by-cert-expiry-mon"}
+
+	endpoints := synthetics.SyntheticEndpoints{}
+	endpoints.Add(synthetics.SyntheticEndpoint{Hostname: "a.example.com", Port: 443})
+	endpoints.Add(synthetics.SyntheticEndpoint{Hostname: "b.example.com", Port: 443})
+
+	result, err := tm.Reconcile(ctx, endpoints)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if created != 1 || len(result.Created) != 1 {
+		t.Fatalf("Unexpected creation after cancellation: %d, %v", created, result.Created)
+	}
+}
+
+func TestUpdateDriftedSyntheticsTests(t *testing.T) {
+	endpoint := synthetics.SyntheticEndpoint{Hostname: "example.com", Port: 443}
+
+	tm := &TestManager{
+		Logger:           zap.NewNop(),
+		DefaultTag:       "managed-by-cert-expiry-mon",
+		Tags:             []string{"team:sre"},
+		DefaultLocations: []string{"aws:ap-northeast-1"},
+		CheckInterval:    300,
+		AlertMessage:     "@sre",
+	}
+
+	type TestCase struct {
+		name            string
+		modify          func(test *datadogV1.SyntheticsAPITest)
+		expectedUpdated bool
+		expectedDiff    FieldDiff
+	}
+
+	tests := []TestCase{
+		{
+			name:            "NoDrift",
+			modify:          func(test *datadogV1.SyntheticsAPITest) {},
+			expectedUpdated: false,
+		},
+		{
+			name: "TagsInDifferentOrder",
+			modify: func(test *datadogV1.SyntheticsAPITest) {
+				test.Tags = []string{"managed-by-cert-expiry-mon", "team:sre"}
 			},
-		}
-		tm.CreateManagedSyntheticsTests(endpoints)
-	}); strings.Contains(got, "Test is already existing for") == false {
-		t.Fatalf("want `Test is already existing for example.com-443 and Ingress exists`, got %s", got)
-	}
-	if got := captureOutput(func() {
-		endpoints := SyntheticEndpoints{
-			"nonexistinguri.com-443": SyntheticEndpoint{
-				Hostname: "nonexistinguri.com",
-				Port:     443,
+			expectedUpdated: false,
+		},
+		{
+			name: "CheckInterval",
+			modify: func(test *datadogV1.SyntheticsAPITest) {
+				options := test.GetOptions()
+				options.SetTickEvery(60)
+				test.SetOptions(options)
 			},
-		}
-		tm.CreateManagedSyntheticsTests(endpoints)
-	}); strings.Contains(got, "Creating new test for Ingress endpoint nonexistinguri.com") == false {
-		t.Fatalf("want test, got %s", got)
+			expectedUpdated: true,
+			expectedDiff:    FieldDiff{Field: "options.tick_every", Desired: "300", Actual: "60"},
+		},
+		{
+			name: "AlertMessage",
+			modify: func(test *datadogV1.SyntheticsAPITest) {
+				test.SetMessage("@old")
+			},
+			expectedUpdated: true,
+			expectedDiff:    FieldDiff{Field: "message", Desired: "@sre", Actual: "@old"},
+		},
+		{
+			name: "Locations",
+			modify: func(test *datadogV1.SyntheticsAPITest) {
+				test.Locations = []string{"aws:us-east-2"}
+			},
+			expectedUpdated: true,
+			expectedDiff:    FieldDiff{Field: "locations", Desired: "aws:ap-northeast-1", Actual: "aws:us-east-2"},
+		},
+		{
+			// Datadog returns numbers of assertion targets as float64.
+			name: "AssertionTargetAsFloat",
+			modify: func(test *datadogV1.SyntheticsAPITest) {
+				config := test.GetConfig()
+				config.Assertions[0].SyntheticsAssertionTarget.Target = float64(12)
+				test.SetConfig(config)
+			},
+			expectedUpdated: false,
+		},
 	}
 
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := tm.newManagedSyntheticsTest(endpoint.GetNormalizedName(), endpoint)
+			actual.SetPublicId("aaa-aaa-aaa")
+			test.modify(actual)
+
+			var updatedID string
+			tm.Client = &fakeClient{
+				t: t,
+				validateUpdateSyntheticsTestFunc: func(t *testing.T, publicId string, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
+					updatedID = publicId
+					options := syntheticsTest.GetOptions()
+					if got, want := options.GetTickEvery(), int64(300); got != want {
+						t.Fatalf("got %v, want %v", got, want)
+					}
+					return syntheticsTest, nil
+				},
+			}
+
+			core, recorded := observer.New(zapcore.InfoLevel)
+			tm.Logger = zap.New(core)
+
+			updated, err := tm.updateManagedSyntheticsTest(context.Background(), endpoint.GetNormalizedName(), *actual, endpoint)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err.Error())
+			}
+
+			if updated != test.expectedUpdated {
+				t.Fatalf("Unexpected updated: %v, expected %v", updated, test.expectedUpdated)
+			}
+			if test.expectedUpdated && updatedID != "aaa-aaa-aaa" {
+				t.Fatalf("Unexpected public ID of updated test: %s", updatedID)
+			}
+			if !test.expectedUpdated {
+				return
+			}
+			entries := recorded.FilterMessage("Synthetics test drifted").FilterField(zap.String("field", test.expectedDiff.Field)).All()
+			if len(entries) != 1 {
+				t.Fatalf("want a log of drift in %s, got %v", test.expectedDiff.Field, recorded.All())
+			}
+			fields := entries[0].ContextMap()
+			if fields["actual"] != test.expectedDiff.Actual || fields["desired"] != test.expectedDiff.Desired {
+				t.Fatalf("want %s, got %v", test.expectedDiff, fields)
+			}
+		})
+	}
 }
 
 func TestDeleteManagedSyntheticsTests(t *testing.T) {
 	client := &fakeClient{
 		t: t,
-		validateGetSyntheticsTestsFunc: func(t *testing.T) []datadog.SyntheticsTest {
-			test := new(datadog.SyntheticsTest)
+		validateGetSyntheticsTestsFunc: func(t *testing.T) []datadogV1.SyntheticsAPITest {
+			test := new(datadogV1.SyntheticsAPITest)
 			test.SetName("example.com-443")
 			test.SetPublicId("aaa-aaa-aaa")
 			test.Tags = append(test.Tags, "managed-by-cert-expiry-mon")
 
-			test2 := new(datadog.SyntheticsTest)
+			test2 := new(datadogV1.SyntheticsAPITest)
 			test.SetPublicId("bbb-bbb-bbb")
 			test2.SetName("example2.com-443")
 
-			test3 := new(datadog.SyntheticsTest)
+			test3 := new(datadogV1.SyntheticsAPITest)
 			test3.SetPublicId("ccc-ccc-ccc")
 			test3.SetName("example3.com-443")
 			test3.Tags = append(test.Tags, "managed-by-cert-expiry-mon")
 
-			tests := &[]datadog.SyntheticsTest{*test, *test2, *test3}
+			tests := &[]datadogV1.SyntheticsAPITest{*test, *test2, *test3}
 			return *tests
 		},
 		validateDeleteSyntheticsTestsFunc: func(t *testing.T, publicIds []string) error {
@@ -218,280 +424,330 @@ func TestDeleteManagedSyntheticsTests(t *testing.T) {
 
 	apiKey := "api_key"
 	appKey := "app_key"
-	tm, _ := NewTestManager(apiKey, appKey)
+	tm, _ := NewTestManager(apiKey, appKey, "")
 	tm.Client = client
 	tm.DefaultTag = "managed-by-cert-expiry-mon"
 
 	// Case 1: Only example3.com should be deleted, example.com is in the Ingress endpoint list and example2 doesn't have the managed tag
-	if got := captureOutput(func() {
-		endpoints := SyntheticEndpoints{
-			"example.com-443": SyntheticEndpoint{
-				Hostname: "example.com",
-				Port:     443,
-			},
-		}
-		tm.DeleteManagedSyntheticsTests(endpoints)
-	}); strings.Contains(got, "Managed test ccc-ccc-ccc, with hostname example3.com-443, doesn't have any matching ingress, adding to delete list") == false {
-		t.Fatalf("want `Managed test ccc-ccc-ccc, with hostname example3.com-443, doesn't have any matching ingress, adding to delete list`, got %s", got)
+	result, err := tm.DeleteManagedSyntheticsTests(context.Background(), synthetics.SyntheticEndpoints{
+		"example.com-443": synthetics.SyntheticEndpoint{
+			Hostname: "example.com",
+			Port:     443,
+		},
+	})
+	if err != nil || strings.Join(result.Deleted, ",") != "example3.com-443" {
+		t.Fatalf("want example3.com-443 deleted, got %v, %v", result.Deleted, err)
 	}
 	// Case 2: example.com and example3.com should be deleted, example2.com doesn't have the tag
-	if got := captureOutput(func() {
-		endpoints := SyntheticEndpoints{}
-		tm.DeleteManagedSyntheticsTests(endpoints)
-	}); strings.Contains(got, "Deleting 2 managed tests") == false {
-		t.Fatalf("want `Deleting 2 managed tests`, got %s", got)
-	}
-	// Case 3: Nothing should be deleted, expect no output
-	if got := captureOutput(func() {
-		endpoints := SyntheticEndpoints{
-			"example.com-443": SyntheticEndpoint{
-				Hostname: "example.com",
-				Port:     443,
-			},
-			"example3.com-443": SyntheticEndpoint{
-				Hostname: "example3.com",
-				Port:     443,
-			},
-		}
-		tm.DeleteManagedSyntheticsTests(endpoints)
-	}); strings.Contains(got, "No test candidate for deletion") == false {
-		t.Fatalf("want `No test candidate for deletion`, got %s", got)
-	}
-	// Case 4: example2.com should not be deleted as it doesn't have the managed tag, expect no output
-	if got := captureOutput(func() {
-		endpoints := SyntheticEndpoints{
-			"example2.com-443": SyntheticEndpoint{
-				Hostname: "example2.com",
-				Port:     443,
-			},
-		}
-
-		tm.DeleteManagedSyntheticsTests(endpoints)
-	}); strings.Contains(got, "re") == false {
-		t.Fatalf("want `Deleting 2 managed tests`, got %s", got)
+	result, err = tm.DeleteManagedSyntheticsTests(context.Background(), synthetics.SyntheticEndpoints{})
+	if err != nil || len(result.Deleted) != 2 {
+		t.Fatalf("want 2 tests deleted, got %v, %v", result.Deleted, err)
+	}
+	// Case 3: Nothing should be deleted
+	result, err = tm.DeleteManagedSyntheticsTests(context.Background(), synthetics.SyntheticEndpoints{
+		"example.com-443": synthetics.SyntheticEndpoint{
+			Hostname: "example.com",
+			Port:     443,
+		},
+		"example3.com-443": synthetics.SyntheticEndpoint{
+			Hostname: "example3.com",
+			Port:     443,
+		},
+	})
+	if err != nil || len(result.Deleted) != 0 {
+		t.Fatalf("want no test deleted, got %v, %v", result.Deleted, err)
+	}
+	// Case 4: example2.com should not be deleted as it doesn't have the managed tag
+	result, err = tm.DeleteManagedSyntheticsTests(context.Background(), synthetics.SyntheticEndpoints{
+		"example2.com-443": synthetics.SyntheticEndpoint{
+			Hostname: "example2.com",
+			Port:     443,
+		},
+	})
+	if err != nil || synthetics.Contains(result.Deleted, "example2.com-443") {
+		t.Fatalf("want example2.com-443 kept, got %v, %v", result.Deleted, err)
 	}
 }
 
-func captureOutput(f func()) string {
-	var buf bytes.Buffer
-	log.SetOutput(&buf)
-	f()
-	log.SetOutput(os.Stderr)
-	return buf.String()
-}
+func TestDeleteManagedSyntheticsTestsSafeguard(t *testing.T) {
+	makeTests := func(names ...string) []datadogV1.SyntheticsAPITest {
+		tests := make([]datadogV1.SyntheticsAPITest, len(names))
+		for i, name := range names {
+			tests[i].SetName(name + "-443")
+			tests[i].SetPublicId(name)
+			tests[i].Tags = []string{"managed-by-cert-expiry-mon"}
+		}
+		return tests
+	}
 
-func TestSyntheticEndpoint_GetNormalizedName(t *testing.T) {
-	type fields struct {
-		Hostname string
-		Port     int
+	type TestCase struct {
+		name            string
+		maxDeleteCount  int
+		maxDeleteRatio  float64
+		deleteAfterRuns int
+		endpoints       []string
+		runs            int
+		expectedDeleted []string
+		expectedErr     bool
 	}
-	tests := []struct {
-		name   string
-		fields fields
-		want   string
-	}{
+
+	tests := []TestCase{
 		{
-			name: "SimpleCase",
-			fields: fields{
-				Hostname: "test.com",
-				Port:     443,
-			},
-			want: "test.com-443",
+			name:            "WithoutLimits",
+			endpoints:       []string{},
+			runs:            1,
+			expectedDeleted: []string{"a", "b", "c", "d"},
 		},
 		{
-			name: "SimpleCase2",
-			fields: fields{
-				Hostname: "test2.com",
-				Port:     10000,
-			},
-			want: "test2.com-10000",
+			name:           "ExceedsCount",
+			maxDeleteCount: 1,
+			endpoints:      []string{"a", "b"},
+			runs:           1,
+			expectedErr:    true,
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			s := SyntheticEndpoint{
-				Hostname: tt.fields.Hostname,
-				Port:     tt.fields.Port,
-			}
-			if got := s.GetNormalizedName(); got != tt.want {
-				t.Errorf("GetNormalizedName() = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
-
-func TestSyntheticEndpoint_FromHostPortStr(t *testing.T) {
-	type args struct {
-		hostname string
-		port     string
-	}
-	tests := []struct {
-		name    string
-		args    args
-		want    SyntheticEndpoint
-		wantErr bool
-	}{
 		{
-			name: "SimpleCase",
-			args: args{
-				hostname: "example.com",
-				port:     "443",
-			},
-			want: SyntheticEndpoint{
-				Hostname: "example.com",
-				Port:     443,
-			},
-			wantErr: false,
+			name:           "ExceedsRatio",
+			maxDeleteRatio: 0.5,
+			endpoints:      []string{},
+			runs:           1,
+			expectedErr:    true,
 		},
 		{
-			name: "SimpleCase2",
-			args: args{
-				hostname: "example2.com",
-				port:     "1000",
-			},
-			want: SyntheticEndpoint{
-				Hostname: "example2.com",
-				Port:     1000,
-			},
-			wantErr: false,
+			name:            "WithinRatio",
+			maxDeleteRatio:  0.5,
+			endpoints:       []string{"a", "b"},
+			runs:            1,
+			expectedDeleted: []string{"c", "d"},
 		},
 		{
-			name: "MissingPort",
-			args: args{
-				hostname: "example.com",
-				port:     "",
-			},
-			want: SyntheticEndpoint{
-				Hostname: "",
-				Port:     0,
-			},
-			wantErr: true,
+			name:            "SingleDeletionIgnoresRatio",
+			maxDeleteRatio:  0.1,
+			endpoints:       []string{"a", "b", "c"},
+			runs:            1,
+			expectedDeleted: []string{"d"},
+		},
+		{
+			name:            "WaitingForGracePeriod",
+			deleteAfterRuns: 3,
+			endpoints:       []string{"a", "b", "c"},
+			runs:            2,
+			expectedDeleted: nil,
+		},
+		{
+			name:            "AfterGracePeriod",
+			deleteAfterRuns: 3,
+			endpoints:       []string{"a", "b", "c"},
+			runs:            3,
+			expectedDeleted: []string{"d"},
 		},
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			s := SyntheticEndpoint{
-				Hostname: "",
-				Port:     0,
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var deleted []string
+			client := &fakeClient{
+				t: t,
+				validateGetSyntheticsTestsFunc: func(t *testing.T) []datadogV1.SyntheticsAPITest {
+					return makeTests("a", "b", "c", "d")
+				},
+				validateDeleteSyntheticsTestsFunc: func(t *testing.T, publicIds []string) error {
+					deleted = append(deleted, publicIds...)
+					return nil
+				},
 			}
-			got, err := s.FromHostPortStr(tt.args.hostname, tt.args.port)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("FromHostPortStr() error = %v, wantErr %v", err, tt.wantErr)
-				return
+
+			tm := &TestManager{
+				Client:          client,
+				Logger:          zap.NewNop(),
+				DefaultTag:      "managed-by-cert-expiry-mon",
+				MaxDeleteCount:  test.maxDeleteCount,
+				MaxDeleteRatio:  test.maxDeleteRatio,
+				DeleteAfterRuns: test.deleteAfterRuns,
+			}
+
+			endpoints := synthetics.SyntheticEndpoints{}
+			for _, name := range test.endpoints {
+				endpoints.Add(synthetics.SyntheticEndpoint{Hostname: name, Port: 443})
+			}
+
+			var err error
+			for i := 0; i < test.runs; i++ {
+				_, err = tm.DeleteManagedSyntheticsTests(context.Background(), endpoints)
 			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("FromHostPortStr() got = %v, want %v", got, tt.want)
+
+			var massDeletion *synthetics.MassDeletionError
+			if errors.As(err, &massDeletion) != test.expectedErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if strings.Join(deleted, ",") != strings.Join(test.expectedDeleted, ",") {
+				t.Fatalf("Unexpected deleted tests: %v, expected %v", deleted, test.expectedDeleted)
 			}
 		})
 	}
 }
 
-func TestSyntheticEndpoint_FromString(t *testing.T) {
-	type args struct {
-		input string
+func TestDeleteManagedSyntheticsTestsResetsGracePeriod(t *testing.T) {
+	var deleted []string
+	client := &fakeClient{
+		t: t,
+		validateGetSyntheticsTestsFunc: func(t *testing.T) []datadogV1.SyntheticsAPITest {
+			test := datadogV1.SyntheticsAPITest{Tags: []string{"managed-by-cert-expiry-mon"}}
+			test.SetName("example.com-443")
+			test.SetPublicId("aaa-aaa-aaa")
+			return []datadogV1.SyntheticsAPITest{test}
+		},
+		validateDeleteSyntheticsTestsFunc: func(t *testing.T, publicIds []string) error {
+			deleted = append(deleted, publicIds...)
+			return nil
+		},
+	}
+	tm := &TestManager{Client: client, Logger: zap.NewNop(), DefaultTag: "managed-by-cert-expiry-mon", DeleteAfterRuns: 2}
+
+	present := synthetics.SyntheticEndpoints{}
+	present.Add(synthetics.SyntheticEndpoint{Hostname: "example.com", Port: 443})
+
+	// The endpoint reappears between missing runs, so the count starts over.
+	for _, endpoints := range []synthetics.SyntheticEndpoints{{}, present, {}} {
+		if _, err := tm.DeleteManagedSyntheticsTests(context.Background(), endpoints); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
 	}
-	tests := []struct {
-		name    string
-		args    args
-		want    SyntheticEndpoint
-		wantErr bool
-	}{
-		{
-			name: "SimpleCase",
-			args: args{
-				input: "example.com:443",
-			},
-			want: SyntheticEndpoint{
-				Hostname: "example.com",
-				Port:     443,
-			},
-			wantErr: false,
+	if len(deleted) != 0 {
+		t.Fatalf("Unexpected deleted tests: %v", deleted)
+	}
+
+	if _, err := tm.DeleteManagedSyntheticsTests(context.Background(), synthetics.SyntheticEndpoints{}); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if len(deleted) != 1 {
+		t.Fatalf("Unexpected deleted tests: %v", deleted)
+	}
+}
+
+func TestSyntheticsTestsOwnership(t *testing.T) {
+	makeTest := func(name string, publicID string, tags ...string) datadogV1.SyntheticsAPITest {
+		test := datadogV1.SyntheticsAPITest{Tags: append([]string{"managed-by-cert-expiry-mon"}, tags...)}
+		test.SetName(name)
+		test.SetPublicId(publicID)
+		return test
+	}
+
+	var created []string
+	updated := make(map[string]string)
+	var deleted []string
+	client := &fakeClient{
+		t: t,
+		validateGetSyntheticsTestsFunc: func(t *testing.T) []datadogV1.SyntheticsAPITest {
+			return []datadogV1.SyntheticsAPITest{
+				makeTest("tokyo/owned.example.com-443", "owned", "cluster:tokyo"),
+				makeTest("tokyo/stale.example.com-443", "stale", "cluster:tokyo"),
+				makeTest("osaka/owned.example.com-443", "other", "cluster:osaka"),
+				makeTest("legacy.example.com-443", "legacy"),
+				makeTest("unused.example.com-443", "unused"),
+			}
 		},
-		{
-			name: "SimpleCase2",
-			args: args{
-				input: "example2.com:1000",
-			},
-			want: SyntheticEndpoint{
-				Hostname: "example2.com",
-				Port:     1000,
-			},
-			wantErr: false,
+		validateCreateSyntheticsTestFunc: func(t *testing.T, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
+			created = append(created, syntheticsTest.GetName())
+			if !synthetics.Contains(syntheticsTest.Tags, "cluster:tokyo") {
+				t.Fatalf("Unexpected tags of created test: %v", syntheticsTest.Tags)
+			}
+			return syntheticsTest, nil
 		},
-		{
-			name: "MissingPort",
-			args: args{
-				input: "example.com:",
-			},
-			want: SyntheticEndpoint{
-				Hostname: "",
-				Port:     0,
-			},
-			wantErr: true,
+		validateUpdateSyntheticsTestFunc: func(t *testing.T, publicId string, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
+			updated[publicId] = syntheticsTest.GetName()
+			return syntheticsTest, nil
 		},
-		{
-			name: "InvalidPort1",
-			args: args{
-				input: "example.com:0",
-			},
-			want: SyntheticEndpoint{
-				Hostname: "",
-				Port:     0,
-			},
-			wantErr: true,
+		validateDeleteSyntheticsTestsFunc: func(t *testing.T, publicIds []string) error {
+			deleted = append(deleted, publicIds...)
+			return nil
 		},
+	}
+
+	tm := &TestManager{Client: client, Logger: zap.NewNop(), DefaultTag: "managed-by-cert-expiry-mon", ClusterName: "tokyo"}
+
+	endpoints := synthetics.SyntheticEndpoints{}
+	for _, host := range []string{"owned.example.com", "legacy.example.com", "new.example.com"} {
+		endpoints.Add(synthetics.SyntheticEndpoint{Hostname: host, Port: 443})
+	}
+
+	if _, err := tm.Reconcile(context.Background(), endpoints); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	if len(created) != 1 || created[0] != "tokyo/new.example.com-443" {
+		t.Fatalf("Unexpected created tests: %v", created)
+	}
+	// The legacy test of the endpoint is adopted with the name of the cluster.
+	if updated["legacy"] != "tokyo/legacy.example.com-443" {
+		t.Fatalf("Unexpected updated tests: %v", updated)
+	}
+	if _, ok := updated["other"]; ok {
+		t.Fatalf("Unexpected update of the test of other cluster: %v", updated)
+	}
+	// Tests of other clusters and legacy tests of other endpoints are never deleted.
+	if len(deleted) != 1 || deleted[0] != "stale" {
+		t.Fatalf("Unexpected deleted tests: %v", deleted)
+	}
+}
+
+func TestNewManagedSyntheticsTestSettings(t *testing.T) {
+	tm := &TestManager{
+		AlertMessage:     "Certificate is expiring",
+		CheckInterval:    900,
+		DefaultTag:       "managed-by-cert-expiry-mon",
+		DefaultLocations: []string{"aws:ap-northeast-1"},
+		PrivateLocations: []string{"pl:private-location"},
+		Tags:             []string{"env:prod"},
+	}
+
+	tests := []struct {
+		endpoint          synthetics.SyntheticEndpoint
+		expectedMessage   string
+		expectedInterval  int64
+		expectedLocations string
+		expectedTags      string
+	}{
 		{
-			name: "InvalidPort2",
-			args: args{
-				input: "example.com:hello",
-			},
-			want: SyntheticEndpoint{
-				Hostname: "",
-				Port:     0,
-			},
-			wantErr: true,
+			endpoint:          synthetics.SyntheticEndpoint{Hostname: "example.com", Port: 443},
+			expectedMessage:   "Certificate is expiring",
+			expectedInterval:  900,
+			expectedLocations: "aws:ap-northeast-1,pl:private-location",
+			expectedTags:      "env:prod,managed-by-cert-expiry-mon",
 		},
 		{
-			name: "MissingHostname",
-			args: args{
-				input: ":443",
-			},
-			want: SyntheticEndpoint{
-				Hostname: "",
-				Port:     0,
+			endpoint: synthetics.SyntheticEndpoint{
+				Hostname: "example.com",
+				Port:     443,
+				Settings: synthetics.Settings{
+					Locations:     []string{"aws:us-east-2"},
+					CheckInterval: 300,
+					Tags:          []string{"team:sre"},
+					AlertMessage:  "Certificate of api is expiring",
+					Mentions:      []string{"@slack-sre"},
+				},
 			},
-			wantErr: true,
+			expectedMessage:   "Certificate of api is expiring @slack-sre",
+			expectedInterval:  300,
+			expectedLocations: "aws:us-east-2",
+			expectedTags:      "env:prod,managed-by-cert-expiry-mon,team:sre",
 		},
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			s := SyntheticEndpoint{
-				Hostname: "",
-				Port:     0,
-			}
-			got, err := s.FromString(tt.args.input)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("FromString() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("FromString() got = %v, want %v", got, tt.want)
-			}
-		})
-	}
-}
 
-func TestSyntheticEndpoints_Add(t *testing.T) {
-	t.Run("AddAnEndpoint", func(t *testing.T) {
-		se := SyntheticEndpoints{}
+	for _, test := range tests {
+		got := tm.newManagedSyntheticsTest(test.endpoint.GetNormalizedName(), test.endpoint)
 
-		se.Add(SyntheticEndpoint{
-			Hostname: "test.com",
-			Port:     443,
-		})
-
-		if _, ok := se["test.com-443"]; !ok {
-			t.Errorf("Expected added endpoint to appear in the SyntheticEndpoints map")
+		if got.GetMessage() != test.expectedMessage {
+			t.Fatalf("Unexpected message: %q, expected %q", got.GetMessage(), test.expectedMessage)
 		}
-	})
+		options := got.GetOptions()
+		if options.GetTickEvery() != test.expectedInterval {
+			t.Fatalf("Unexpected tick_every: %d, expected %d", options.GetTickEvery(), test.expectedInterval)
+		}
+		if locations := sortedJoin(got.Locations); locations != test.expectedLocations {
+			t.Fatalf("Unexpected locations: %s, expected %s", locations, test.expectedLocations)
+		}
+		if tags := sortedJoin(got.Tags); tags != test.expectedTags {
+			t.Fatalf("Unexpected tags: %s, expected %s", tags, test.expectedTags)
+		}
+	}
 }
@@ -0,0 +1,68 @@
+package datadog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+)
+
+// FieldDiff expresses a field of synthetics test that differs between the desired and the actual spec.
+type FieldDiff struct {
+	Field   string
+	Desired string
+	Actual  string
+}
+
+func (d FieldDiff) String() string {
+	return fmt.Sprintf("%s: %q -> %q", d.Field, d.Actual, d.Desired)
+}
+
+// diffSyntheticsTest returns fields of actual that drifted from desired.
+// Only fields managed by TestManager are compared, and tags and locations are compared regardless of their order.
+func diffSyntheticsTest(desired, actual datadogV1.SyntheticsAPITest) []FieldDiff {
+	var diffs []FieldDiff
+	add := func(field, d, a string) {
+		if d != a {
+			diffs = append(diffs, FieldDiff{Field: field, Desired: d, Actual: a})
+		}
+	}
+
+	desiredConfig, actualConfig := desired.Config, actual.Config
+	desiredRequest, actualRequest := desiredConfig.GetRequest(), actualConfig.GetRequest()
+	desiredOptions, actualOptions := desired.GetOptions(), actual.GetOptions()
+
+	add("name", desired.GetName(), actual.GetName())
+	add("message", desired.GetMessage(), actual.GetMessage())
+	add("tags", sortedJoin(desired.Tags), sortedJoin(actual.Tags))
+	add("locations", sortedJoin(desired.Locations), sortedJoin(actual.Locations))
+	add("request.host", desiredRequest.GetHost(), actualRequest.GetHost())
+	add("request.port", desiredRequest.GetPort(), actualRequest.GetPort())
+	add("options.tick_every", fmt.Sprint(desiredOptions.GetTickEvery()), fmt.Sprint(actualOptions.GetTickEvery()))
+	add("options.accept_self_signed", fmt.Sprint(desiredOptions.GetAcceptSelfSigned()), fmt.Sprint(actualOptions.GetAcceptSelfSigned()))
+	add("assertions", formatAssertions(desiredConfig.Assertions), formatAssertions(actualConfig.Assertions))
+
+	return diffs
+}
+
+// formatAssertions returns assertions as `type operator target` separated by commas.
+// The target is formatted with %v, since Datadog returns numbers of targets as float64.
+// Assertions other than SyntheticsAssertionTarget are formatted as they are, since TestManager never sets them.
+func formatAssertions(assertions []datadogV1.SyntheticsAssertion) string {
+	formatted := make([]string, len(assertions))
+	for i, a := range assertions {
+		if t := a.SyntheticsAssertionTarget; t != nil {
+			formatted[i] = fmt.Sprintf("%s %s %v", t.Type, t.Operator, t.Target)
+		} else {
+			formatted[i] = fmt.Sprintf("%v", a.GetActualInstance())
+		}
+	}
+	return sortedJoin(formatted)
+}
+
+func sortedJoin(values []string) string {
+	sorted := append([]string{}, values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
@@ -0,0 +1,62 @@
+package datadog
+
+import (
+	"context"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	"go.uber.org/zap"
+)
+
+// DryRunClient is a Client that logs what would be created and deleted instead of calling Datadog.
+// Synthetics tests are still read from Client, so that the diff against Ingresses is calculated as usual.
+type DryRunClient struct {
+	Client Client
+	Logger *zap.Logger
+}
+
+// NewDryRunClient returns new instance of DryRunClient that wraps client.
+func NewDryRunClient(logger *zap.Logger, client Client) *DryRunClient {
+	return &DryRunClient{
+		Client: client,
+		Logger: logger,
+	}
+}
+
+// CreateSyntheticsTest logs the synthetics test and returns it without creating it.
+func (d *DryRunClient) CreateSyntheticsTest(ctx context.Context, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
+	request := syntheticsTest.Config.GetRequest()
+
+	d.Logger.Info("DRY RUN: Create synthetics test",
+		zap.String("name", syntheticsTest.GetName()),
+		zap.String("host", request.GetHost()),
+		zap.String("port", request.GetPort()),
+		zap.Strings("tags", syntheticsTest.Tags),
+		zap.Strings("locations", syntheticsTest.Locations),
+		zap.Int64("tickEvery", syntheticsTest.Options.GetTickEvery()),
+		zap.String("message", syntheticsTest.GetMessage()),
+	)
+	return syntheticsTest, nil
+}
+
+// GetSyntheticsTests returns synthetics tests of Client.
+func (d *DryRunClient) GetSyntheticsTests(ctx context.Context) ([]datadogV1.SyntheticsAPITest, error) {
+	return d.Client.GetSyntheticsTests(ctx)
+}
+
+// UpdateSyntheticsTest logs the synthetics test and returns it without updating it.
+func (d *DryRunClient) UpdateSyntheticsTest(ctx context.Context, publicId string, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
+	d.Logger.Info("DRY RUN: Update synthetics test",
+		zap.String("publicId", publicId),
+		zap.String("name", syntheticsTest.GetName()),
+	)
+	return syntheticsTest, nil
+}
+
+// DeleteSyntheticsTests logs publicIds without deleting them.
+func (d *DryRunClient) DeleteSyntheticsTests(ctx context.Context, publicIds []string) error {
+	d.Logger.Info("DRY RUN: Delete synthetics tests",
+		zap.Strings("publicIds", publicIds),
+		zap.Int("count", len(publicIds)),
+	)
+	return nil
+}
@@ -0,0 +1,68 @@
+package datadog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/synthetics"
+)
+
+func TestDryRunClient(t *testing.T) {
+	client := &fakeClient{
+		t: t,
+		validateGetSyntheticsTestsFunc: func(t *testing.T) []datadogV1.SyntheticsAPITest {
+			test := new(datadogV1.SyntheticsAPITest)
+			test.SetName("stale.example.com-443")
+			test.SetPublicId("aaa-aaa-aaa")
+			test.Tags = []string{"managed-by-cert-expiry-mon"}
+			return []datadogV1.SyntheticsAPITest{*test}
+		},
+		validateCreateSyntheticsTestFunc: func(t *testing.T, syntheticsTest *datadogV1.SyntheticsAPITest) (*datadogV1.SyntheticsAPITest, error) {
+			t.Fatalf("Unexpected call of CreateSyntheticsTest: %s", syntheticsTest.GetName())
+			return nil, nil
+		},
+		validateDeleteSyntheticsTestsFunc: func(t *testing.T, publicIds []string) error {
+			t.Fatalf("Unexpected call of DeleteSyntheticsTests: %v", publicIds)
+			return nil
+		},
+	}
+
+	core, recorded := observer.New(zapcore.InfoLevel)
+	tm := &TestManager{
+		Client:        NewDryRunClient(zap.New(core), client),
+		Logger:        zap.NewNop(),
+		DefaultTag:    "managed-by-cert-expiry-mon",
+		CheckInterval: 60,
+	}
+
+	endpoints := synthetics.SyntheticEndpoints{}
+	endpoints.Add(synthetics.SyntheticEndpoint{Hostname: "example.com", Port: 443})
+
+	if _, err := tm.CreateManagedSyntheticsTests(context.Background(), endpoints); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if _, err := tm.DeleteManagedSyntheticsTests(context.Background(), endpoints); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	creates := recorded.FilterMessage("DRY RUN: Create synthetics test")
+	if creates.Len() != 1 {
+		t.Fatalf("Unexpected number of create logs: %d", creates.Len())
+	}
+	if creates.FilterField(zap.String("host", "example.com")).Len() != 1 {
+		t.Fatalf("Unexpected create log: %v", creates.All()[0].Context)
+	}
+
+	deletes := recorded.FilterMessage("DRY RUN: Delete synthetics tests")
+	if deletes.Len() != 1 {
+		t.Fatalf("Unexpected number of delete logs: %d", deletes.Len())
+	}
+	if deletes.FilterField(zap.Strings("publicIds", []string{"aaa-aaa-aaa"})).Len() != 1 {
+		t.Fatalf("Unexpected delete log: %v", deletes.All()[0].Context)
+	}
+}
@@ -0,0 +1,45 @@
+package synthetics
+
+import (
+	"time"
+)
+
+// timeNow returns the current time. Overwritten in testing.
+var timeNow = time.Now
+
+// MissingRuns counts consecutive runs that endpoints of managed tests are missing, by ID of tests.
+// The zero value is ready to use.
+type MissingRuns struct {
+	missing map[string]missingRun
+}
+
+type missingRun struct {
+	count     int
+	countedAt time.Time
+}
+
+// Count records that endpoints of tests of ids are missing at the run, forgets the other tests, and returns the number of runs by ID.
+// A run is counted at most once per interval, so that runs triggered by changes of Ingresses between periodic runs
+// do not shorten the grace period before deletion. Zero interval counts every run.
+func (m *MissingRuns) Count(ids []string, interval time.Duration) map[string]int {
+	now := timeNow()
+	missing := make(map[string]missingRun, len(ids))
+	counts := make(map[string]int, len(ids))
+	for _, id := range ids {
+		run, ok := m.missing[id]
+		if !ok || now.Sub(run.countedAt) >= interval {
+			run.count++
+			run.countedAt = now
+		}
+		missing[id] = run
+		counts[id] = run.count
+	}
+	// Tests that reappeared or no longer exist are forgotten.
+	m.missing = missing
+	return counts
+}
+
+// Forget forgets the test of id, e.g. after the test is deleted.
+func (m *MissingRuns) Forget(id string) {
+	delete(m.missing, id)
+}
@@ -0,0 +1,42 @@
+package synthetics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMissingRunsCount(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	tests := []struct {
+		elapsed  time.Duration
+		ids      []string
+		expected map[string]int
+	}{
+		{elapsed: 0, ids: []string{"a", "b"}, expected: map[string]int{"a": 1, "b": 1}},
+		// Runs triggered within the interval are not counted.
+		{elapsed: time.Second, ids: []string{"a", "b"}, expected: map[string]int{"a": 1, "b": 1}},
+		{elapsed: 10 * time.Minute, ids: []string{"a", "b"}, expected: map[string]int{"a": 2, "b": 2}},
+		// Tests that reappeared are forgotten.
+		{elapsed: 10 * time.Minute, ids: []string{"a"}, expected: map[string]int{"a": 3}},
+		{elapsed: 10 * time.Minute, ids: []string{"a", "b"}, expected: map[string]int{"a": 4, "b": 1}},
+	}
+
+	var missing MissingRuns
+	for i, test := range tests {
+		now = now.Add(test.elapsed)
+		got := missing.Count(test.ids, 10*time.Minute)
+		for id, expected := range test.expected {
+			if got[id] != expected {
+				t.Fatalf("Unexpected runs of %s at run %d: %d, expected %d", id, i, got[id], expected)
+			}
+		}
+	}
+
+	missing.Forget("a")
+	if got := missing.Count([]string{"a"}, 0); got["a"] != 1 {
+		t.Fatalf("Unexpected runs of forgotten test: %d", got["a"])
+	}
+}
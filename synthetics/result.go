@@ -0,0 +1,43 @@
+package synthetics
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// Result holds outcomes of a reconciliation by names of tests.
+type Result struct {
+	// Created are tests created for new endpoints.
+	Created []string
+
+	// Updated are tests updated since they drifted from the configuration.
+	Updated []string
+
+	// Skipped are tests that are up to date.
+	Skipped []string
+
+	// Failed are tests that failed to be created, updated or deleted.
+	Failed []string
+
+	// Deleted are managed tests deleted since their endpoints no longer exist.
+	Deleted []string
+}
+
+// Merge returns the result with outcomes of other appended.
+func (r Result) Merge(other Result) Result {
+	r.Created = append(r.Created, other.Created...)
+	r.Updated = append(r.Updated, other.Updated...)
+	r.Skipped = append(r.Skipped, other.Skipped...)
+	r.Failed = append(r.Failed, other.Failed...)
+	r.Deleted = append(r.Deleted, other.Deleted...)
+	return r
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler interface, and logs the number of tests of each outcome.
+func (r Result) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("created", len(r.Created))
+	enc.AddInt("updated", len(r.Updated))
+	enc.AddInt("skipped", len(r.Skipped))
+	enc.AddInt("failed", len(r.Failed))
+	enc.AddInt("deleted", len(r.Deleted))
+	return nil
+}
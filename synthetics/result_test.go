@@ -0,0 +1,37 @@
+package synthetics
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestResultMerge(t *testing.T) {
+	created := Result{Created: []string{"a-443"}, Failed: []string{"b-443"}}
+	deleted := Result{Deleted: []string{"c-443"}, Failed: []string{"d-443"}}
+
+	got := created.Merge(deleted)
+	if len(got.Created) != 1 || len(got.Deleted) != 1 || len(got.Failed) != 2 {
+		t.Fatalf("Unexpected result: %+v", got)
+	}
+}
+
+func TestResultMarshalLogObject(t *testing.T) {
+	result := Result{
+		Created: []string{"a-443"},
+		Skipped: []string{"b-443", "c-443"},
+		Deleted: []string{"d-443"},
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	if err := result.MarshalLogObject(enc); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	expected := map[string]int{"created": 1, "updated": 0, "skipped": 2, "failed": 0, "deleted": 1}
+	for key, count := range expected {
+		if enc.Fields[key] != count {
+			t.Fatalf("Unexpected %s: %v, expected %d", key, enc.Fields[key], count)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package synthetics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AnnotationPrefix is the prefix of annotations of Ingress that configure synthetics tests of its endpoints.
+const AnnotationPrefix = "cert-expiry-monitor/synthetics-"
+
+// Annotations of Ingress that override settings of synthetics tests of its endpoints.
+const (
+	EnabledAnnotation       = "cert-expiry-monitor/synthetics-enabled"
+	LocationsAnnotation     = "cert-expiry-monitor/synthetics-locations"
+	CheckIntervalAnnotation = "cert-expiry-monitor/synthetics-check-interval"
+	TagsAnnotation          = "cert-expiry-monitor/synthetics-tags"
+	AlertMessageAnnotation  = "cert-expiry-monitor/synthetics-alert-message"
+	MentionsAnnotation      = "cert-expiry-monitor/synthetics-mentions"
+)
+
+// minCheckInterval is the lowest interval in seconds supported by providers.
+const minCheckInterval = 60
+
+// ClusterTagPrefix is the prefix of the tag that providers use to identify the cluster owning tests.
+const ClusterTagPrefix = "cluster:"
+
+// reservedTagPrefixes are prefixes of tags that providers use to identify the owner of tests.
+var reservedTagPrefixes = []string{ClusterTagPrefix}
+
+// Settings expresses settings of synthetics tests of an endpoint.
+// Zero values fall back to the settings of the provider.
+type Settings struct {
+	// Locations replace the locations of the provider, including private locations.
+	Locations []string
+
+	// CheckInterval replaces the interval of the provider in seconds.
+	CheckInterval int
+
+	// Tags are added to the tags of the provider.
+	Tags []string
+
+	// AlertMessage replaces the alert message of the provider.
+	AlertMessage string
+
+	// Mentions are appended to the alert message, e.g. `@slack-sre`.
+	Mentions []string
+
+	// Assertions override the default assertions of the provider.
+	Assertions Assertions
+}
+
+// EnabledFromAnnotations returns false if annotations of Ingress opt its endpoints out of synthetics tests.
+func EnabledFromAnnotations(annotations map[string]string) (bool, error) {
+	v, ok := annotations[EnabledAnnotation]
+	if !ok {
+		return true, nil
+	}
+
+	enabled, err := strconv.ParseBool(v)
+	if err != nil {
+		return true, fmt.Errorf("%s must be true or false: %s", EnabledAnnotation, v)
+	}
+	return enabled, nil
+}
+
+// SettingsFromAnnotations returns settings set by annotations of Ingress.
+func SettingsFromAnnotations(annotations map[string]string) (Settings, error) {
+	var s Settings
+
+	if v, ok := annotations[LocationsAnnotation]; ok {
+		s.Locations = splitList(v)
+		if len(s.Locations) == 0 {
+			return Settings{}, fmt.Errorf("%s must not be empty", LocationsAnnotation)
+		}
+	}
+
+	if v, ok := annotations[CheckIntervalAnnotation]; ok {
+		interval, err := strconv.Atoi(v)
+		if err != nil || interval < minCheckInterval {
+			return Settings{}, fmt.Errorf("%s must be an integer of %d or more: %s", CheckIntervalAnnotation, minCheckInterval, v)
+		}
+		s.CheckInterval = interval
+	}
+
+	if v, ok := annotations[TagsAnnotation]; ok {
+		s.Tags = splitList(v)
+		if err := ValidateTags(s.Tags); err != nil {
+			return Settings{}, fmt.Errorf("%s %s", TagsAnnotation, err.Error())
+		}
+	}
+
+	s.AlertMessage = annotations[AlertMessageAnnotation]
+
+	if v, ok := annotations[MentionsAnnotation]; ok {
+		s.Mentions = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+		for _, mention := range s.Mentions {
+			if !strings.HasPrefix(mention, "@") || len(mention) == 1 {
+				return Settings{}, fmt.Errorf("%s must be handles starting with @: %s", MentionsAnnotation, mention)
+			}
+		}
+	}
+
+	assertions, err := AssertionsFromAnnotations(annotations)
+	if err != nil {
+		return Settings{}, err
+	}
+	s.Assertions = assertions
+
+	return s, nil
+}
+
+// ValidateTags returns error if tags contain tags prefixed by reserved prefixes,
+// which would make tests look owned by another cluster.
+func ValidateTags(tags []string) error {
+	for _, tag := range tags {
+		for _, prefix := range reservedTagPrefixes {
+			if strings.HasPrefix(tag, prefix) {
+				return fmt.Errorf("must not contain tags prefixed by %s: %s", prefix, tag)
+			}
+		}
+	}
+	return nil
+}
+
+// Message returns the alert message of the endpoint, falling back to defaultMessage, followed by Mentions.
+func (s Settings) Message(defaultMessage string) string {
+	message := s.AlertMessage
+	if message == "" {
+		message = defaultMessage
+	}
+	if len(s.Mentions) == 0 {
+		return message
+	}
+	return strings.TrimSpace(message + " " + strings.Join(s.Mentions, " "))
+}
+
+// splitList returns non empty values separated by commas.
+func splitList(v string) []string {
+	var values []string
+	for _, value := range strings.Split(v, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,116 @@
+package synthetics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnabledFromAnnotations(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		expected    bool
+		expectedErr bool
+	}{
+		{annotations: map[string]string{}, expected: true},
+		{annotations: map[string]string{EnabledAnnotation: "true"}, expected: true},
+		{annotations: map[string]string{EnabledAnnotation: "false"}, expected: false},
+		{annotations: map[string]string{EnabledAnnotation: "no"}, expected: true, expectedErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := EnabledFromAnnotations(test.annotations)
+		if (err != nil) != test.expectedErr {
+			t.Fatalf("Unexpected error of %v: %v", test.annotations, err)
+		}
+		if got != test.expected {
+			t.Fatalf("Unexpected enabled of %v: %v, expected %v", test.annotations, got, test.expected)
+		}
+	}
+}
+
+func TestSettingsFromAnnotations(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		expected    Settings
+		expectedErr bool
+	}{
+		{
+			annotations: map[string]string{},
+			expected:    Settings{},
+		},
+		{
+			annotations: map[string]string{
+				LocationsAnnotation:       "aws:us-east-2, pl:private-location",
+				CheckIntervalAnnotation:   "300",
+				TagsAnnotation:            "team:sre,service:api,",
+				AlertMessageAnnotation:    "Certificate of api is expiring",
+				MentionsAnnotation:        "@slack-sre, @pagerduty-api",
+				CertificateDaysAnnotation: "30",
+			},
+			expected: Settings{
+				Locations:     []string{"aws:us-east-2", "pl:private-location"},
+				CheckInterval: 300,
+				Tags:          []string{"team:sre", "service:api"},
+				AlertMessage:  "Certificate of api is expiring",
+				Mentions:      []string{"@slack-sre", "@pagerduty-api"},
+				Assertions:    Assertions{CertificateDays: 30},
+			},
+		},
+		{
+			annotations: map[string]string{LocationsAnnotation: " , "},
+			expectedErr: true,
+		},
+		{
+			annotations: map[string]string{CheckIntervalAnnotation: "30"},
+			expectedErr: true,
+		},
+		{
+			annotations: map[string]string{CheckIntervalAnnotation: "5m"},
+			expectedErr: true,
+		},
+		{
+			annotations: map[string]string{TagsAnnotation: "cluster:other"},
+			expectedErr: true,
+		},
+		{
+			annotations: map[string]string{MentionsAnnotation: "slack-sre"},
+			expectedErr: true,
+		},
+		{
+			annotations: map[string]string{CertificateDaysAnnotation: "-1"},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := SettingsFromAnnotations(test.annotations)
+		if (err != nil) != test.expectedErr {
+			t.Fatalf("Unexpected error of %v: %v", test.annotations, err)
+		}
+		if test.expectedErr {
+			continue
+		}
+
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Fatalf("Unexpected settings: %+v, expected %+v", got, test.expected)
+		}
+	}
+}
+
+func TestSettingsMessage(t *testing.T) {
+	tests := []struct {
+		settings Settings
+		expected string
+	}{
+		{settings: Settings{}, expected: "default"},
+		{settings: Settings{AlertMessage: "custom"}, expected: "custom"},
+		{settings: Settings{Mentions: []string{"@slack-sre", "@pagerduty-api"}}, expected: "default @slack-sre @pagerduty-api"},
+		{settings: Settings{AlertMessage: "custom", Mentions: []string{"@slack-sre"}}, expected: "custom @slack-sre"},
+	}
+
+	for _, test := range tests {
+		if got := test.settings.Message("default"); got != test.expected {
+			t.Fatalf("Unexpected message of %+v: %q, expected %q", test.settings, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package synthetics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mercari/certificate-expiry-monitor-controller/hostport"
+)
+
+// defaultPort is the port of endpoints without port.
+const defaultPort = 443
+
+// SyntheticsProvider is an interface that synthetics monitoring services implement
+// to synchronize their tests with endpoints of Kubernetes Ingresses.
+type SyntheticsProvider interface {
+	// Reconcile creates and updates tests of endpoints, and deletes managed tests of endpoints that no longer exist.
+	// Failures of tests do not stop the reconciliation of others, and are returned as an aggregated error with Result.
+	// It returns MassDeletionError when the deletion is refused by the safeguard.
+	Reconcile(ctx context.Context, endpoints SyntheticEndpoints) (Result, error)
+}
+
+type SyntheticEndpoint struct {
+	Hostname string
+	Port     int
+
+	// Settings override the settings of the provider for the endpoint.
+	Settings
+}
+
+type SyntheticEndpoints map[string]SyntheticEndpoint
+
+func (s SyntheticEndpoint) GetNormalizedName() string {
+	return fmt.Sprintf("%s-%d", s.Hostname, s.Port)
+}
+
+// FromHostPortStr returns the endpoint of hostname and port, e.g. of TLS endpoints of Ingresses.
+// Hostname can be an IPv6 literal with or without brackets.
+func (s SyntheticEndpoint) FromHostPortStr(hostname string, port string) (SyntheticEndpoint, error) {
+	host, err := hostport.NormalizeHost(hostname)
+	if err != nil {
+		return s, err
+	}
+
+	p, err := hostport.ParsePort(port)
+	if err != nil {
+		return s, err
+	}
+
+	s.Hostname = host
+	s.Port = p
+
+	return s, nil
+}
+
+// FromString returns the endpoint of input formatted as `host`, `host:port`, `[IPv6]` or `[IPv6]:port`.
+// The port defaults to 443, and internationalized hostnames are converted to punycode.
+func (s SyntheticEndpoint) FromString(input string) (SyntheticEndpoint, error) {
+	host, port, err := hostport.Parse(input, defaultPort)
+	if err != nil {
+		return s, errors.New("Invalid endpoint " + input + ": " + err.Error())
+	}
+
+	s.Hostname = host
+	s.Port = port
+
+	return s, nil
+}
+
+// ValidateEndpoints returns error if any of inputs is not a valid endpoint for FromString.
+func ValidateEndpoints(inputs []string) error {
+	for _, input := range inputs {
+		if _, err := (SyntheticEndpoint{}).FromString(input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (se SyntheticEndpoints) Add(s SyntheticEndpoint) {
+	se[s.GetNormalizedName()] = s
+}
+
+// Contains return whether a slice contains a specific value
+func Contains(slice []string, val string) bool {
+	for _, n := range slice {
+		if val == n {
+			return true
+		}
+	}
+	return false
+}
+
+// MassDeletionError is returned when managed tests to delete exceed MaxDeleteCount or MaxDeleteRatio.
+// It protects synthetics tests from transient empty results of Ingresses, e.g. by a regression of RBAC.
+type MassDeletionError struct {
+	Count   int
+	Managed int
+	Limit   string
+}
+
+func (e *MassDeletionError) Error() string {
+	return fmt.Sprintf("deleting %d of %d managed synthetics tests exceeds %s", e.Count, e.Managed, e.Limit)
+}
+
+// CheckMassDeletion returns MassDeletionError if deleting count of managed tests exceeds maxCount or maxRatio.
+// Zero disables each limit. The ratio is not applied to a single deletion, so that the last test of small clusters can be deleted.
+func CheckMassDeletion(count int, managed int, maxCount int, maxRatio float64) error {
+	if maxCount > 0 && count > maxCount {
+		return &MassDeletionError{Count: count, Managed: managed, Limit: fmt.Sprintf("the maximum count %d", maxCount)}
+	}
+	if maxRatio > 0 && count > 1 && float64(count) > float64(managed)*maxRatio {
+		return &MassDeletionError{Count: count, Managed: managed, Limit: fmt.Sprintf("the maximum ratio %g", maxRatio)}
+	}
+	return nil
+}